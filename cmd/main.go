@@ -1,22 +1,36 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"html/template"
 	"io"
+	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	echoMiddleware "github.com/labstack/echo/v4/middleware"
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/nrbernard/gator/internal/database"
+	"github.com/nrbernard/gator/internal/feedparser"
 	"github.com/nrbernard/gator/internal/handler"
 	"github.com/nrbernard/gator/internal/middleware"
 	"github.com/nrbernard/gator/internal/models"
+	"github.com/nrbernard/gator/internal/scheduler"
 	"github.com/nrbernard/gator/internal/service"
 )
 
+const defaultRefreshInterval = 15 * time.Minute
+
 type Template struct {
 	tmpl *template.Template
 }
@@ -35,6 +49,107 @@ type Page struct {
 	Posts []models.Post
 }
 
+// envInt reads an integer from the environment, falling back to def when the
+// variable is unset.
+func envInt(key string, def int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return def, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", key, value, err)
+	}
+	return n, nil
+}
+
+// sqliteDSN enables WAL and a busy timeout so concurrent feed fetches and
+// page loads wait for the write lock instead of failing with SQLITE_BUSY. It
+// also turns on foreign keys so deleting a feed cascades to its posts.
+func sqliteDSN(path string) string {
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	return path + sep + "_busy_timeout=5000&_journal_mode=WAL&_foreign_keys=1"
+}
+
+// envDuration reads a duration such as "30m" from the environment, falling
+// back to def when the variable is unset.
+func envDuration(key string, def time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return def, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", key, value, err)
+	}
+	return d, nil
+}
+
+// credentialsKey reads the key feed credentials are encrypted with: 32
+// random bytes in base64, e.g. from `openssl rand -base64 32`. Without it,
+// feeds can't be given credentials.
+func credentialsKey() ([]byte, error) {
+	value := os.Getenv("FEED_CREDENTIALS_KEY")
+	if value == "" {
+		return nil, nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid FEED_CREDENTIALS_KEY: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("invalid FEED_CREDENTIALS_KEY: expected 32 bytes, got %d", len(key))
+	}
+	return key, nil
+}
+
+// newFetcher configures how feeds are downloaded. Settings left unset use
+// the fetcher's defaults.
+func newFetcher(timeout time.Duration) (*feedparser.Fetcher, error) {
+	connectTimeout, err := envDuration("FEED_CONNECT_TIMEOUT", 0)
+	if err != nil {
+		return nil, err
+	}
+	headerTimeout, err := envDuration("FEED_HEADER_TIMEOUT", 0)
+	if err != nil {
+		return nil, err
+	}
+	maxBodySize, err := envInt("FEED_MAX_BODY_BYTES", 0)
+	if err != nil {
+		return nil, err
+	}
+	maxDecompressedSize, err := envInt("FEED_MAX_DECOMPRESSED_BYTES", 0)
+	if err != nil {
+		return nil, err
+	}
+	maxRedirects, err := envInt("FEED_MAX_REDIRECTS", 0)
+	if err != nil {
+		return nil, err
+	}
+	// Feeds are only fetched from public addresses unless the network is
+	// allowlisted, e.g. FEED_ALLOWED_NETWORKS=10.0.0.0/8
+	allowedNetworks, err := feedparser.ParseNetworks(os.Getenv("FEED_ALLOWED_NETWORKS"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid FEED_ALLOWED_NETWORKS: %w", err)
+	}
+
+	return &feedparser.Fetcher{
+		ConnectTimeout:      connectTimeout,
+		HeaderTimeout:       headerTimeout,
+		Timeout:             timeout,
+		MaxBodySize:         int64(maxBodySize),
+		MaxDecompressedSize: int64(maxDecompressedSize),
+		MaxRedirects:        maxRedirects,
+		AllowedNetworks:     allowedNetworks,
+	}, nil
+}
+
 func main() {
 	e := echo.New()
 	e.Renderer = newTemplate()
@@ -42,21 +157,91 @@ func main() {
 	e.Static("/static", "static")
 
 	dbPath := os.Getenv("DATABASE_PATH")
-	db, err := sql.Open("sqlite3", dbPath)
+	db, err := sql.Open("sqlite3", sqliteDSN(dbPath))
 	if err != nil {
 		fmt.Printf("Failed to connect to database: %s\n", err)
 		os.Exit(1)
 	}
 
+	// Post search relies on FTS5, which go-sqlite3 only includes when built
+	// with the sqlite_fts5 tag
+	var hasFTS5 bool
+	if err := db.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&hasFTS5); err != nil || !hasFTS5 {
+		fmt.Println("SQLite was built without FTS5; rebuild gator with -tags sqlite_fts5")
+		os.Exit(1)
+	}
+
 	dbQueries := database.New(db)
 
+	maxConcurrency, err := envInt("FEED_MAX_CONCURRENCY", 0)
+	if err != nil {
+		fmt.Printf("Failed to read feed concurrency: %s\n", err)
+		os.Exit(1)
+	}
+	maxPerHost, err := envInt("FEED_MAX_PER_HOST", 0)
+	if err != nil {
+		fmt.Printf("Failed to read per-host concurrency: %s\n", err)
+		os.Exit(1)
+	}
+	fetchTimeout, err := envDuration("FEED_FETCH_TIMEOUT", 0)
+	if err != nil {
+		fmt.Printf("Failed to read feed fetch timeout: %s\n", err)
+		os.Exit(1)
+	}
+
+	minFetchInterval, err := envDuration("FEED_MIN_FETCH_INTERVAL", 0)
+	if err != nil {
+		fmt.Printf("Failed to read minimum fetch interval: %s\n", err)
+		os.Exit(1)
+	}
+	maxFetchInterval, err := envDuration("FEED_MAX_FETCH_INTERVAL", 0)
+	if err != nil {
+		fmt.Printf("Failed to read maximum fetch interval: %s\n", err)
+		os.Exit(1)
+	}
+
+	deadAfter, err := envDuration("FEED_DEAD_AFTER", 0)
+	if err != nil {
+		fmt.Printf("Failed to read dead feed threshold: %s\n", err)
+		os.Exit(1)
+	}
+
+	credsKey, err := credentialsKey()
+	if err != nil {
+		fmt.Printf("Failed to read feed credentials key: %s\n", err)
+		os.Exit(1)
+	}
+
+	fetcher, err := newFetcher(fetchTimeout)
+	if err != nil {
+		fmt.Printf("Failed to read feed fetcher settings: %s\n", err)
+		os.Exit(1)
+	}
+
 	userService := &service.UserService{Repo: dbQueries}
 	postService := &service.PostService{Repo: dbQueries}
-	feedService := &service.FeedService{Repo: dbQueries}
+	feedService := &service.FeedService{
+		Repo:             dbQueries,
+		MaxConcurrency:   maxConcurrency,
+		MaxPerHost:       maxPerHost,
+		FetchTimeout:     fetchTimeout,
+		MinFetchInterval: minFetchInterval,
+		MaxFetchInterval: maxFetchInterval,
+		Fetcher:          fetcher,
+		DeadAfter:        deadAfter,
+		CredentialsKey:   credsKey,
+		DB:               db,
+	}
 	savedPostService := &service.SavedPostService{Repo: dbQueries}
 	readPostService := &service.ReadPostService{Repo: dbQueries}
+	sessionService := &service.SessionService{Repo: dbQueries}
+	apiTokenService := &service.APITokenService{Repo: dbQueries}
 
-	e.Use(middleware.CurrentUser(userService))
+	authHandler, err := handler.NewAuthHandler(userService, sessionService)
+	if err != nil {
+		fmt.Printf("Failed to create auth handler: %s\n", err)
+		os.Exit(1)
+	}
 
 	postHandler, err := handler.NewPostHandler(postService, userService, feedService)
 	if err != nil {
@@ -82,24 +267,107 @@ func main() {
 		os.Exit(1)
 	}
 
-	e.GET("/", func(c echo.Context) error {
+	tokenHandler, err := handler.NewTokenHandler(apiTokenService)
+	if err != nil {
+		fmt.Printf("Failed to create token handler: %s\n", err)
+		os.Exit(1)
+	}
+
+	apiHandler, err := handler.NewAPIHandler(postService, feedService, savedPostService, readPostService)
+	if err != nil {
+		fmt.Printf("Failed to create API handler: %s\n", err)
+		os.Exit(1)
+	}
+
+	e.GET("/login", authHandler.LoginPage)
+	e.POST("/login", authHandler.Login)
+	e.GET("/signup", authHandler.SignupPage)
+	e.POST("/signup", authHandler.Signup)
+	e.POST("/logout", authHandler.Logout)
+
+	app := e.Group("", middleware.CurrentUser(sessionService))
+
+	app.GET("/", func(c echo.Context) error {
 		return c.Redirect(301, "/posts")
 	})
 
-	e.GET("/posts", postHandler.Index)
+	app.GET("/posts", postHandler.Index)
+	app.GET("/posts/:id/content", postHandler.Content)
+
+	app.POST("/saved-posts/:id", savedPostHandler.Save)
+	app.DELETE("/saved-posts/:id", savedPostHandler.Delete)
 
-	e.POST("/saved-posts/:id", savedPostHandler.Save)
-	e.DELETE("/saved-posts/:id", savedPostHandler.Delete)
+	app.POST("/read-posts/:id", readPostHandler.Save)
 
-	e.POST("/read-posts/:id", readPostHandler.Save)
+	app.POST("/posts/refresh", postHandler.Refresh)
 
-	e.POST("/posts/refresh", postHandler.Refresh)
+	app.POST("/search", postHandler.Search)
 
-	e.POST("/search", postHandler.Search)
+	app.GET("/feeds", feedHandler.Index)
+	app.POST("/feeds", feedHandler.Create)
+	app.POST("/feeds/preview", feedHandler.Preview)
+	app.POST("/feeds/import", feedHandler.Import)
+	app.GET("/feeds/export.opml", feedHandler.Export)
+	app.GET("/feeds/:id/edit", feedHandler.Edit)
+	app.PUT("/feeds/:id", feedHandler.Update)
+	app.DELETE("/feeds/:id", feedHandler.Delete)
 
-	e.GET("/feeds", feedHandler.Index)
-	e.POST("/feeds", feedHandler.Create)
-	e.DELETE("/feeds/:id", feedHandler.Delete)
+	app.GET("/tokens", tokenHandler.Index)
+	app.POST("/tokens", tokenHandler.Create)
+	app.DELETE("/tokens/:id", tokenHandler.Delete)
+
+	api := e.Group("/api/v1", middleware.APIToken(apiTokenService))
+
+	api.GET("/feeds", apiHandler.ListFeeds)
+	api.POST("/feeds", apiHandler.CreateFeed)
+	api.DELETE("/feeds/:id", apiHandler.DeleteFeed)
+
+	api.GET("/posts", apiHandler.ListPosts)
+	api.GET("/search", apiHandler.Search)
+
+	api.PUT("/posts/:id/saved", apiHandler.SavePost)
+	api.DELETE("/posts/:id/saved", apiHandler.UnsavePost)
+	api.PUT("/posts/:id/read", apiHandler.MarkRead)
+	api.DELETE("/posts/:id/read", apiHandler.MarkUnread)
+
+	refreshInterval, err := envDuration("FEED_REFRESH_INTERVAL", defaultRefreshInterval)
+	if err != nil {
+		fmt.Printf("Failed to read refresh interval: %s\n", err)
+		os.Exit(1)
+	}
+
+	feedScheduler, err := scheduler.NewScheduler(feedService, refreshInterval)
+	if err != nil {
+		fmt.Printf("Failed to create feed scheduler: %s\n", err)
+		os.Exit(1)
+	}
+	feedScheduler.Sessions = sessionService
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		feedScheduler.Run(ctx)
+	}()
+
+	go func() {
+		if err := e.Start(":8080"); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Printf("Server stopped: %s\n", err)
+			stop()
+		}
+	}()
+
+	<-ctx.Done()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := e.Shutdown(shutdownCtx); err != nil {
+		fmt.Printf("Failed to shut down server: %s\n", err)
+	}
 
-	e.Start(":8080")
+	wg.Wait()
+	db.Close()
 }
@@ -11,6 +11,34 @@ import (
 	"time"
 )
 
+const clearFeedRedirect = `-- name: ClearFeedRedirect :exec
+UPDATE feeds
+SET redirect_url = NULL, redirect_count = 0, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+func (q *Queries) ClearFeedRedirect(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, clearFeedRedirect, id)
+	return err
+}
+
+const countOtherFeedFollowers = `-- name: CountOtherFeedFollowers :one
+SELECT count(*) FROM feed_follows
+WHERE feed_id = ?1 AND user_id != ?2
+`
+
+type CountOtherFeedFollowersParams struct {
+	FeedID string
+	UserID string
+}
+
+func (q *Queries) CountOtherFeedFollowers(ctx context.Context, arg CountOtherFeedFollowersParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countOtherFeedFollowers, arg.FeedID, arg.UserID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createFeed = `-- name: CreateFeed :one
 INSERT INTO feeds (id, name, url, description, user_id)
 VALUES (
@@ -20,7 +48,7 @@ VALUES (
     ?,
     ? 
 )
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, description, etag, last_modified
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, description, etag, last_modified, last_error, consecutive_failures, last_success_at, next_fetch_after, fetch_interval_seconds, fetch_interval_override_seconds, skip_hours, skip_days, redirect_url, redirect_count, dead_at, credentials
 `
 
 type CreateFeedParams struct {
@@ -28,7 +56,7 @@ type CreateFeedParams struct {
 	Name        string
 	Url         string
 	Description sql.NullString
-	UserID      string
+	UserID      sql.NullString
 }
 
 func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, error) {
@@ -51,6 +79,18 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.Description,
 		&i.Etag,
 		&i.LastModified,
+		&i.LastError,
+		&i.ConsecutiveFailures,
+		&i.LastSuccessAt,
+		&i.NextFetchAfter,
+		&i.FetchIntervalSeconds,
+		&i.FetchIntervalOverrideSeconds,
+		&i.SkipHours,
+		&i.SkipDays,
+		&i.RedirectUrl,
+		&i.RedirectCount,
+		&i.DeadAt,
+		&i.Credentials,
 	)
 	return i, err
 }
@@ -58,7 +98,7 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 const createFeedFollow = `-- name: CreateFeedFollow :one
 INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
 VALUES (?, ?, ?, ?, ?)
-RETURNING id, created_at, updated_at, user_id, feed_id
+RETURNING id, created_at, updated_at, user_id, feed_id, folder, fetch_interval_override_seconds, mark_unread_on_update
 `
 
 type CreateFeedFollowParams struct {
@@ -84,6 +124,9 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.FeedID,
+		&i.Folder,
+		&i.FetchIntervalOverrideSeconds,
+		&i.MarkUnreadOnUpdate,
 	)
 	return i, err
 }
@@ -97,22 +140,95 @@ func (q *Queries) DeleteFeed(ctx context.Context, id string) error {
 	return err
 }
 
-const deleteFeedFollow = `-- name: DeleteFeedFollow :exec
-DELETE FROM feed_follows WHERE feed_follows.user_id = ? AND feed_follows.feed_id = (SELECT id FROM feeds WHERE url = ?)
+const deleteFeedFollow = `-- name: DeleteFeedFollow :execrows
+DELETE FROM feed_follows WHERE feed_follows.user_id = ? AND feed_follows.feed_id = ?
 `
 
 type DeleteFeedFollowParams struct {
 	UserID string
-	Url    string
+	FeedID string
 }
 
-func (q *Queries) DeleteFeedFollow(ctx context.Context, arg DeleteFeedFollowParams) error {
-	_, err := q.db.ExecContext(ctx, deleteFeedFollow, arg.UserID, arg.Url)
+func (q *Queries) DeleteFeedFollow(ctx context.Context, arg DeleteFeedFollowParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteFeedFollow, arg.UserID, arg.FeedID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteFeedIfUnfollowed = `-- name: DeleteFeedIfUnfollowed :exec
+DELETE FROM feeds
+WHERE id = ? AND NOT EXISTS (SELECT 1 FROM feed_follows WHERE feed_follows.feed_id = feeds.id)
+`
+
+func (q *Queries) DeleteFeedIfUnfollowed(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, deleteFeedIfUnfollowed, id)
 	return err
 }
 
+const followFeed = `-- name: FollowFeed :execrows
+INSERT INTO feed_follows (id, user_id, feed_id, folder)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (user_id, feed_id) DO NOTHING
+`
+
+type FollowFeedParams struct {
+	ID     string
+	UserID string
+	FeedID string
+	Folder sql.NullString
+}
+
+func (q *Queries) FollowFeed(ctx context.Context, arg FollowFeedParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, followFeed,
+		arg.ID,
+		arg.UserID,
+		arg.FeedID,
+		arg.Folder,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getFeedByID = `-- name: GetFeedByID :one
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, description, etag, last_modified, last_error, consecutive_failures, last_success_at, next_fetch_after, fetch_interval_seconds, fetch_interval_override_seconds, skip_hours, skip_days, redirect_url, redirect_count, dead_at, credentials FROM feeds WHERE id = ?
+`
+
+func (q *Queries) GetFeedByID(ctx context.Context, id string) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getFeedByID, id)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Description,
+		&i.Etag,
+		&i.LastModified,
+		&i.LastError,
+		&i.ConsecutiveFailures,
+		&i.LastSuccessAt,
+		&i.NextFetchAfter,
+		&i.FetchIntervalSeconds,
+		&i.FetchIntervalOverrideSeconds,
+		&i.SkipHours,
+		&i.SkipDays,
+		&i.RedirectUrl,
+		&i.RedirectCount,
+		&i.DeadAt,
+		&i.Credentials,
+	)
+	return i, err
+}
+
 const getFeedByUrl = `-- name: GetFeedByUrl :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, description, etag, last_modified FROM feeds WHERE url = ?
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, description, etag, last_modified, last_error, consecutive_failures, last_success_at, next_fetch_after, fetch_interval_seconds, fetch_interval_override_seconds, skip_hours, skip_days, redirect_url, redirect_count, dead_at, credentials FROM feeds WHERE url = ?
 `
 
 func (q *Queries) GetFeedByUrl(ctx context.Context, url string) (Feed, error) {
@@ -129,12 +245,49 @@ func (q *Queries) GetFeedByUrl(ctx context.Context, url string) (Feed, error) {
 		&i.Description,
 		&i.Etag,
 		&i.LastModified,
+		&i.LastError,
+		&i.ConsecutiveFailures,
+		&i.LastSuccessAt,
+		&i.NextFetchAfter,
+		&i.FetchIntervalSeconds,
+		&i.FetchIntervalOverrideSeconds,
+		&i.SkipHours,
+		&i.SkipDays,
+		&i.RedirectUrl,
+		&i.RedirectCount,
+		&i.DeadAt,
+		&i.Credentials,
+	)
+	return i, err
+}
+
+const getFeedFollow = `-- name: GetFeedFollow :one
+SELECT id, created_at, updated_at, user_id, feed_id, folder, fetch_interval_override_seconds, mark_unread_on_update FROM feed_follows WHERE user_id = ? AND feed_id = ?
+`
+
+type GetFeedFollowParams struct {
+	UserID string
+	FeedID string
+}
+
+func (q *Queries) GetFeedFollow(ctx context.Context, arg GetFeedFollowParams) (FeedFollow, error) {
+	row := q.db.QueryRowContext(ctx, getFeedFollow, arg.UserID, arg.FeedID)
+	var i FeedFollow
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.FeedID,
+		&i.Folder,
+		&i.FetchIntervalOverrideSeconds,
+		&i.MarkUnreadOnUpdate,
 	)
 	return i, err
 }
 
 const getFeedFollowsForUser = `-- name: GetFeedFollowsForUser :many
-SELECT feed_follows.id, feed_follows.created_at, feed_follows.updated_at, feed_follows.user_id, feed_follows.feed_id, f.name as feed_name, u.name as user_name
+SELECT feed_follows.id, feed_follows.created_at, feed_follows.updated_at, feed_follows.user_id, feed_follows.feed_id, feed_follows.folder, feed_follows.fetch_interval_override_seconds, feed_follows.mark_unread_on_update, f.name as feed_name, u.name as user_name
 FROM feed_follows
 JOIN feeds f ON feed_follows.feed_id = f.id
 JOIN users u ON feed_follows.user_id = u.id
@@ -142,13 +295,16 @@ WHERE feed_follows.user_id = ?
 `
 
 type GetFeedFollowsForUserRow struct {
-	ID        string
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	UserID    string
-	FeedID    string
-	FeedName  string
-	UserName  string
+	ID                           string
+	CreatedAt                    time.Time
+	UpdatedAt                    time.Time
+	UserID                       string
+	FeedID                       string
+	Folder                       sql.NullString
+	FetchIntervalOverrideSeconds sql.NullInt64
+	MarkUnreadOnUpdate           bool
+	FeedName                     string
+	UserName                     string
 }
 
 func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID string) ([]GetFeedFollowsForUserRow, error) {
@@ -166,6 +322,9 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID string) ([]G
 			&i.UpdatedAt,
 			&i.UserID,
 			&i.FeedID,
+			&i.Folder,
+			&i.FetchIntervalOverrideSeconds,
+			&i.MarkUnreadOnUpdate,
 			&i.FeedName,
 			&i.UserName,
 		); err != nil {
@@ -182,36 +341,51 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID string) ([]G
 	return items, nil
 }
 
-const getFeeds = `-- name: GetFeeds :many
-SELECT f.id, f.name, f.url, f.description, u.name as user_name 
-FROM feeds f
-JOIN users u ON f.user_id = u.id
-ORDER BY f.created_at DESC
+const getFeedsToFetch = `-- name: GetFeedsToFetch :many
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, description, etag, last_modified, last_error, consecutive_failures, last_success_at, next_fetch_after, fetch_interval_seconds, fetch_interval_override_seconds, skip_hours, skip_days, redirect_url, redirect_count, dead_at, credentials FROM feeds
+WHERE dead_at IS NULL
+  AND ((next_fetch_after IS NULL AND (last_fetched_at IS NULL OR last_fetched_at < ?1))
+   OR next_fetch_after <= ?2)
+ORDER BY (last_fetched_at IS NOT NULL), last_fetched_at ASC
 `
 
-type GetFeedsRow struct {
-	ID          string
-	Name        string
-	Url         string
-	Description sql.NullString
-	UserName    string
+type GetFeedsToFetchParams struct {
+	Cutoff sql.NullTime
+	Now    sql.NullTime
 }
 
-func (q *Queries) GetFeeds(ctx context.Context) ([]GetFeedsRow, error) {
-	rows, err := q.db.QueryContext(ctx, getFeeds)
+func (q *Queries) GetFeedsToFetch(ctx context.Context, arg GetFeedsToFetchParams) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedsToFetch, arg.Cutoff, arg.Now)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetFeedsRow
+	var items []Feed
 	for rows.Next() {
-		var i GetFeedsRow
+		var i Feed
 		if err := rows.Scan(
 			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
 			&i.Name,
 			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
 			&i.Description,
-			&i.UserName,
+			&i.Etag,
+			&i.LastModified,
+			&i.LastError,
+			&i.ConsecutiveFailures,
+			&i.LastSuccessAt,
+			&i.NextFetchAfter,
+			&i.FetchIntervalSeconds,
+			&i.FetchIntervalOverrideSeconds,
+			&i.SkipHours,
+			&i.SkipDays,
+			&i.RedirectUrl,
+			&i.RedirectCount,
+			&i.DeadAt,
+			&i.Credentials,
 		); err != nil {
 			return nil, err
 		}
@@ -226,33 +400,85 @@ func (q *Queries) GetFeeds(ctx context.Context) ([]GetFeedsRow, error) {
 	return items, nil
 }
 
-const getFeedsToFetch = `-- name: GetFeedsToFetch :many
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, description, etag, last_modified FROM feeds
-WHERE last_fetched_at IS NULL
-   OR last_fetched_at < ?
-ORDER BY (last_fetched_at IS NOT NULL), last_fetched_at ASC
+const getFollowedFeed = `-- name: GetFollowedFeed :one
+SELECT feeds.id, feeds.created_at, feeds.updated_at, feeds.name, feeds.url, feeds.user_id, feeds.last_fetched_at, feeds.description, feeds.etag, feeds.last_modified, feeds.last_error, feeds.consecutive_failures, feeds.last_success_at, feeds.next_fetch_after, feeds.fetch_interval_seconds, feeds.fetch_interval_override_seconds, feeds.skip_hours, feeds.skip_days, feeds.redirect_url, feeds.redirect_count, feeds.dead_at, feeds.credentials
+FROM feeds
+JOIN feed_follows ON feed_follows.feed_id = feeds.id
+WHERE feeds.id = ? AND feed_follows.user_id = ?
+`
+
+type GetFollowedFeedParams struct {
+	ID     string
+	UserID string
+}
+
+func (q *Queries) GetFollowedFeed(ctx context.Context, arg GetFollowedFeedParams) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getFollowedFeed, arg.ID, arg.UserID)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.Description,
+		&i.Etag,
+		&i.LastModified,
+		&i.LastError,
+		&i.ConsecutiveFailures,
+		&i.LastSuccessAt,
+		&i.NextFetchAfter,
+		&i.FetchIntervalSeconds,
+		&i.FetchIntervalOverrideSeconds,
+		&i.SkipHours,
+		&i.SkipDays,
+		&i.RedirectUrl,
+		&i.RedirectCount,
+		&i.DeadAt,
+		&i.Credentials,
+	)
+	return i, err
+}
+
+const getFollowedFeedsForUser = `-- name: GetFollowedFeedsForUser :many
+SELECT feeds.id, feeds.name, feeds.url, feeds.description, feeds.last_fetched_at, feeds.last_error, feeds.dead_at, feed_follows.folder
+FROM feed_follows
+JOIN feeds ON feed_follows.feed_id = feeds.id
+WHERE feed_follows.user_id = ?
+ORDER BY feed_follows.folder, feeds.name
 `
 
-func (q *Queries) GetFeedsToFetch(ctx context.Context, lastFetchedAt sql.NullTime) ([]Feed, error) {
-	rows, err := q.db.QueryContext(ctx, getFeedsToFetch, lastFetchedAt)
+type GetFollowedFeedsForUserRow struct {
+	ID            string
+	Name          string
+	Url           string
+	Description   sql.NullString
+	LastFetchedAt sql.NullTime
+	LastError     sql.NullString
+	DeadAt        sql.NullTime
+	Folder        sql.NullString
+}
+
+func (q *Queries) GetFollowedFeedsForUser(ctx context.Context, userID string) ([]GetFollowedFeedsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFollowedFeedsForUser, userID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Feed
+	var items []GetFollowedFeedsForUserRow
 	for rows.Next() {
-		var i Feed
+		var i GetFollowedFeedsForUserRow
 		if err := rows.Scan(
 			&i.ID,
-			&i.CreatedAt,
-			&i.UpdatedAt,
 			&i.Name,
 			&i.Url,
-			&i.UserID,
-			&i.LastFetchedAt,
 			&i.Description,
-			&i.Etag,
-			&i.LastModified,
+			&i.LastFetchedAt,
+			&i.LastError,
+			&i.DeadAt,
+			&i.Folder,
 		); err != nil {
 			return nil, err
 		}
@@ -267,8 +493,22 @@ func (q *Queries) GetFeedsToFetch(ctx context.Context, lastFetchedAt sql.NullTim
 	return items, nil
 }
 
+const getShortestFetchIntervalOverride = `-- name: GetShortestFetchIntervalOverride :one
+SELECT fetch_interval_override_seconds FROM feed_follows
+WHERE feed_id = ? AND fetch_interval_override_seconds IS NOT NULL
+ORDER BY fetch_interval_override_seconds
+LIMIT 1
+`
+
+func (q *Queries) GetShortestFetchIntervalOverride(ctx context.Context, feedID string) (sql.NullInt64, error) {
+	row := q.db.QueryRowContext(ctx, getShortestFetchIntervalOverride, feedID)
+	var fetch_interval_override_seconds sql.NullInt64
+	err := row.Scan(&fetch_interval_override_seconds)
+	return fetch_interval_override_seconds, err
+}
+
 const getNextFeedToFetch = `-- name: GetNextFeedToFetch :one
-SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, description, etag, last_modified FROM feeds
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, description, etag, last_modified, last_error, consecutive_failures, last_success_at, next_fetch_after, fetch_interval_seconds, fetch_interval_override_seconds, skip_hours, skip_days, redirect_url, redirect_count, dead_at, credentials FROM feeds
 ORDER BY (last_fetched_at IS NOT NULL), last_fetched_at ASC
 LIMIT 1
 `
@@ -287,6 +527,18 @@ func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
 		&i.Description,
 		&i.Etag,
 		&i.LastModified,
+		&i.LastError,
+		&i.ConsecutiveFailures,
+		&i.LastSuccessAt,
+		&i.NextFetchAfter,
+		&i.FetchIntervalSeconds,
+		&i.FetchIntervalOverrideSeconds,
+		&i.SkipHours,
+		&i.SkipDays,
+		&i.RedirectUrl,
+		&i.RedirectCount,
+		&i.DeadAt,
+		&i.Credentials,
 	)
 	return i, err
 }
@@ -300,6 +552,169 @@ func (q *Queries) MarkFeedAsFetched(ctx context.Context, id string) error {
 	return err
 }
 
+const markFeedDead = `-- name: MarkFeedDead :exec
+UPDATE feeds
+SET dead_at = CURRENT_TIMESTAMP, last_error = ?, consecutive_failures = consecutive_failures + 1, next_fetch_after = NULL, last_fetched_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+type MarkFeedDeadParams struct {
+	LastError sql.NullString
+	ID        string
+}
+
+func (q *Queries) MarkFeedDead(ctx context.Context, arg MarkFeedDeadParams) error {
+	_, err := q.db.ExecContext(ctx, markFeedDead, arg.LastError, arg.ID)
+	return err
+}
+
+const moveFeed = `-- name: MoveFeed :exec
+UPDATE feeds
+SET url = ?, redirect_url = NULL, redirect_count = 0, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+type MoveFeedParams struct {
+	Url string
+	ID  string
+}
+
+func (q *Queries) MoveFeed(ctx context.Context, arg MoveFeedParams) error {
+	_, err := q.db.ExecContext(ctx, moveFeed, arg.Url, arg.ID)
+	return err
+}
+
+const recordFeedFetchFailure = `-- name: RecordFeedFetchFailure :exec
+UPDATE feeds
+SET last_error = ?, consecutive_failures = consecutive_failures + 1, next_fetch_after = ?, last_fetched_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+type RecordFeedFetchFailureParams struct {
+	LastError      sql.NullString
+	NextFetchAfter sql.NullTime
+	ID             string
+}
+
+func (q *Queries) RecordFeedFetchFailure(ctx context.Context, arg RecordFeedFetchFailureParams) error {
+	_, err := q.db.ExecContext(ctx, recordFeedFetchFailure, arg.LastError, arg.NextFetchAfter, arg.ID)
+	return err
+}
+
+const recordFeedFetchSuccess = `-- name: RecordFeedFetchSuccess :exec
+UPDATE feeds
+SET last_error = ?, consecutive_failures = 0, next_fetch_after = ?, last_success_at = CURRENT_TIMESTAMP, last_fetched_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+type RecordFeedFetchSuccessParams struct {
+	LastError      sql.NullString
+	NextFetchAfter sql.NullTime
+	ID             string
+}
+
+func (q *Queries) RecordFeedFetchSuccess(ctx context.Context, arg RecordFeedFetchSuccessParams) error {
+	_, err := q.db.ExecContext(ctx, recordFeedFetchSuccess, arg.LastError, arg.NextFetchAfter, arg.ID)
+	return err
+}
+
+const recordFeedRedirect = `-- name: RecordFeedRedirect :one
+UPDATE feeds
+SET redirect_count = CASE WHEN redirect_url = ?1 THEN redirect_count + 1 ELSE 1 END, redirect_url = ?1, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?2
+RETURNING redirect_count
+`
+
+type RecordFeedRedirectParams struct {
+	RedirectUrl sql.NullString
+	ID          string
+}
+
+func (q *Queries) RecordFeedRedirect(ctx context.Context, arg RecordFeedRedirectParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, recordFeedRedirect, arg.RedirectUrl, arg.ID)
+	var redirect_count int64
+	err := row.Scan(&redirect_count)
+	return redirect_count, err
+}
+
+const reviveFeed = `-- name: ReviveFeed :exec
+UPDATE feeds
+SET dead_at = NULL, last_error = NULL, consecutive_failures = 0, next_fetch_after = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+func (q *Queries) ReviveFeed(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, reviveFeed, id)
+	return err
+}
+
+const setFeedCredentials = `-- name: SetFeedCredentials :exec
+UPDATE feeds
+SET credentials = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+type SetFeedCredentialsParams struct {
+	Credentials []byte
+	ID          string
+}
+
+func (q *Queries) SetFeedCredentials(ctx context.Context, arg SetFeedCredentialsParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedCredentials, arg.Credentials, arg.ID)
+	return err
+}
+
+const setFeedFetchIntervalOverride = `-- name: SetFeedFetchIntervalOverride :exec
+UPDATE feeds
+SET fetch_interval_override_seconds = ?, next_fetch_after = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+type SetFeedFetchIntervalOverrideParams struct {
+	FetchIntervalOverrideSeconds sql.NullInt64
+	NextFetchAfter               sql.NullTime
+	ID                           string
+}
+
+func (q *Queries) SetFeedFetchIntervalOverride(ctx context.Context, arg SetFeedFetchIntervalOverrideParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedFetchIntervalOverride, arg.FetchIntervalOverrideSeconds, arg.NextFetchAfter, arg.ID)
+	return err
+}
+
+const setFeedFollowFetchIntervalOverride = `-- name: SetFeedFollowFetchIntervalOverride :exec
+UPDATE feed_follows
+SET fetch_interval_override_seconds = ?, updated_at = CURRENT_TIMESTAMP
+WHERE user_id = ? AND feed_id = ?
+`
+
+type SetFeedFollowFetchIntervalOverrideParams struct {
+	FetchIntervalOverrideSeconds sql.NullInt64
+	UserID                       string
+	FeedID                       string
+}
+
+func (q *Queries) SetFeedFollowFetchIntervalOverride(ctx context.Context, arg SetFeedFollowFetchIntervalOverrideParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedFollowFetchIntervalOverride, arg.FetchIntervalOverrideSeconds, arg.UserID, arg.FeedID)
+	return err
+}
+
+const setFeedFollowMarkUnreadOnUpdate = `-- name: SetFeedFollowMarkUnreadOnUpdate :exec
+UPDATE feed_follows
+SET mark_unread_on_update = ?, updated_at = CURRENT_TIMESTAMP
+WHERE user_id = ? AND feed_id = ?
+`
+
+type SetFeedFollowMarkUnreadOnUpdateParams struct {
+	MarkUnreadOnUpdate bool
+	UserID             string
+	FeedID             string
+}
+
+func (q *Queries) SetFeedFollowMarkUnreadOnUpdate(ctx context.Context, arg SetFeedFollowMarkUnreadOnUpdateParams) error {
+	_, err := q.db.ExecContext(ctx, setFeedFollowMarkUnreadOnUpdate, arg.MarkUnreadOnUpdate, arg.UserID, arg.FeedID)
+	return err
+}
+
 const updateFeedConditionalHeaders = `-- name: UpdateFeedConditionalHeaders :exec
 UPDATE feeds 
 SET etag = ?, last_modified = ?, last_fetched_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP 
@@ -333,3 +748,26 @@ func (q *Queries) UpdateFeedConditionalHeadersNoFetch(ctx context.Context, arg U
 	_, err := q.db.ExecContext(ctx, updateFeedConditionalHeadersNoFetch, arg.Etag, arg.LastModified, arg.ID)
 	return err
 }
+
+const updateFeedSchedule = `-- name: UpdateFeedSchedule :exec
+UPDATE feeds
+SET fetch_interval_seconds = ?, skip_hours = ?, skip_days = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+type UpdateFeedScheduleParams struct {
+	FetchIntervalSeconds sql.NullInt64
+	SkipHours            sql.NullString
+	SkipDays             sql.NullString
+	ID                   string
+}
+
+func (q *Queries) UpdateFeedSchedule(ctx context.Context, arg UpdateFeedScheduleParams) error {
+	_, err := q.db.ExecContext(ctx, updateFeedSchedule,
+		arg.FetchIntervalSeconds,
+		arg.SkipHours,
+		arg.SkipDays,
+		arg.ID,
+	)
+	return err
+}
@@ -9,39 +9,99 @@ import (
 	"time"
 )
 
+type ApiToken struct {
+	ID         string
+	CreatedAt  time.Time
+	LastUsedAt sql.NullTime
+	Name       string
+	TokenHash  string
+	UserID     string
+}
+
 type Feed struct {
-	ID            string
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
-	Name          string
-	Url           string
-	UserID        string
-	LastFetchedAt sql.NullTime
-	Description   sql.NullString
-	Etag          sql.NullString
-	LastModified  sql.NullString
+	ID                           string
+	CreatedAt                    time.Time
+	UpdatedAt                    time.Time
+	Name                         string
+	Url                          string
+	UserID                       sql.NullString
+	LastFetchedAt                sql.NullTime
+	Description                  sql.NullString
+	Etag                         sql.NullString
+	LastModified                 sql.NullString
+	LastError                    sql.NullString
+	ConsecutiveFailures          int64
+	LastSuccessAt                sql.NullTime
+	NextFetchAfter               sql.NullTime
+	FetchIntervalSeconds         sql.NullInt64
+	FetchIntervalOverrideSeconds sql.NullInt64
+	SkipHours                    sql.NullString
+	SkipDays                     sql.NullString
+	RedirectUrl                  sql.NullString
+	RedirectCount                int64
+	DeadAt                       sql.NullTime
+	Credentials                  []byte
 }
 
 type FeedFollow struct {
+	ID                           string
+	CreatedAt                    time.Time
+	UpdatedAt                    time.Time
+	UserID                       string
+	FeedID                       string
+	Folder                       sql.NullString
+	FetchIntervalOverrideSeconds sql.NullInt64
+	MarkUnreadOnUpdate           bool
+}
+
+type Post struct {
+	ID           string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Title        string
+	Url          string
+	Description  sql.NullString
+	PublishedAt  time.Time
+	FeedID       string
+	Seq          int64
+	Guid         sql.NullString
+	IdentityKey  string
+	ContentHash  sql.NullString
+	ThumbnailUrl sql.NullString
+	ContentHtml  sql.NullString
+}
+
+type PostEnclosure struct {
+	ID              string
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	PostID          string
+	Url             string
+	MimeType        sql.NullString
+	Length          sql.NullInt64
+	DurationSeconds sql.NullInt64
+}
+
+type PostRead struct {
 	ID        string
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	PostID    string
 	UserID    string
-	FeedID    string
 }
 
-type Post struct {
+type PostRevision struct {
 	ID          string
 	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	PostID      string
 	Title       string
-	Url         string
 	Description sql.NullString
 	PublishedAt time.Time
-	FeedID      string
+	ContentHash sql.NullString
+	ContentHtml sql.NullString
 }
 
-type PostRead struct {
+type PostSafe struct {
 	ID        string
 	CreatedAt time.Time
 	UpdatedAt time.Time
@@ -49,17 +109,17 @@ type PostRead struct {
 	UserID    string
 }
 
-type PostSafe struct {
+type Session struct {
 	ID        string
 	CreatedAt time.Time
-	UpdatedAt time.Time
-	PostID    string
+	ExpiresAt time.Time
 	UserID    string
 }
 
 type User struct {
-	ID        string
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	Name      string
+	ID           string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+	Name         string
+	PasswordHash sql.NullString
 }
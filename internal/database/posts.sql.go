@@ -11,30 +11,128 @@ import (
 	"time"
 )
 
-const createPost = `-- name: CreatePost :one
-INSERT INTO posts (id, title, url, description, published_at, feed_id)
-VALUES (?, ?, ?, ?, ?, ?)
-RETURNING id, created_at, updated_at, title, url, description, published_at, feed_id
+const adoptPostGUID = `-- name: AdoptPostGUID :execrows
+UPDATE posts
+SET guid = ?1, identity_key = ?2, updated_at = CURRENT_TIMESTAMP
+WHERE feed_id = ?3 AND identity_key = ?4 AND guid IS NULL
 `
 
-type CreatePostParams struct {
-	ID          string
-	Title       string
-	Url         string
-	Description sql.NullString
-	PublishedAt time.Time
+type AdoptPostGUIDParams struct {
+	Guid        sql.NullString
+	IdentityKey string
 	FeedID      string
+	LinkKey     string
+}
+
+func (q *Queries) AdoptPostGUID(ctx context.Context, arg AdoptPostGUIDParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, adoptPostGUID,
+		arg.Guid,
+		arg.IdentityKey,
+		arg.FeedID,
+		arg.LinkKey,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const createPost = `-- name: CreatePost :execrows
+INSERT INTO posts (id, title, url, description, published_at, feed_id, guid, identity_key, content_hash, thumbnail_url, content_html)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (feed_id, identity_key) DO NOTHING
+`
+
+type CreatePostParams struct {
+	ID           string
+	Title        string
+	Url          string
+	Description  sql.NullString
+	PublishedAt  time.Time
+	FeedID       string
+	Guid         sql.NullString
+	IdentityKey  string
+	ContentHash  sql.NullString
+	ThumbnailUrl sql.NullString
+	ContentHtml  sql.NullString
 }
 
-func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, error) {
-	row := q.db.QueryRowContext(ctx, createPost,
+func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, createPost,
 		arg.ID,
 		arg.Title,
 		arg.Url,
 		arg.Description,
 		arg.PublishedAt,
 		arg.FeedID,
+		arg.Guid,
+		arg.IdentityKey,
+		arg.ContentHash,
+		arg.ThumbnailUrl,
+		arg.ContentHtml,
 	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const createPostEnclosure = `-- name: CreatePostEnclosure :exec
+INSERT INTO post_enclosures (id, post_id, url, mime_type, length, duration_seconds)
+VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT (post_id, url) DO UPDATE
+SET mime_type = excluded.mime_type, length = excluded.length, duration_seconds = excluded.duration_seconds, updated_at = CURRENT_TIMESTAMP
+`
+
+type CreatePostEnclosureParams struct {
+	ID              string
+	PostID          string
+	Url             string
+	MimeType        sql.NullString
+	Length          sql.NullInt64
+	DurationSeconds sql.NullInt64
+}
+
+func (q *Queries) CreatePostEnclosure(ctx context.Context, arg CreatePostEnclosureParams) error {
+	_, err := q.db.ExecContext(ctx, createPostEnclosure,
+		arg.ID,
+		arg.PostID,
+		arg.Url,
+		arg.MimeType,
+		arg.Length,
+		arg.DurationSeconds,
+	)
+	return err
+}
+
+const createPostRevision = `-- name: CreatePostRevision :exec
+INSERT INTO post_revisions (id, post_id, title, description, published_at, content_hash, content_html)
+SELECT ?1, posts.id, posts.title, posts.description, posts.published_at, posts.content_hash, posts.content_html
+FROM posts
+WHERE posts.id = ?2
+`
+
+type CreatePostRevisionParams struct {
+	ID     string
+	PostID string
+}
+
+func (q *Queries) CreatePostRevision(ctx context.Context, arg CreatePostRevisionParams) error {
+	_, err := q.db.ExecContext(ctx, createPostRevision, arg.ID, arg.PostID)
+	return err
+}
+
+const getPostByIdentity = `-- name: GetPostByIdentity :one
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, seq, guid, identity_key, content_hash, thumbnail_url, content_html FROM posts WHERE feed_id = ? AND identity_key = ?
+`
+
+type GetPostByIdentityParams struct {
+	FeedID      string
+	IdentityKey string
+}
+
+func (q *Queries) GetPostByIdentity(ctx context.Context, arg GetPostByIdentityParams) (Post, error) {
+	row := q.db.QueryRowContext(ctx, getPostByIdentity, arg.FeedID, arg.IdentityKey)
 	var i Post
 	err := row.Scan(
 		&i.ID,
@@ -45,12 +143,54 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		&i.Description,
 		&i.PublishedAt,
 		&i.FeedID,
+		&i.Seq,
+		&i.Guid,
+		&i.IdentityKey,
+		&i.ContentHash,
+		&i.ThumbnailUrl,
+		&i.ContentHtml,
 	)
 	return i, err
 }
 
+const getPostContentForUser = `-- name: GetPostContentForUser :one
+SELECT posts.content_html FROM posts
+WHERE posts.id = ?1
+AND posts.feed_id IN (SELECT feed_id FROM feed_follows WHERE feed_follows.user_id = ?2)
+`
+
+type GetPostContentForUserParams struct {
+	PostID string
+	UserID string
+}
+
+func (q *Queries) GetPostContentForUser(ctx context.Context, arg GetPostContentForUserParams) (sql.NullString, error) {
+	row := q.db.QueryRowContext(ctx, getPostContentForUser, arg.PostID, arg.UserID)
+	var content_html sql.NullString
+	err := row.Scan(&content_html)
+	return content_html, err
+}
+
+const getPostFeedIDForUser = `-- name: GetPostFeedIDForUser :one
+SELECT posts.feed_id FROM posts
+WHERE posts.id = ?1
+AND posts.feed_id IN (SELECT feed_id FROM feed_follows WHERE feed_follows.user_id = ?2)
+`
+
+type GetPostFeedIDForUserParams struct {
+	PostID string
+	UserID string
+}
+
+func (q *Queries) GetPostFeedIDForUser(ctx context.Context, arg GetPostFeedIDForUserParams) (string, error) {
+	row := q.db.QueryRowContext(ctx, getPostFeedIDForUser, arg.PostID, arg.UserID)
+	var feed_id string
+	err := row.Scan(&feed_id)
+	return feed_id, err
+}
+
 const getPostsByUser = `-- name: GetPostsByUser :many
-SELECT id, created_at, updated_at, title, url, description, published_at, feed_id FROM posts WHERE feed_id IN (SELECT feed_id FROM feed_follows WHERE user_id = ?1) ORDER BY published_at DESC LIMIT ?2
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id, seq, guid, identity_key, content_hash, thumbnail_url, content_html FROM posts WHERE feed_id IN (SELECT feed_id FROM feed_follows WHERE user_id = ?1) ORDER BY published_at DESC LIMIT ?2
 `
 
 type GetPostsByUserParams struct {
@@ -76,6 +216,12 @@ func (q *Queries) GetPostsByUser(ctx context.Context, arg GetPostsByUserParams)
 			&i.Description,
 			&i.PublishedAt,
 			&i.FeedID,
+			&i.Seq,
+			&i.Guid,
+			&i.IdentityKey,
+			&i.ContentHash,
+			&i.ThumbnailUrl,
+			&i.ContentHtml,
 		); err != nil {
 			return nil, err
 		}
@@ -90,45 +236,84 @@ func (q *Queries) GetPostsByUser(ctx context.Context, arg GetPostsByUserParams)
 	return items, nil
 }
 
+const getRecentPostDatesForFeed = `-- name: GetRecentPostDatesForFeed :many
+SELECT published_at FROM posts WHERE feed_id = ? ORDER BY published_at DESC LIMIT ?
+`
+
+type GetRecentPostDatesForFeedParams struct {
+	FeedID string
+	Limit  int64
+}
+
+func (q *Queries) GetRecentPostDatesForFeed(ctx context.Context, arg GetRecentPostDatesForFeedParams) ([]time.Time, error) {
+	rows, err := q.db.QueryContext(ctx, getRecentPostDatesForFeed, arg.FeedID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []time.Time
+	for rows.Next() {
+		var published_at time.Time
+		if err := rows.Scan(&published_at); err != nil {
+			return nil, err
+		}
+		items = append(items, published_at)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const searchPostsByUser = `-- name: SearchPostsByUser :many
-SELECT posts.id as id, title, posts.url as url, posts.description as description, published_at, feeds.name as feed_name, feeds.id as feed_id, post_saves.created_at as saved_at, post_reads.created_at as read_at FROM posts
+SELECT posts.id as id, title, posts.url as url, posts.description as description, published_at, feeds.name as feed_name, feeds.id as feed_id, post_saves.created_at as saved_at, post_reads.created_at as read_at,
+    posts.thumbnail_url as thumbnail_url, post_enclosures.url as enclosure_url, post_enclosures.mime_type as enclosure_mime_type, post_enclosures.duration_seconds as enclosure_duration_seconds,
+    CAST(posts.content_html IS NOT NULL AS BOOLEAN) as has_content
+FROM posts
 JOIN feeds ON posts.feed_id = feeds.id
+LEFT JOIN post_enclosures ON post_enclosures.id = (
+    SELECT media.id FROM post_enclosures AS media
+    WHERE media.post_id = posts.id AND (media.mime_type LIKE 'audio/%' OR media.mime_type LIKE 'video/%')
+    ORDER BY media.rowid LIMIT 1
+)
 LEFT JOIN post_saves ON posts.id = post_saves.post_id AND post_saves.user_id = ?1
 LEFT JOIN post_reads ON posts.id = post_reads.post_id AND post_reads.user_id = ?1
 WHERE feed_id IN (SELECT feed_id FROM feed_follows WHERE feed_follows.user_id = ?1) 
-AND ( CAST(?2 AS TEXT) = ''
-      OR posts.title LIKE '%' || CAST(?2 AS TEXT) || '%'
-      OR posts.description LIKE '%' || CAST(?2 AS TEXT) || '%'
-    )
-AND ( CAST(?3 AS BOOLEAN) = false OR post_reads.id  IS NULL )
-AND ( CAST(?4 AS BOOLEAN)  = false OR post_saves.id IS NOT NULL )
-ORDER BY published_at DESC LIMIT ?5
+AND ( CAST(?2 AS BOOLEAN) = false OR post_reads.id  IS NULL )
+AND ( CAST(?3 AS BOOLEAN)  = false OR post_saves.id IS NOT NULL )
+ORDER BY published_at DESC LIMIT ?4
 `
 
 type SearchPostsByUserParams struct {
 	UserID         string
-	SearchText     string
 	FilterByUnread bool
 	FilterBySaved  bool
 	LimitCount     int64
 }
 
 type SearchPostsByUserRow struct {
-	ID          string
-	Title       string
-	Url         string
-	Description sql.NullString
-	PublishedAt time.Time
-	FeedName    string
-	FeedID      string
-	SavedAt     sql.NullTime
-	ReadAt      sql.NullTime
+	ID                       string
+	Title                    string
+	Url                      string
+	Description              sql.NullString
+	PublishedAt              time.Time
+	FeedName                 string
+	FeedID                   string
+	SavedAt                  sql.NullTime
+	ReadAt                   sql.NullTime
+	ThumbnailUrl             sql.NullString
+	EnclosureUrl             sql.NullString
+	EnclosureMimeType        sql.NullString
+	EnclosureDurationSeconds sql.NullInt64
+	HasContent               bool
 }
 
 func (q *Queries) SearchPostsByUser(ctx context.Context, arg SearchPostsByUserParams) ([]SearchPostsByUserRow, error) {
 	rows, err := q.db.QueryContext(ctx, searchPostsByUser,
 		arg.UserID,
-		arg.SearchText,
 		arg.FilterByUnread,
 		arg.FilterBySaved,
 		arg.LimitCount,
@@ -150,6 +335,11 @@ func (q *Queries) SearchPostsByUser(ctx context.Context, arg SearchPostsByUserPa
 			&i.FeedID,
 			&i.SavedAt,
 			&i.ReadAt,
+			&i.ThumbnailUrl,
+			&i.EnclosureUrl,
+			&i.EnclosureMimeType,
+			&i.EnclosureDurationSeconds,
+			&i.HasContent,
 		); err != nil {
 			return nil, err
 		}
@@ -163,3 +353,128 @@ func (q *Queries) SearchPostsByUser(ctx context.Context, arg SearchPostsByUserPa
 	}
 	return items, nil
 }
+
+const searchPostsFullText = `-- name: SearchPostsFullText :many
+SELECT posts.id as id, posts.title as title, posts.url as url, posts.description as description, posts.published_at as published_at, feeds.name as feed_name, feeds.id as feed_id, post_saves.created_at as saved_at, post_reads.created_at as read_at,
+    posts.thumbnail_url as thumbnail_url, post_enclosures.url as enclosure_url, post_enclosures.mime_type as enclosure_mime_type, post_enclosures.duration_seconds as enclosure_duration_seconds,
+    CAST(posts.content_html IS NOT NULL AS BOOLEAN) as has_content,
+    CAST(highlight(posts_fts, 0, char(2), char(3)) AS TEXT) as title_highlight,
+    CAST(snippet(posts_fts, 1, char(2), char(3), '…', 32) AS TEXT) as description_snippet
+FROM posts_fts
+JOIN posts ON posts.seq = posts_fts.rowid
+JOIN feeds ON posts.feed_id = feeds.id
+LEFT JOIN post_enclosures ON post_enclosures.id = (
+    SELECT media.id FROM post_enclosures AS media
+    WHERE media.post_id = posts.id AND (media.mime_type LIKE 'audio/%' OR media.mime_type LIKE 'video/%')
+    ORDER BY media.rowid LIMIT 1
+)
+LEFT JOIN post_saves ON posts.id = post_saves.post_id AND post_saves.user_id = ?1
+LEFT JOIN post_reads ON posts.id = post_reads.post_id AND post_reads.user_id = ?1
+WHERE posts_fts MATCH ?2
+AND posts.feed_id IN (SELECT feed_id FROM feed_follows WHERE feed_follows.user_id = ?1)
+AND ( CAST(?3 AS BOOLEAN) = false OR post_reads.id  IS NULL )
+AND ( CAST(?4 AS BOOLEAN)  = false OR post_saves.id IS NOT NULL )
+ORDER BY bm25(posts_fts, 10.0, 1.0, 3.0), posts.published_at DESC LIMIT ?5
+`
+
+type SearchPostsFullTextParams struct {
+	UserID         string
+	MatchQuery     string
+	FilterByUnread bool
+	FilterBySaved  bool
+	LimitCount     int64
+}
+
+type SearchPostsFullTextRow struct {
+	ID                       string
+	Title                    string
+	Url                      string
+	Description              sql.NullString
+	PublishedAt              time.Time
+	FeedName                 string
+	FeedID                   string
+	SavedAt                  sql.NullTime
+	ReadAt                   sql.NullTime
+	ThumbnailUrl             sql.NullString
+	EnclosureUrl             sql.NullString
+	EnclosureMimeType        sql.NullString
+	EnclosureDurationSeconds sql.NullInt64
+	HasContent               bool
+	TitleHighlight           string
+	DescriptionSnippet       string
+}
+
+func (q *Queries) SearchPostsFullText(ctx context.Context, arg SearchPostsFullTextParams) ([]SearchPostsFullTextRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchPostsFullText,
+		arg.UserID,
+		arg.MatchQuery,
+		arg.FilterByUnread,
+		arg.FilterBySaved,
+		arg.LimitCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchPostsFullTextRow
+	for rows.Next() {
+		var i SearchPostsFullTextRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedName,
+			&i.FeedID,
+			&i.SavedAt,
+			&i.ReadAt,
+			&i.ThumbnailUrl,
+			&i.EnclosureUrl,
+			&i.EnclosureMimeType,
+			&i.EnclosureDurationSeconds,
+			&i.HasContent,
+			&i.TitleHighlight,
+			&i.DescriptionSnippet,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updatePostContent = `-- name: UpdatePostContent :exec
+UPDATE posts
+SET title = ?, description = ?, published_at = ?, content_hash = ?, thumbnail_url = ?, content_html = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+type UpdatePostContentParams struct {
+	Title        string
+	Description  sql.NullString
+	PublishedAt  time.Time
+	ContentHash  sql.NullString
+	ThumbnailUrl sql.NullString
+	ContentHtml  sql.NullString
+	ID           string
+}
+
+func (q *Queries) UpdatePostContent(ctx context.Context, arg UpdatePostContentParams) error {
+	_, err := q.db.ExecContext(ctx, updatePostContent,
+		arg.Title,
+		arg.Description,
+		arg.PublishedAt,
+		arg.ContentHash,
+		arg.ThumbnailUrl,
+		arg.ContentHtml,
+		arg.ID,
+	)
+	return err
+}
@@ -23,8 +23,25 @@ func (q *Queries) DeleteReadPost(ctx context.Context, arg DeleteReadPostParams)
 	return err
 }
 
+const deleteReadPostsForPost = `-- name: DeleteReadPostsForPost :exec
+DELETE FROM post_reads
+WHERE post_id = ?1
+  AND user_id IN (SELECT user_id FROM feed_follows WHERE feed_id = ?2 AND mark_unread_on_update)
+`
+
+type DeleteReadPostsForPostParams struct {
+	PostID string
+	FeedID string
+}
+
+func (q *Queries) DeleteReadPostsForPost(ctx context.Context, arg DeleteReadPostsForPostParams) error {
+	_, err := q.db.ExecContext(ctx, deleteReadPostsForPost, arg.PostID, arg.FeedID)
+	return err
+}
+
 const saveReadPost = `-- name: SaveReadPost :exec
 INSERT INTO post_reads (id, post_id, user_id) VALUES (?1, ?2, ?3)
+ON CONFLICT (post_id, user_id) DO NOTHING
 `
 
 type SaveReadPostParams struct {
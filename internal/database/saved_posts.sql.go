@@ -25,6 +25,7 @@ func (q *Queries) DeleteSavedPost(ctx context.Context, arg DeleteSavedPostParams
 
 const saveSavedPost = `-- name: SaveSavedPost :exec
 INSERT INTO post_saves (id, post_id, user_id) VALUES (?, ?, ?)
+ON CONFLICT (post_id, user_id) DO NOTHING
 `
 
 type SaveSavedPostParams struct {
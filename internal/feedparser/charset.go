@@ -0,0 +1,106 @@
+package feedparser
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/unicode"
+)
+
+var (
+	utf8BOM    = []byte{0xef, 0xbb, 0xbf}
+	utf16LEBOM = []byte{0xff, 0xfe}
+	utf16BEBOM = []byte{0xfe, 0xff}
+)
+
+// xmlEncoding finds the encoding named in an XML declaration.
+var xmlEncoding = regexp.MustCompile(`^\s*<\?xml[^>]*?\sencoding\s*=\s*["']([A-Za-z0-9._:-]+)["']`)
+
+// decodeXML converts an XML feed to UTF-8. The encoding is taken from a
+// byte order mark, then the charset of the Content-Type header, then the XML
+// declaration, and is otherwise assumed to be UTF-8. Labels are looked up as
+// browsers do, so ISO-8859-1 is read as its superset windows-1252.
+func decodeXML(body []byte, contentType string) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(body, utf8BOM):
+		return body[len(utf8BOM):], nil
+	case bytes.HasPrefix(body, utf16LEBOM), bytes.HasPrefix(body, utf16BEBOM):
+		decoded, err := unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM).NewDecoder().Bytes(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode UTF-16: %w", err)
+		}
+		return decoded, nil
+	}
+
+	// Servers often label every feed UTF-8, so the XML declaration wins when
+	// the body turns out not to be
+	label := contentTypeCharset(contentType)
+	if label == "" || (isUTF8(label) && !utf8.Valid(body)) {
+		if declared := declaredEncoding(body); declared != "" {
+			label = declared
+		}
+	}
+	if label == "" || isUTF8(label) {
+		return body, nil
+	}
+
+	encoding, err := htmlindex.Get(label)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported encoding %q", label)
+	}
+	decoded, err := encoding.NewDecoder().Bytes(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", label, err)
+	}
+	return decoded, nil
+}
+
+func isUTF8(label string) bool {
+	encoding, err := htmlindex.Get(label)
+	if err != nil {
+		return false
+	}
+	name, _ := htmlindex.Name(encoding)
+	return name == "utf-8"
+}
+
+func declaredEncoding(body []byte) string {
+	head := body
+	if len(head) > 1024 {
+		head = head[:1024]
+	}
+	if match := xmlEncoding.FindSubmatch(head); match != nil {
+		return string(match[1])
+	}
+	return ""
+}
+
+func contentTypeCharset(contentType string) string {
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(params["charset"])
+}
+
+// newXMLDecoder reads a feed that decodeXML has already converted to UTF-8,
+// so an XML declaration naming another encoding is ignored rather than
+// rejected.
+func newXMLDecoder(body []byte) *xml.Decoder {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	decoder.CharsetReader = func(label string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+	return decoder
+}
+
+func unmarshalXML(body []byte, v any) error {
+	return newXMLDecoder(body).Decode(v)
+}
@@ -0,0 +1,118 @@
+package feedparser
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestFetchFeed_Encodings(t *testing.T) {
+	tests := []struct {
+		name          string
+		fixture       string
+		contentType   string
+		expectedTitle string
+		expectedItem  string
+	}{
+		{
+			name:          "ISO-8859-1 from the XML declaration",
+			fixture:       "testdata/iso-8859-1.xml",
+			contentType:   "application/rss+xml",
+			expectedTitle: "Café français",
+			expectedItem:  "Crème brûlée à la carte",
+		},
+		{
+			name:          "windows-1252 from the XML declaration",
+			fixture:       "testdata/windows-1252.xml",
+			contentType:   "text/xml",
+			expectedTitle: "Smart “quotes”",
+			expectedItem:  "Price: 5 € — on sale",
+		},
+		{
+			name:          "Shift_JIS from the XML declaration",
+			fixture:       "testdata/shift_jis.xml",
+			contentType:   "application/rss+xml",
+			expectedTitle: "日本語のフィード",
+			expectedItem:  "新しい記事です",
+		},
+		{
+			name:          "KOI8-R from the XML declaration",
+			fixture:       "testdata/koi8-r.xml",
+			contentType:   "application/rss+xml",
+			expectedTitle: "Новости",
+			expectedItem:  "Привет, мир",
+		},
+		{
+			name:          "UTF-16 from the byte order mark",
+			fixture:       "testdata/utf-16le-bom.xml",
+			contentType:   "application/rss+xml; charset=iso-8859-1",
+			expectedTitle: "UTF-16 feed",
+			expectedItem:  "Zoë’s post",
+		},
+		{
+			name:          "windows-1252 from the Content-Type charset",
+			fixture:       "testdata/windows-1252-undeclared.xml",
+			contentType:   "application/rss+xml; charset=windows-1252",
+			expectedTitle: "Undeclared",
+			expectedItem:  "Naïve café",
+		},
+		{
+			name:          "declaration wins over a wrong UTF-8 charset",
+			fixture:       "testdata/iso-8859-1.xml",
+			contentType:   "application/rss+xml; charset=utf-8",
+			expectedTitle: "Café français",
+			expectedItem:  "Crème brûlée à la carte",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			body, err := os.ReadFile(tc.fixture)
+			if err != nil {
+				t.Fatalf("failed to read fixture: %v", err)
+			}
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tc.contentType)
+				w.Write(body)
+			}))
+			defer server.Close()
+
+			feed, err := FetchFeed(context.Background(), server.URL)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if feed.GetTitle() != tc.expectedTitle {
+				t.Errorf("expected title %q, got %q", tc.expectedTitle, feed.GetTitle())
+			}
+			items := feed.GetItems()
+			if len(items) != 1 {
+				t.Fatalf("expected 1 item, got %d", len(items))
+			}
+			if items[0].GetTitle() != tc.expectedItem {
+				t.Errorf("expected item title %q, got %q", tc.expectedItem, items[0].GetTitle())
+			}
+		})
+	}
+}
+
+func TestDecodeXML_UnsupportedEncoding(t *testing.T) {
+	_, err := decodeXML([]byte(`<?xml version="1.0" encoding="x-made-up"?><rss/>`), "")
+	if err == nil {
+		t.Errorf("expected an error for an unknown encoding")
+	}
+}
+
+func TestParseJSONFeed_BOM(t *testing.T) {
+	body := append([]byte{0xef, 0xbb, 0xbf}, `{"version": "https://jsonfeed.org/version/1.1", "title": "BOM", "items": []}`...)
+	feed, err := parseJSONFeed(body)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if feed.GetTitle() != "BOM" {
+		t.Errorf("expected title BOM, got %q", feed.GetTitle())
+	}
+}
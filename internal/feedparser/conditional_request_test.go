@@ -2,9 +2,11 @@ package feedparser
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestFetchFeedWithConditionals(t *testing.T) {
@@ -119,6 +121,7 @@ func TestFetchFeedWithConditionals(t *testing.T) {
 func TestFetchFeedWithConditionals_ErrorHandling(t *testing.T) {
 	// Test server that returns 429 Too Many Requests
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "120")
 		w.WriteHeader(http.StatusTooManyRequests)
 		w.Write([]byte("Rate limited"))
 	}))
@@ -135,4 +138,46 @@ func TestFetchFeedWithConditionals_ErrorHandling(t *testing.T) {
 	if result != nil {
 		t.Error("Expected result to be nil on error")
 	}
+
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) {
+		t.Fatalf("Expected *HTTPError, got %T", err)
+	}
+
+	if httpErr.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("Expected status 429, got %d", httpErr.StatusCode)
+	}
+
+	if httpErr.RetryAfter != 120*time.Second {
+		t.Errorf("Expected Retry-After of 120s, got %s", httpErr.RetryAfter)
+	}
+
+	if !httpErr.Temporary() {
+		t.Error("Expected 429 to be temporary")
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2015, 10, 21, 7, 28, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		value    string
+		expected time.Duration
+	}{
+		{name: "empty", value: "", expected: 0},
+		{name: "seconds", value: "90", expected: 90 * time.Second},
+		{name: "negative seconds", value: "-5", expected: 0},
+		{name: "http date", value: "Wed, 21 Oct 2015 07:38:00 GMT", expected: 10 * time.Minute},
+		{name: "date in the past", value: "Wed, 21 Oct 2015 07:00:00 GMT", expected: 0},
+		{name: "garbage", value: "soon", expected: 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := parseRetryAfter(tc.value, now); got != tc.expected {
+				t.Errorf("Expected %s, got %s", tc.expected, got)
+			}
+		})
+	}
 }
@@ -0,0 +1,139 @@
+package feedparser
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// zoneOffsets maps the zone abbreviations feeds use in place of numeric
+// offsets. time.Parse accepts any abbreviation but treats unknown ones as
+// UTC, which would silently shift US dates by several hours.
+var zoneOffsets = map[string]string{
+	"UT":   "+0000",
+	"UTC":  "+0000",
+	"GMT":  "+0000",
+	"Z":    "+0000",
+	"EST":  "-0500",
+	"EDT":  "-0400",
+	"CST":  "-0600",
+	"CDT":  "-0500",
+	"MST":  "-0700",
+	"MDT":  "-0600",
+	"PST":  "-0800",
+	"PDT":  "-0700",
+	"AKST": "-0900",
+	"AKDT": "-0800",
+	"HST":  "-1000",
+	"BST":  "+0100",
+	"IST":  "+0530",
+	"CET":  "+0100",
+	"CEST": "+0200",
+	"EET":  "+0200",
+	"EEST": "+0300",
+	"JST":  "+0900",
+	"AEST": "+1000",
+	"AEDT": "+1100",
+}
+
+// dateLayouts are tried in order once a date has been normalized: weekday
+// and commas removed, and zone names replaced by offsets.
+var dateLayouts = buildDateLayouts()
+
+func buildDateLayouts() []string {
+	layouts := []string{
+		time.RFC3339,
+		"2006-01-02T15:04Z07:00",
+		"2006-01-02T15:04:05 -0700",
+		"2006-01-02T15:04:05",
+		"2006-01-02T15:04",
+		"2006-01-02 15:04:05Z07:00",
+		// The shortest W3C-DTF forms used by dc:date
+		"2006-01",
+		"2006",
+	}
+
+	days := []string{
+		"2 Jan 2006",
+		"2 January 2006",
+		"2 Jan 06",
+		"2 January 06",
+		"Jan 2 2006",
+		"January 2 2006",
+		"2006-01-02",
+	}
+	times := []string{"15:04:05", "15:04"}
+	zones := []string{" -0700", " -07:00", ""}
+
+	for _, day := range days {
+		for _, clock := range times {
+			for _, zone := range zones {
+				layouts = append(layouts, day+" "+clock+zone)
+			}
+		}
+		layouts = append(layouts, day)
+	}
+	return layouts
+}
+
+var (
+	spaceRun     = regexp.MustCompile(`\s+`)
+	leadingWord  = regexp.MustCompile(`^\pL+\.?,?\s+`)
+	trailingZone = regexp.MustCompile(`\s\(?([A-Za-z]{1,5})\)?$`)
+	monthPrefix  = regexp.MustCompile(`(?i)^(jan|feb|mar|apr|may|jun|jul|aug|sep|oct|nov|dec)`)
+
+	// leadingMonth matches a month first, followed by the day and year or by
+	// the year alone, as in "Jan 2, 2006" or "March 2006"
+	leadingMonth = regexp.MustCompile(`^\pL+\.?,?\s+\d+(,?\s+\d|$)`)
+)
+
+// parseDate reads the many date formats found in the wild, from RFC 822
+// with or without a weekday, seconds or a four-digit year, to ISO 8601 with
+// or without a zone. Weekday names are ignored, so they may be in any
+// language. Dates without a zone are taken to be UTC.
+func parseDate(date string) (time.Time, error) {
+	date = strings.TrimSpace(date)
+	if date == "" {
+		return time.Time{}, fmt.Errorf("failed to parse date: empty")
+	}
+
+	// The common cases need no normalization
+	for _, layout := range []string{time.RFC1123Z, time.RFC3339} {
+		if parsed, err := time.Parse(layout, date); err == nil {
+			return parsed, nil
+		}
+	}
+
+	normalized := normalizeDate(date)
+	for _, layout := range dateLayouts {
+		if parsed, err := time.Parse(layout, normalized); err == nil {
+			return parsed, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("failed to parse date: %s", date)
+}
+
+// normalizeDate reduces a date to a shape dateLayouts can match.
+func normalizeDate(date string) string {
+	date = spaceRun.ReplaceAllString(date, " ")
+
+	// Drop a leading weekday in any language. It is told apart from a
+	// leading month by what follows, since names like "mar" are both.
+	if match := leadingWord.FindString(date); match != "" && !leadingMonth.MatchString(date) {
+		date = date[len(match):]
+	}
+
+	// Replace a named zone with its offset, or drop it if it's unknown
+	if match := trailingZone.FindStringSubmatchIndex(date); match != nil {
+		zone := strings.ToUpper(date[match[2]:match[3]])
+		if offset, ok := zoneOffsets[zone]; ok {
+			date = date[:match[0]] + " " + offset
+		} else if !monthPrefix.MatchString(zone) {
+			date = date[:match[0]]
+		}
+	}
+
+	return strings.TrimSpace(strings.ReplaceAll(date, ",", ""))
+}
@@ -0,0 +1,76 @@
+package feedparser
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected time.Time
+	}{
+		{"Wed, 01 Jan 2024 12:00:00 +0000", time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)},
+		{"Wed, 01 Jan 2024 12:00:00 GMT", time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)},
+		{"2024-01-01T12:00:00Z", time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)},
+		{"2024-01-01T12:00:00.123+02:00", time.Date(2024, 1, 1, 10, 0, 0, 123000000, time.UTC)},
+
+		// Single-digit days and no weekday
+		{"Mon, 1 Jan 2024 12:00:00 +0000", time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)},
+		{"1 Jan 2024 12:00:00 +0000", time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)},
+
+		// Named zones
+		{"Tue, 02 Jan 2024 08:00:00 EST", time.Date(2024, 1, 2, 13, 0, 0, 0, time.UTC)},
+		{"Tue, 02 Jul 2024 08:00:00 PDT", time.Date(2024, 7, 2, 15, 0, 0, 0, time.UTC)},
+		{"Tue, 02 Jul 2024 08:00:00 (CEST)", time.Date(2024, 7, 2, 6, 0, 0, 0, time.UTC)},
+		{"Tue, 02 Jul 2024 08:00:00 XYZ", time.Date(2024, 7, 2, 8, 0, 0, 0, time.UTC)},
+
+		// Missing seconds and two-digit years
+		{"Tue, 02 Jan 2024 08:00 +0100", time.Date(2024, 1, 2, 7, 0, 0, 0, time.UTC)},
+		{"Tue, 02 Jan 24 08:00:00 GMT", time.Date(2024, 1, 2, 8, 0, 0, 0, time.UTC)},
+		{"2 Jan 99 08:00 GMT", time.Date(1999, 1, 2, 8, 0, 0, 0, time.UTC)},
+
+		// Full month names, US order and colon offsets
+		{"Tuesday, 2 January 2024 08:00:00 -05:00", time.Date(2024, 1, 2, 13, 0, 0, 0, time.UTC)},
+		{"January 2, 2024 08:00", time.Date(2024, 1, 2, 8, 0, 0, 0, time.UTC)},
+		{"Jan 2, 2024", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"Tuesday, January 2, 2024", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+
+		// ISO 8601 without a zone or separator
+		{"2024-01-02T08:00:00", time.Date(2024, 1, 2, 8, 0, 0, 0, time.UTC)},
+		{"2024-01-02 08:00:00", time.Date(2024, 1, 2, 8, 0, 0, 0, time.UTC)},
+		{"2024-01-02 08:00:00 +0200", time.Date(2024, 1, 2, 6, 0, 0, 0, time.UTC)},
+		{"2024-01-02T08:00", time.Date(2024, 1, 2, 8, 0, 0, 0, time.UTC)},
+		{"2024-01-02", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"2024-01", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+
+		// Non-English weekday names
+		{"Mi, 03 Jan 2024 10:00:00 +0100", time.Date(2024, 1, 3, 9, 0, 0, 0, time.UTC)},
+		{"mercredi, 3 Jan 2024 10:00:00 +0100", time.Date(2024, 1, 3, 9, 0, 0, 0, time.UTC)},
+		{"Śr., 03 Jan 2024 10:00:00 GMT", time.Date(2024, 1, 3, 10, 0, 0, 0, time.UTC)},
+		{"mar, 12 mar 2024 10:00:00 +0100", time.Date(2024, 3, 12, 9, 0, 0, 0, time.UTC)},
+
+		// Stray whitespace
+		{"  Wed,  03 Jan 2024\t10:00:00   GMT ", time.Date(2024, 1, 3, 10, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			parsed, err := parseDate(tc.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !parsed.Equal(tc.expected) {
+				t.Errorf("expected %s, got %s", tc.expected, parsed.UTC())
+			}
+		})
+	}
+}
+
+func TestParseDate_Invalid(t *testing.T) {
+	for _, input := range []string{"", "sometime last week", "32 Jan 2024", "Wed"} {
+		if parsed, err := parseDate(input); err == nil {
+			t.Errorf("expected error for %q, got %s", input, parsed)
+		}
+	}
+}
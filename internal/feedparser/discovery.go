@@ -0,0 +1,191 @@
+package feedparser
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"golang.org/x/net/html/charset"
+)
+
+// ErrNotFeed is returned when a URL serves a web page rather than a feed.
+// DiscoverFeeds can find the feeds the page links to.
+var ErrNotFeed = errors.New("not a feed but a web page")
+
+// FeedLink is a feed found on a web page.
+type FeedLink struct {
+	URL   string
+	Title string
+	Type  FeedType
+}
+
+// feedLinkTypes are the <link type> values that point to feeds.
+var feedLinkTypes = map[string]FeedType{
+	"application/rss+xml":   FeedTypeRSS,
+	"application/atom+xml":  FeedTypeAtom,
+	"application/feed+json": FeedTypeJSON,
+}
+
+// wellKnownFeedPaths are tried, in order, on sites whose pages don't link
+// to their feed.
+var wellKnownFeedPaths = []string{
+	"/feed",
+	"/rss",
+	"/feed.xml",
+	"/rss.xml",
+	"/atom.xml",
+	"/index.xml",
+	"/feed.json",
+}
+
+// isHTMLPage reports whether a response is a web page rather than a feed,
+// going by its Content-Type or, since some servers send everything as
+// text/html, by how the body starts.
+func isHTMLPage(contentType string, body []byte) bool {
+	trimmed := bytes.TrimLeft(bytes.TrimPrefix(body, utf8BOM), " \t\r\n")
+	head := bytes.ToLower(trimmed[:min(len(trimmed), 64)])
+	if bytes.HasPrefix(head, []byte("<!doctype html")) || bytes.HasPrefix(head, []byte("<html")) {
+		return true
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType != "text/html" {
+		return false
+	}
+	for _, prefix := range []string{"<?xml", "<rss", "<feed", "<rdf", "{"} {
+		if bytes.HasPrefix(head, []byte(prefix)) {
+			return false
+		}
+	}
+	return true
+}
+
+// DiscoverFeeds finds the feeds of the web page at pageURL with
+// DefaultFetcher.
+func DiscoverFeeds(ctx context.Context, pageURL string) ([]FeedLink, error) {
+	return DefaultFetcher.DiscoverFeeds(ctx, pageURL)
+}
+
+// DiscoverFeeds finds the feeds of the web page at pageURL. It reads the
+// page's <link rel="alternate"> tags and, when there are none, tries the
+// paths sites commonly publish their feed at, returning the first that
+// works.
+func (f *Fetcher) DiscoverFeeds(ctx context.Context, pageURL string) ([]FeedLink, error) {
+	resp, err := f.get(ctx, pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &HTTPError{StatusCode: resp.StatusCode}
+	}
+
+	// Links are relative to wherever redirects ended up
+	base := resp.URL
+	body, err := charset.NewReader(bytes.NewReader(resp.Body), resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode page: %w", err)
+	}
+
+	links := feedLinks(html.NewTokenizer(body), base)
+	if len(links) > 0 {
+		return links, nil
+	}
+
+	for _, path := range wellKnownFeedPaths {
+		feedURL := resolveBase(base, path)
+		feed, err := f.FetchFeed(ctx, feedURL)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			continue
+		}
+		return []FeedLink{{URL: feedURL, Title: feed.GetTitle(), Type: feedTypeOf(feed)}}, nil
+	}
+
+	return nil, nil
+}
+
+// feedLinks collects the feeds a page links to, honouring its <base>.
+func feedLinks(tokenizer *html.Tokenizer, base string) []FeedLink {
+	var links []FeedLink
+	seen := map[string]bool{}
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return links
+		case html.StartTagToken, html.SelfClosingTagToken:
+		default:
+			continue
+		}
+
+		name, hasAttr := tokenizer.TagName()
+		if !hasAttr {
+			continue
+		}
+		attrs := map[string]string{}
+		for {
+			key, val, more := tokenizer.TagAttr()
+			attrs[string(key)] = string(val)
+			if !more {
+				break
+			}
+		}
+
+		switch atom.Lookup(name) {
+		case atom.Base:
+			if href := attrs["href"]; href != "" {
+				base = resolveBase(base, href)
+			}
+		case atom.Link:
+			if !hasToken(attrs["rel"], "alternate") {
+				continue
+			}
+			mediaType, _, _ := mime.ParseMediaType(attrs["type"])
+			feedType, ok := feedLinkTypes[mediaType]
+			if !ok || strings.TrimSpace(attrs["href"]) == "" {
+				continue
+			}
+			feedURL := resolveBase(base, attrs["href"])
+			if !isHTTPURL(feedURL) || seen[feedURL] {
+				continue
+			}
+			seen[feedURL] = true
+			links = append(links, FeedLink{
+				URL:   feedURL,
+				Title: strings.TrimSpace(attrs["title"]),
+				Type:  feedType,
+			})
+		}
+	}
+}
+
+// hasToken reports whether a space-separated list such as rel contains
+// token, ignoring case.
+func hasToken(list, token string) bool {
+	for _, field := range strings.Fields(list) {
+		if strings.EqualFold(field, token) {
+			return true
+		}
+	}
+	return false
+}
+
+func feedTypeOf(feed Feed) FeedType {
+	switch feed.(type) {
+	case *AtomFeed:
+		return FeedTypeAtom
+	case *RDFFeed:
+		return FeedTypeRDF
+	case *JSONFeed:
+		return FeedTypeJSON
+	default:
+		return FeedTypeRSS
+	}
+}
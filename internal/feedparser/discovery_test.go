@@ -0,0 +1,211 @@
+package feedparser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+const discoveryRSS = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0">
+  <channel>
+    <title>Probed Feed</title>
+  </channel>
+</rss>`
+
+func TestFetchFeed_HTMLPage(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{
+			name:        "HTML5 page",
+			contentType: "text/html; charset=utf-8",
+			body:        "<!DOCTYPE html>\n<html><head><title>Home</title></head><body></body></html>",
+		},
+		{
+			name:        "page served as XML",
+			contentType: "application/xml",
+			body:        "<html><head><title>Home</title></head><body><p>Hi</p></body></html>",
+		},
+		{
+			name:        "XHTML page",
+			contentType: "application/xhtml+xml",
+			body: `<?xml version="1.0" encoding="UTF-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml"><head><title>Home</title></head><body></body></html>`,
+		},
+		{
+			name:        "markup without a doctype",
+			contentType: "text/html",
+			body:        "<head><title>Home</title></head><body><p>Hi & bye</p></body>",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tc.contentType)
+				fmt.Fprint(w, tc.body)
+			}))
+			defer server.Close()
+
+			_, err := FetchFeed(context.Background(), server.URL)
+			if !errors.Is(err, ErrNotFeed) {
+				t.Errorf("Expected ErrNotFeed, got %v", err)
+			}
+		})
+	}
+}
+
+func TestFetchFeed_FeedServedAsHTML(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, discoveryRSS)
+	}))
+	defer server.Close()
+
+	feed, err := FetchFeed(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("FetchFeed failed: %v", err)
+	}
+	if feed.GetTitle() != "Probed Feed" {
+		t.Errorf("Expected title %q, got %q", "Probed Feed", feed.GetTitle())
+	}
+}
+
+func TestDiscoverFeeds(t *testing.T) {
+	tests := []struct {
+		name     string
+		pages    map[string]string
+		path     string
+		expected func(base string) []FeedLink
+	}{
+		{
+			name: "alternate links",
+			pages: map[string]string{
+				"/blog/": `<!DOCTYPE html>
+<html>
+<head>
+  <link rel="stylesheet" href="/style.css">
+  <link rel="alternate" type="application/rss+xml" title="Posts (RSS)" href="/blog/rss.xml">
+  <link rel="Alternate" type="application/atom+xml; charset=utf-8" title=" Posts (Atom) " href="atom.xml">
+  <link rel="alternate" type="application/feed+json" href="https://feeds.example.com/posts.json">
+  <link rel="alternate" type="application/rss+xml" href="/blog/rss.xml">
+  <link rel="alternate" hreflang="fr" href="/fr/">
+  <link rel="alternate" type="application/rss+xml" href="javascript:alert(1)">
+</head>
+<body></body>
+</html>`,
+			},
+			path: "/blog/",
+			expected: func(base string) []FeedLink {
+				return []FeedLink{
+					{URL: base + "/blog/rss.xml", Title: "Posts (RSS)", Type: FeedTypeRSS},
+					{URL: base + "/blog/atom.xml", Title: "Posts (Atom)", Type: FeedTypeAtom},
+					{URL: "https://feeds.example.com/posts.json", Type: FeedTypeJSON},
+				}
+			},
+		},
+		{
+			name: "links relative to the base element",
+			pages: map[string]string{
+				"/": `<html><head>
+<base href="/static/">
+<link rel="alternate" type="application/atom+xml" href="feed.atom">
+</head></html>`,
+			},
+			path: "/",
+			expected: func(base string) []FeedLink {
+				return []FeedLink{
+					{URL: base + "/static/feed.atom", Type: FeedTypeAtom},
+				}
+			},
+		},
+		{
+			name: "links relative to the redirected page",
+			pages: map[string]string{
+				"/moved/": `<html><head>
+<link rel="alternate" type="application/rss+xml" href="feed">
+</head></html>`,
+			},
+			path: "/old",
+			expected: func(base string) []FeedLink {
+				return []FeedLink{
+					{URL: base + "/moved/feed", Type: FeedTypeRSS},
+				}
+			},
+		},
+		{
+			name: "well-known path",
+			pages: map[string]string{
+				"/":        `<html><head><title>No links</title></head></html>`,
+				"/feed":    `<html><head><title>Not a feed</title></head></html>`,
+				"/rss.xml": discoveryRSS,
+			},
+			path: "/",
+			expected: func(base string) []FeedLink {
+				return []FeedLink{
+					{URL: base + "/rss.xml", Title: "Probed Feed", Type: FeedTypeRSS},
+				}
+			},
+		},
+		{
+			name: "no feeds",
+			pages: map[string]string{
+				"/": `<html><head><title>No links</title></head></html>`,
+			},
+			path: "/",
+			expected: func(base string) []FeedLink {
+				return nil
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/old" {
+					http.Redirect(w, r, "/moved/", http.StatusMovedPermanently)
+					return
+				}
+				page, ok := tc.pages[r.URL.Path]
+				if !ok {
+					http.NotFound(w, r)
+					return
+				}
+				w.Header().Set("Content-Type", "text/html; charset=utf-8")
+				fmt.Fprint(w, page)
+			}))
+			defer server.Close()
+
+			links, err := DiscoverFeeds(context.Background(), server.URL+tc.path)
+			if err != nil {
+				t.Fatalf("DiscoverFeeds failed: %v", err)
+			}
+			if expected := tc.expected(server.URL); !reflect.DeepEqual(links, expected) {
+				t.Errorf("Expected %+v, got %+v", expected, links)
+			}
+		})
+	}
+}
+
+func TestDiscoverFeeds_PageCharset(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=iso-8859-1")
+		fmt.Fprint(w, "<html><head><link rel=\"alternate\" type=\"application/rss+xml\" title=\"Caf\xe9\" href=\"/rss\"></head></html>")
+	}))
+	defer server.Close()
+
+	links, err := DiscoverFeeds(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("DiscoverFeeds failed: %v", err)
+	}
+	if len(links) != 1 || links[0].Title != "Café" {
+		t.Errorf("Expected one feed titled Café, got %+v", links)
+	}
+}
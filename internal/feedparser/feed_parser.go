@@ -7,6 +7,8 @@ import (
 	"html"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -16,20 +18,40 @@ type FeedType string
 const (
 	FeedTypeAtom FeedType = "atom"
 	FeedTypeRSS  FeedType = "rss"
+	FeedTypeRDF  FeedType = "rdf"
+	FeedTypeJSON FeedType = "json"
 )
 
+const userAgent = "Gator Feed Reader/1.1.0"
+
 type Feed interface {
 	GetTitle() string
 	GetLink() string
 	GetDescription() string
 	GetItems() []Item
+	GetUpdateHints() UpdateHints
 }
 
 type Item interface {
+	// GetGUID returns the item's own identifier, such as an RSS <guid> or an
+	// Atom <id>, or "" when it has none.
+	GetGUID() string
 	GetTitle() string
 	GetLink() string
+	// GetDescription returns the item's text, without markup.
 	GetDescription() *string
+	// GetContent returns the item's body as sanitized HTML, with relative
+	// URLs resolved, or "" when it has none.
+	GetContent() string
+	GetAuthor() string
+	// GetDate returns the zero time when the feed gave no readable date.
 	GetDate() time.Time
+	// GetEnclosures returns the item's media files, such as a podcast
+	// episode's audio.
+	GetEnclosures() []Enclosure
+	// GetThumbnail returns the URL of an image representing the item, or ""
+	// when it has none.
+	GetThumbnail() string
 }
 
 type rssXML struct {
@@ -37,40 +59,93 @@ type rssXML struct {
 		Title       string       `xml:"title"`
 		Link        string       `xml:"link"`
 		Description string       `xml:"description"`
+		TTL         string       `xml:"ttl"`
+		SkipHours   []string     `xml:"skipHours>hour"`
+		SkipDays    []string     `xml:"skipDays>day"`
 		Item        []rssItemXML `xml:"item"`
+		syndicationXML
 	} `xml:"channel"`
 }
 
 type rssItemXML struct {
-	Title       string `xml:"title"`
-	Link        string `xml:"link"`
-	Description string `xml:"description"`
-	Date        string `xml:"pubDate"`
+	dublinCoreXML
+	mediaXML
+	ContentEncoded string `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	GUID           struct {
+		IsPermaLink string `xml:"isPermaLink,attr"`
+		Value       string `xml:",chardata"`
+	} `xml:"guid"`
+	Title       string         `xml:"title"`
+	Link        string         `xml:"link"`
+	Description string         `xml:"description"`
+	Author      string         `xml:"author"`
+	Date        string         `xml:"pubDate"`
+	Enclosures  []enclosureXML `xml:"enclosure"`
 }
 
 type atomXML struct {
-	Title string `xml:"title"`
-	Links []struct {
-		Rel  string `xml:"rel,attr"`
-		URL  string `xml:"href,attr"`
-		Type string `xml:"type,attr"`
-	} `xml:"link"`
-	Description string        `xml:"description"`
-	Item        []atomItemXML `xml:"entry"`
+	Base     string        `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
+	Title    atomTextXML   `xml:"title"`
+	Subtitle atomTextXML   `xml:"subtitle"`
+	Links    []atomLinkXML `xml:"link"`
+	Authors  []string      `xml:"author>name"`
+	Item     []atomItemXML `xml:"entry"`
+	syndicationXML
 }
 
 type atomItemXML struct {
-	Title string `xml:"title"`
-	Links []struct {
-		Rel  string `xml:"rel,attr"`
-		URL  string `xml:"href,attr"`
-		Type string `xml:"type,attr"`
-	} `xml:"link"`
-	Content struct {
-		Type string `xml:"type,attr"`
-		Data string `xml:",chardata"`
-	} `xml:"content"`
-	Date string `xml:"updated"`
+	mediaXML
+	Base      string        `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
+	ID        string        `xml:"id"`
+	Title     atomTextXML   `xml:"title"`
+	Links     []atomLinkXML `xml:"link"`
+	Summary   atomTextXML   `xml:"summary"`
+	Content   atomTextXML   `xml:"content"`
+	Authors   []string      `xml:"author>name"`
+	Published string        `xml:"published"`
+	Updated   string        `xml:"updated"`
+}
+
+type atomLinkXML struct {
+	Base   string `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
+	Rel    string `xml:"rel,attr"`
+	URL    string `xml:"href,attr"`
+	Type   string `xml:"type,attr"`
+	Length string `xml:"length,attr"`
+}
+
+// atomTextXML is an Atom text construct, whose type says whether it holds
+// plain text, escaped HTML or inline XHTML.
+type atomTextXML struct {
+	Base     string `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
+	Type     string `xml:"type,attr"`
+	Data     string `xml:",chardata"`
+	InnerXML string `xml:",innerxml"`
+}
+
+// text returns the construct as plain text.
+func (t atomTextXML) text() string {
+	switch strings.ToLower(strings.TrimSpace(t.Type)) {
+	case "html", "text/html":
+		return htmlToText(t.Data)
+	case "xhtml", "application/xhtml+xml":
+		return htmlToText(t.InnerXML)
+	default:
+		return strings.TrimSpace(t.Data)
+	}
+}
+
+// html returns the construct as sanitized HTML, resolving relative URLs
+// against base.
+func (t atomTextXML) html(base string) string {
+	switch strings.ToLower(strings.TrimSpace(t.Type)) {
+	case "html", "text/html":
+		return sanitizeHTML(t.Data, base)
+	case "xhtml", "application/xhtml+xml":
+		return sanitizeHTML(t.InnerXML, base)
+	default:
+		return textToHTML(t.Data)
+	}
 }
 
 type RSSFeed struct {
@@ -78,13 +153,19 @@ type RSSFeed struct {
 	link        string
 	description string
 	items       []*RSSItem
+	hints       UpdateHints
 }
 
 type RSSItem struct {
+	guid        string
 	title       string
 	link        string
 	description string
+	content     string
+	author      string
 	date        time.Time
+	enclosures  []Enclosure
+	thumbnail   string
 }
 
 type AtomFeed struct {
@@ -92,13 +173,19 @@ type AtomFeed struct {
 	link        string
 	description string
 	items       []*AtomItem
+	hints       UpdateHints
 }
 
 type AtomItem struct {
+	guid        string
 	title       string
 	link        string
 	description string
+	content     string
+	author      string
 	date        time.Time
+	enclosures  []Enclosure
+	thumbnail   string
 }
 
 func (f *RSSFeed) GetTitle() string {
@@ -121,6 +208,14 @@ func (f *RSSFeed) GetItems() []Item {
 	return items
 }
 
+func (f *RSSFeed) GetUpdateHints() UpdateHints {
+	return f.hints
+}
+
+func (i *RSSItem) GetGUID() string {
+	return i.guid
+}
+
 func (i *RSSItem) GetTitle() string {
 	return i.title
 }
@@ -133,10 +228,26 @@ func (i *RSSItem) GetDescription() *string {
 	return &i.description
 }
 
+func (i *RSSItem) GetContent() string {
+	return i.content
+}
+
+func (i *RSSItem) GetAuthor() string {
+	return i.author
+}
+
 func (i *RSSItem) GetDate() time.Time {
 	return i.date
 }
 
+func (i *RSSItem) GetEnclosures() []Enclosure {
+	return i.enclosures
+}
+
+func (i *RSSItem) GetThumbnail() string {
+	return i.thumbnail
+}
+
 func (f *AtomFeed) GetTitle() string {
 	return f.title
 }
@@ -157,6 +268,14 @@ func (f *AtomFeed) GetItems() []Item {
 	return items
 }
 
+func (f *AtomFeed) GetUpdateHints() UpdateHints {
+	return f.hints
+}
+
+func (i *AtomItem) GetGUID() string {
+	return i.guid
+}
+
 func (i *AtomItem) GetTitle() string {
 	return i.title
 }
@@ -169,59 +288,28 @@ func (i *AtomItem) GetDescription() *string {
 	return &i.description
 }
 
-func (i *AtomItem) GetDate() time.Time {
-	return i.date
+func (i *AtomItem) GetContent() string {
+	return i.content
 }
 
-func stripHTMLTags(htmlContent string) string {
-	// First unescape HTML entities
-	text := html.UnescapeString(htmlContent)
-
-	// Remove HTML tags
-	var result strings.Builder
-	inTag := false
-	for _, char := range text {
-		if char == '<' {
-			inTag = true
-			continue
-		}
-		if char == '>' {
-			inTag = false
-			continue
-		}
-		if !inTag {
-			result.WriteRune(char)
-		}
-	}
-
-	// Clean up whitespace
-	return strings.TrimSpace(result.String())
+func (i *AtomItem) GetAuthor() string {
+	return i.author
 }
 
-func parseDate(date string) (time.Time, error) {
-	// Try RFC1123Z first (with timezone offset)
-	parsed, err := time.Parse(time.RFC1123Z, date)
-	if err == nil {
-		return parsed, nil
-	}
-
-	// Try RFC1123 (with GMT)
-	parsed, err = time.Parse(time.RFC1123, date)
-	if err == nil {
-		return parsed, nil
-	}
+func (i *AtomItem) GetDate() time.Time {
+	return i.date
+}
 
-	// Try ISO8601 format
-	parsed, err = time.Parse(time.RFC3339, date)
-	if err == nil {
-		return parsed, nil
-	}
+func (i *AtomItem) GetEnclosures() []Enclosure {
+	return i.enclosures
+}
 
-	return time.Time{}, fmt.Errorf("failed to parse date: %s", date)
+func (i *AtomItem) GetThumbnail() string {
+	return i.thumbnail
 }
 
 func detectFeedType(body []byte) (FeedType, error) {
-	decoder := xml.NewDecoder(strings.NewReader(string(body)))
+	decoder := newXMLDecoder(body)
 
 	token, err := decoder.Token()
 	if err != nil {
@@ -235,10 +323,18 @@ func detectFeedType(body []byte) (FeedType, error) {
 				return FeedTypeAtom, nil
 			}
 
-			if t.Name.Local == "rss" || t.Name.Local == "rdf" {
+			if t.Name.Space == rdfNamespace && t.Name.Local == "RDF" {
+				return FeedTypeRDF, nil
+			}
+
+			if t.Name.Local == "rss" {
 				return FeedTypeRSS, nil
 			}
 
+			if t.Name.Local == "html" {
+				return "", ErrNotFeed
+			}
+
 			for _, attr := range t.Attr {
 				if attr.Name.Space == "xmlns" && strings.Contains(attr.Value, "atom") {
 					return FeedTypeAtom, nil
@@ -263,137 +359,243 @@ func detectFeedType(body []byte) (FeedType, error) {
 	}
 }
 
-// FetchResult represents the result of a feed fetch operation
-type FetchResult struct {
-	Feed         Feed
-	StatusCode   int
-	ETag         string
-	LastModified string
-	NotModified  bool
+// HTTPError is returned when a feed responds with a status other than 200 or
+// 304. RetryAfter is set when the server sent a usable Retry-After header.
+type HTTPError struct {
+	StatusCode int
+	RetryAfter time.Duration
 }
 
-// FetchFeed fetches a feed with optional conditional headers
-func FetchFeed(ctx context.Context, feedURL string) (Feed, error) {
-	result, err := FetchFeedWithConditionals(ctx, feedURL, nil, nil)
-	if err != nil {
-		return nil, err
+func (e *HTTPError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("status code: %d (retry after %s)", e.StatusCode, e.RetryAfter)
 	}
-	return result.Feed, nil
+	return fmt.Sprintf("status code: %d", e.StatusCode)
 }
 
-// FetchFeedWithConditionals fetches a feed with conditional request headers
-func FetchFeedWithConditionals(ctx context.Context, feedURL string, etag, lastModified *string) (*FetchResult, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", feedURL, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	req.Header.Set("User-Agent", "Gator Feed Reader/1.1.0")
+// Temporary reports whether the request is worth retrying later, which is
+// the case for rate limiting and server errors.
+func (e *HTTPError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
 
-	// Add conditional headers if provided
-	if etag != nil && *etag != "" {
-		req.Header.Set("If-None-Match", *etag)
-	}
-	if lastModified != nil && *lastModified != "" {
-		req.Header.Set("If-Modified-Since", *lastModified)
+// parseRetryAfter reads a Retry-After header, which is either a number of
+// seconds or an HTTP date. It returns 0 when the header is missing, invalid
+// or already in the past.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
 	}
 
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, err
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
 	}
-	defer resp.Body.Close()
 
-	result := &FetchResult{
-		StatusCode:   resp.StatusCode,
-		ETag:         resp.Header.Get("ETag"),
-		LastModified: resp.Header.Get("Last-Modified"),
-		NotModified:  resp.StatusCode == http.StatusNotModified,
+	if date, err := http.ParseTime(value); err == nil {
+		if d := date.Sub(now); d > 0 {
+			return d
+		}
 	}
 
-	// Handle 304 Not Modified response
-	if resp.StatusCode == http.StatusNotModified {
-		return result, nil
-	}
+	return 0
+}
 
-	// Handle other non-200 status codes
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("status code: %d", resp.StatusCode)
-	}
+// FetchResult represents the result of a feed fetch operation
+type FetchResult struct {
+	Feed         Feed
+	StatusCode   int
+	ETag         string
+	LastModified string
+	NotModified  bool
+	// PermanentURL is where the feed has permanently moved to, when the
+	// request was answered with a 301 or 308 redirect.
+	PermanentURL string
+	// PageErr is set when a later page of a paged feed couldn't be fetched.
+	// Feed then holds the items of the pages before it.
+	PageErr error
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
+// FetchFeed fetches a feed with DefaultFetcher.
+func FetchFeed(ctx context.Context, feedURL string) (Feed, error) {
+	return DefaultFetcher.FetchFeed(ctx, feedURL)
+}
 
-	// Detect feed type by parsing XML namespace
-	feedType, err := detectFeedType(body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to detect feed type: %w", err)
+// FetchFeedWithConditionals fetches a feed with conditional request headers
+// with DefaultFetcher.
+func FetchFeedWithConditionals(ctx context.Context, feedURL string, etag, lastModified *string) (*FetchResult, error) {
+	return DefaultFetcher.FetchFeedWithConditionals(ctx, feedURL, etag, lastModified)
+}
+
+// parseFeed parses a fetched feed of any supported format. header holds the
+// extra headers to fetch further pages with.
+func (f *Fetcher) parseFeed(ctx context.Context, feedURL string, header http.Header, contentType string, body []byte) (Feed, error) {
+	if isHTMLPage(contentType, body) {
+		return nil, ErrNotFeed
 	}
 
-	if feedType == FeedTypeAtom {
-		feed, err := parseAtomFeed(body)
+	// JSON Feeds are recognized by Content-Type or shape, everything else by
+	// parsing the XML namespace
+	feedType := FeedTypeJSON
+	if !isJSONFeed(contentType, body) {
+		var err error
+		body, err = decodeXML(body, contentType)
 		if err != nil {
 			return nil, err
 		}
-		result.Feed = feed
-	} else {
-		feed, err := parseRSSFeed(body)
+		feedType, err = detectFeedType(body)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("failed to detect feed type: %w", err)
 		}
-		result.Feed = feed
 	}
 
-	return result, nil
+	switch feedType {
+	case FeedTypeJSON:
+		return f.fetchJSONFeed(ctx, feedURL, header, body)
+	case FeedTypeAtom:
+		return parseAtomFeed(body, feedURL)
+	case FeedTypeRDF:
+		return parseRDFFeed(body, feedURL)
+	default:
+		return parseRSSFeed(body, feedURL)
+	}
 }
 
-func parseAtomFeed(body []byte) (Feed, error) {
+func parseAtomFeed(body []byte, feedURL string) (Feed, error) {
 	var xmlFeed atomXML
-	if err := xml.Unmarshal(body, &xmlFeed); err != nil {
+	if err := unmarshalXML(body, &xmlFeed); err != nil {
 		return nil, err
 	}
 
+	// Relative references resolve against the nearest xml:base, which may
+	// itself be relative to an outer one or to the feed's own URL
+	feedBase := resolveBase(feedURL, xmlFeed.Base)
+
 	feed := &AtomFeed{
-		title:       html.UnescapeString(xmlFeed.Title),
-		description: html.UnescapeString(xmlFeed.Description),
-		items:       make([]*AtomItem, len(xmlFeed.Item)),
+		title:       xmlFeed.Title.text(),
+		link:        alternateLink(feedBase, xmlFeed.Links),
+		description: xmlFeed.Subtitle.text(),
+		items:       make([]*AtomItem, 0, len(xmlFeed.Item)),
+		hints: UpdateHints{
+			UpdatePeriod: xmlFeed.period(),
+		},
 	}
 
-	for _, link := range xmlFeed.Links {
-		if link.Rel == "self" {
-			feed.link = link.URL
-			break
+	for _, item := range xmlFeed.Item {
+		// Items with unreadable dates keep a zero date rather than being dropped
+		parsedDate, _ := parseDate(firstNonEmpty(item.Published, item.Updated))
+
+		description := item.Content.text()
+		if description == "" {
+			description = item.Summary.text()
 		}
-	}
 
-	for i, item := range xmlFeed.Item {
-		parsedDate, err := parseDate(item.Date)
-		if err != nil {
-			return nil, err
+		itemBase := resolveBase(feedBase, item.Base)
+		content := item.Content.html(resolveBase(itemBase, item.Content.Base))
+		if content == "" {
+			content = item.Summary.html(resolveBase(itemBase, item.Summary.Base))
+		}
+
+		// Entries without an author inherit the feed's
+		authors := item.Authors
+		if len(authors) == 0 {
+			authors = xmlFeed.Authors
 		}
 
-		feed.items[i] = &AtomItem{
-			title:       html.UnescapeString(item.Title),
-			description: stripHTMLTags(item.Content.Data),
+		enclosures := item.enclosures(enclosureLinks(itemBase, item.Links))
+
+		feed.items = append(feed.items, &AtomItem{
+			guid:        strings.TrimSpace(item.ID),
+			title:       item.Title.text(),
+			link:        alternateLink(itemBase, item.Links),
+			description: description,
+			content:     content,
+			author:      joinNames(authors),
 			date:        parsedDate,
+			enclosures:  enclosures,
+			thumbnail:   item.thumbnail(enclosures),
+		})
+	}
+
+	return feed, nil
+}
+
+// alternateLink picks the link to show for a feed or entry. A link with no
+// rel is an alternate link, and an HTML alternate is preferred over others.
+func alternateLink(base string, links []atomLinkXML) string {
+	var alternate string
+	for _, link := range links {
+		rel := strings.TrimSpace(link.Rel)
+		if rel != "" && rel != "alternate" {
+			continue
 		}
 
-		for _, link := range item.Links {
-			if link.Rel == "alternate" {
-				feed.items[i].link = link.URL
-				break
-			}
+		href := resolveBase(resolveBase(base, link.Base), link.URL)
+		linkType := strings.ToLower(strings.TrimSpace(link.Type))
+		if linkType == "" || linkType == "text/html" {
+			return href
+		}
+		if alternate == "" {
+			alternate = href
 		}
 	}
+	return alternate
+}
 
-	return feed, nil
+// enclosureLinks reads an entry's rel="enclosure" links.
+func enclosureLinks(base string, links []atomLinkXML) []Enclosure {
+	var enclosures []Enclosure
+	for _, link := range links {
+		if strings.TrimSpace(link.Rel) != "enclosure" {
+			continue
+		}
+		enclosures = appendEnclosure(enclosures, Enclosure{
+			URL:      resolveBase(resolveBase(base, link.Base), link.URL),
+			MimeType: link.Type,
+			Length:   parseLength(link.Length),
+		})
+	}
+	return enclosures
 }
 
-func parseRSSFeed(body []byte) (Feed, error) {
+// resolveBase resolves ref against base, returning base when ref is empty
+// and ref unchanged when either can't be parsed.
+func resolveBase(base, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return base
+	}
+	if base == "" {
+		return ref
+	}
+	resolved, err := resolveURL(base, ref)
+	if err != nil {
+		return ref
+	}
+	return resolved
+}
+
+func isHTTPURL(value string) bool {
+	parsed, err := url.Parse(value)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+func joinNames(names []string) string {
+	var trimmed []string
+	for _, name := range names {
+		if name = strings.TrimSpace(name); name != "" {
+			trimmed = append(trimmed, name)
+		}
+	}
+	return strings.Join(trimmed, ", ")
+}
+
+func parseRSSFeed(body []byte, feedURL string) (Feed, error) {
 	var xmlFeed rssXML
-	if err := xml.Unmarshal(body, &xmlFeed); err != nil {
+	if err := unmarshalXML(body, &xmlFeed); err != nil {
 		return nil, err
 	}
 
@@ -401,21 +603,55 @@ func parseRSSFeed(body []byte) (Feed, error) {
 		title:       html.UnescapeString(xmlFeed.Channel.Title),
 		link:        xmlFeed.Channel.Link,
 		description: html.UnescapeString(xmlFeed.Channel.Description),
-		items:       make([]*RSSItem, len(xmlFeed.Channel.Item)),
+		items:       make([]*RSSItem, 0, len(xmlFeed.Channel.Item)),
+		hints: UpdateHints{
+			TTL:          parseTTL(xmlFeed.Channel.TTL),
+			UpdatePeriod: xmlFeed.Channel.period(),
+			SkipHours:    parseSkipHours(xmlFeed.Channel.SkipHours),
+			SkipDays:     parseSkipDays(xmlFeed.Channel.SkipDays),
+		},
 	}
 
-	for i, item := range xmlFeed.Channel.Item {
-		parsedDate, err := parseDate(item.Date)
-		if err != nil {
-			return nil, err
+	// Relative URLs in content are taken to be relative to the item's page,
+	// or failing that to the site or the feed itself
+	channelBase := resolveBase(feedURL, xmlFeed.Channel.Link)
+
+	for _, item := range xmlFeed.Channel.Item {
+		// Items with unreadable dates keep a zero date rather than being dropped
+		parsedDate, _ := parseDate(firstNonEmpty(item.Date, item.DCDate))
+
+		// A permalink GUID doubles as the link when there is no <link>
+		guid := strings.TrimSpace(item.GUID.Value)
+		link := strings.TrimSpace(item.Link)
+		if link == "" && !strings.EqualFold(item.GUID.IsPermaLink, "false") && isHTTPURL(guid) {
+			link = guid
 		}
 
-		feed.items[i] = &RSSItem{
-			title:       html.UnescapeString(item.Title),
-			link:        item.Link,
-			description: html.UnescapeString(item.Description),
-			date:        parsedDate,
+		var enclosures []Enclosure
+		for _, enclosure := range item.Enclosures {
+			enclosures = appendEnclosure(enclosures, Enclosure{
+				URL:      enclosure.URL,
+				MimeType: enclosure.Type,
+				Length:   parseLength(enclosure.Length),
+			})
 		}
+		enclosures = item.enclosures(enclosures)
+
+		// The description is often a summary of content:encoded, and either
+		// may hold HTML
+		description := firstNonEmpty(item.Description, item.DCDescription, item.ContentEncoded)
+
+		feed.items = append(feed.items, &RSSItem{
+			guid:        guid,
+			title:       html.UnescapeString(firstNonEmpty(item.Title, item.DCTitle, item.ITunesTitle)),
+			link:        link,
+			description: htmlToText(description),
+			content:     sanitizeHTML(firstNonEmpty(item.ContentEncoded, description), resolveBase(channelBase, link)),
+			author:      html.UnescapeString(strings.TrimSpace(firstNonEmpty(item.DCCreator, item.Author, item.ITunesAuthor))),
+			date:        parsedDate,
+			enclosures:  enclosures,
+			thumbnail:   item.thumbnail(enclosures),
+		})
 	}
 
 	return feed, nil
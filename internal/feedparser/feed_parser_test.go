@@ -2,8 +2,11 @@ package feedparser
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -45,6 +48,9 @@ func compareFeeds(expected, actual Feed) bool {
 
 // Helper function to compare items
 func compareItems(expected, actual Item) bool {
+	if expected.GetGUID() != actual.GetGUID() {
+		return false
+	}
 	if expected.GetTitle() != actual.GetTitle() {
 		return false
 	}
@@ -65,9 +71,18 @@ func compareItems(expected, actual Item) bool {
 		return false
 	}
 
+	if expected.GetAuthor() != actual.GetAuthor() {
+		return false
+	}
 	if !expected.GetDate().Equal(actual.GetDate()) {
 		return false
 	}
+	if !reflect.DeepEqual(expected.GetEnclosures(), actual.GetEnclosures()) {
+		return false
+	}
+	if expected.GetThumbnail() != actual.GetThumbnail() {
+		return false
+	}
 	return true
 }
 
@@ -145,14 +160,62 @@ func TestFetchFeed(t *testing.T) {
 			expectedError: false,
 			expectedFeed: &AtomFeed{
 				title:       "David Heinemeier Hansson",
-				link:        "https://world.hey.com/dhh/feed.atom",
+				link:        "https://world.hey.com/dhh",
 				description: "",
 				items: []*AtomItem{
 					{
+						guid:        "tag:world.hey.com,2005:World::Post/42931",
 						title:       "Don't make Google sell Chrome",
 						link:        "https://world.hey.com/dhh/don-t-make-google-sell-chrome-93cefbc6",
 						description: "The web will be far worse off if Google is forced to sell Chrome, even if it's to atone for legitimate ad-market monopoly abuses.",
-						date:        time.Date(2025, 4, 28, 6, 0, 48, 0, time.UTC), // Use <updated> date
+						author:      "David Heinemeier Hansson",
+						date:        time.Date(2025, 4, 28, 6, 0, 37, 0, time.UTC), // Use <published> date
+					},
+				},
+			},
+		},
+		{
+			name: "item with unreadable date has no date",
+			serverResponse: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/rss+xml")
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+				<rss version="2.0">
+					<channel>
+						<title>Test Feed</title>
+						<link>https://example.com</link>
+						<description>Test Description</description>
+						<item>
+							<title>Broken Item</title>
+							<link>https://example.com/broken</link>
+							<description>Broken Item Description</description>
+							<pubDate>sometime last week</pubDate>
+						</item>
+						<item>
+							<title>Test Item</title>
+							<link>https://example.com/item</link>
+							<description>Test Item Description</description>
+							<pubDate>Wed, 01 Jan 2024 12:00:00 GMT</pubDate>
+						</item>
+					</channel>
+				</rss>`))
+			},
+			expectedError: false,
+			expectedFeed: &RSSFeed{
+				title:       "Test Feed",
+				link:        "https://example.com",
+				description: "Test Description",
+				items: []*RSSItem{
+					{
+						title:       "Broken Item",
+						link:        "https://example.com/broken",
+						description: "Broken Item Description",
+					},
+					{
+						title:       "Test Item",
+						link:        "https://example.com/item",
+						description: "Test Item Description",
+						date:        time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
 					},
 				},
 			},
@@ -241,3 +304,569 @@ func TestFetchFeed(t *testing.T) {
 		})
 	}
 }
+
+func TestFetchFeed_UpdateHints(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected UpdateHints
+	}{
+		{
+			name: "RSS ttl and skip hints",
+			body: `<?xml version="1.0" encoding="UTF-8"?>
+			<rss version="2.0">
+				<channel>
+					<title>Test Feed</title>
+					<ttl>120</ttl>
+					<skipHours><hour>0</hour><hour>24</hour><hour>6</hour></skipHours>
+					<skipDays><day>Saturday</day><day>sunday</day><day>Caturday</day></skipDays>
+				</channel>
+			</rss>`,
+			expected: UpdateHints{
+				TTL:       2 * time.Hour,
+				SkipHours: []int{0, 0, 6},
+				SkipDays:  []time.Weekday{time.Saturday, time.Sunday},
+			},
+		},
+		{
+			name: "RSS syndication module",
+			body: `<?xml version="1.0" encoding="UTF-8"?>
+			<rss version="2.0" xmlns:sy="http://purl.org/rss/1.0/modules/syndication/">
+				<channel>
+					<title>Test Feed</title>
+					<sy:updatePeriod>daily</sy:updatePeriod>
+					<sy:updateFrequency>4</sy:updateFrequency>
+				</channel>
+			</rss>`,
+			expected: UpdateHints{
+				UpdatePeriod: 6 * time.Hour,
+			},
+		},
+		{
+			name: "Atom syndication module",
+			body: `<?xml version="1.0" encoding="UTF-8"?>
+			<feed xmlns="http://www.w3.org/2005/Atom" xmlns:sy="http://purl.org/rss/1.0/modules/syndication/">
+				<title>Test Feed</title>
+				<sy:updatePeriod>hourly</sy:updatePeriod>
+			</feed>`,
+			expected: UpdateHints{
+				UpdatePeriod: time.Hour,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(tc.body))
+			}))
+			defer server.Close()
+
+			feed, err := FetchFeed(context.Background(), server.URL)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(feed.GetUpdateHints(), tc.expected) {
+				t.Errorf("expected hints %+v, got %+v", tc.expected, feed.GetUpdateHints())
+			}
+		})
+	}
+}
+
+func TestFetchFeed_Fixtures(t *testing.T) {
+	jsonFeed := &JSONFeed{
+		title:       "Example Microblog",
+		link:        "https://micro.example.com/",
+		description: "Short posts and a podcast",
+		items: []*JSONFeedItem{
+			{
+				guid:        "https://micro.example.com/2024/06/01/episode-12",
+				title:       "Episode 12",
+				link:        "https://micro.example.com/2024/06/01/episode-12",
+				description: "We talk about feeds & readers.",
+				author:      "Host One, Host Two",
+				date:        time.Date(2024, 6, 1, 14, 0, 0, 0, time.UTC),
+				enclosures: []Enclosure{
+					{
+						URL:      "https://cdn.example.com/episode-12.mp3",
+						MimeType: "audio/mpeg",
+						Length:   23456789,
+						Duration: 30 * time.Minute,
+					},
+				},
+				thumbnail: "https://cdn.example.com/episode-12.jpg",
+			},
+			{
+				guid:        "2",
+				title:       "Just shipped a new release.",
+				link:        "https://micro.example.com/2024/05/30/note",
+				description: "Just shipped a new release.\nMore details tomorrow.",
+				author:      "Feed Author",
+				date:        time.Date(2024, 5, 30, 8, 15, 0, 0, time.UTC),
+			},
+			{
+				guid:        "3",
+				title:       "Linked elsewhere",
+				link:        "https://elsewhere.example.org/article",
+				description: "A link post",
+				author:      "Legacy Author",
+				date:        time.Date(2024, 5, 29, 12, 0, 0, 0, time.UTC),
+			},
+			{
+				guid:        "4",
+				title:       "No date",
+				link:        "https://micro.example.com/undated",
+				description: "Kept without a date",
+				author:      "Feed Author",
+			},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		fixture       string
+		contentType   string
+		expectedFeed  Feed
+		expectedHints UpdateHints
+	}{
+		{
+			name:        "RSS 1.0 (RDF) with Dublin Core",
+			fixture:     "testdata/rss1.rdf",
+			contentType: "application/rdf+xml",
+			expectedFeed: &RDFFeed{
+				title:       "Example News",
+				link:        "https://news.example.org/",
+				description: "News for & about examples",
+				items: []*RDFItem{
+					{
+						guid:        "https://news.example.org/story/1",
+						title:       "First story",
+						link:        "https://news.example.org/story/1",
+						description: "The first story.",
+						author:      "Ada Lovelace",
+						date:        time.Date(2024, 3, 1, 17, 5, 0, 0, time.UTC),
+					},
+					{
+						guid:        "https://news.example.org/story/2",
+						title:       "Second story",
+						link:        "https://news.example.org/story/2", // From rdf:about
+						description: "Only Dublin Core here.",
+						date:        time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+					},
+					{
+						guid:        "https://news.example.org/story/3",
+						title:       "Undated story",
+						link:        "https://news.example.org/story/3",
+						description: "Falls back to the channel date.",
+						date:        time.Date(2024, 3, 2, 9, 30, 0, 0, time.UTC),
+					},
+				},
+			},
+			expectedHints: UpdateHints{
+				UpdatePeriod: 30 * time.Minute,
+			},
+		},
+		{
+			name:        "Atom with xml:base",
+			fixture:     "testdata/atom.xml",
+			contentType: "application/atom+xml",
+			expectedFeed: &AtomFeed{
+				title:       "Example <Atom> Blog",
+				link:        "https://blog.example.net/",
+				description: "Notes on RFC 4287",
+				items: []*AtomItem{
+					{
+						guid:        "tag:blog.example.net,2024:1",
+						title:       "Summary only",
+						link:        "https://blog.example.net/posts/1",
+						description: "Only a summary here.",
+						author:      "Entry Author, Co Author",
+						date:        time.Date(2024, 7, 1, 9, 0, 0, 0, time.UTC),
+					},
+					{
+						guid:        "tag:blog.example.net,2024:2",
+						title:       "An XHTML title",
+						link:        "https://blog.example.net/archive/2024/second",
+						description: "Inline XHTML content.",
+						author:      "Feed Author",
+						date:        time.Date(2024, 7, 3, 7, 0, 0, 0, time.UTC),
+						enclosures: []Enclosure{
+							{
+								URL:      "https://blog.example.net/archive/2024/media/second.ogg",
+								MimeType: "audio/ogg",
+								Length:   5120000,
+							},
+						},
+					},
+					{
+						guid:        "tag:blog.example.net,2024:3",
+						title:       "Plain <text> title",
+						link:        "https://cdn.example.net/mirror/third",
+						description: "Escaped HTML",
+						author:      "Feed Author",
+						date:        time.Date(2024, 7, 4, 0, 0, 0, 0, time.UTC),
+					},
+				},
+			},
+		},
+		{
+			name:         "JSON Feed",
+			fixture:      "testdata/jsonfeed.json",
+			contentType:  "application/feed+json",
+			expectedFeed: jsonFeed,
+		},
+		{
+			name:         "JSON Feed sniffed from body",
+			fixture:      "testdata/jsonfeed.json",
+			contentType:  "text/plain; charset=utf-8",
+			expectedFeed: jsonFeed,
+		},
+		{
+			name:        "RSS 2.0 podcast with Media RSS and iTunes",
+			fixture:     "testdata/podcast.xml",
+			contentType: "application/rss+xml",
+			expectedFeed: &RSSFeed{
+				title:       "Example Podcast",
+				link:        "https://podcast.example.com/",
+				description: "Conversations about feeds",
+				items: []*RSSItem{
+					{
+						guid:        "episode-3",
+						title:       "Episode 3: Enclosures",
+						link:        "https://podcast.example.com/3",
+						description: "How podcasts attach audio.",
+						author:      "Host One",
+						date:        time.Date(2024, 6, 3, 9, 0, 0, 0, time.UTC),
+						enclosures: []Enclosure{
+							{
+								URL:      "https://cdn.example.com/episode-3.mp3",
+								MimeType: "audio/mpeg",
+								Length:   34216300,
+								Duration: 2851 * time.Second, // From media:content
+							},
+						},
+						thumbnail: "https://cdn.example.com/episode-3.jpg",
+					},
+					{
+						guid:        "episode-2",
+						title:       "Episode 2: Video",
+						link:        "https://podcast.example.com/2",
+						description: "This one has video.",
+						date:        time.Date(2024, 5, 27, 9, 0, 0, 0, time.UTC),
+						enclosures: []Enclosure{
+							{
+								URL:      "https://cdn.example.com/episode-2.mp4",
+								MimeType: "video/mp4",
+								Length:   98765432,
+								Duration: time.Hour + 2*time.Minute + 3*time.Second,
+							},
+							{
+								URL:      "https://cdn.example.com/episode-2.webm?token=abc",
+								MimeType: "video/webm",
+							},
+						},
+						thumbnail: "https://cdn.example.com/episode-2.jpg",
+					},
+					{
+						guid:  "episode-1",
+						title: "Episode 1: Photo",
+						link:  "https://podcast.example.com/1",
+						date:  time.Date(2024, 5, 20, 9, 0, 0, 0, time.UTC),
+						enclosures: []Enclosure{
+							{
+								URL:      "https://cdn.example.com/studio.png",
+								MimeType: "image/png",
+							},
+						},
+						thumbnail: "https://cdn.example.com/studio.png",
+					},
+				},
+			},
+		},
+		{
+			name:        "RSS 2.0 with Dublin Core",
+			fixture:     "testdata/rss2_dublin_core.xml",
+			contentType: "application/rss+xml",
+			expectedFeed: &RSSFeed{
+				title:       "Dublin Core Blog",
+				link:        "https://blog.example.com/",
+				description: "An RSS 2.0 feed dated with dc:date",
+				items: []*RSSItem{
+					{
+						title:       "Post with dc:date",
+						link:        "https://blog.example.com/posts/1",
+						description: "No pubDate, only dc:date.",
+						author:      "Grace Hopper",
+						date:        time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC),
+					},
+					{
+						title:       "Post with both",
+						link:        "https://blog.example.com/posts/2",
+						description: "pubDate wins over dc:date.",
+						author:      "editor@example.com (The Editor)",
+						date:        time.Date(2024, 5, 7, 10, 0, 0, 0, time.UTC),
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			body, err := os.ReadFile(tc.fixture)
+			if err != nil {
+				t.Fatalf("failed to read fixture: %v", err)
+			}
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tc.contentType)
+				w.Write(body)
+			}))
+			defer server.Close()
+
+			feed, err := FetchFeed(context.Background(), server.URL)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(reflect.TypeOf(feed), reflect.TypeOf(tc.expectedFeed)) {
+				t.Errorf("expected feed type %T, got %T", tc.expectedFeed, feed)
+			}
+			if !compareFeeds(tc.expectedFeed, feed) {
+				t.Errorf("expected feed %v, got %v", tc.expectedFeed, feed)
+			}
+			if !reflect.DeepEqual(feed.GetUpdateHints(), tc.expectedHints) {
+				t.Errorf("expected hints %+v, got %+v", tc.expectedHints, feed.GetUpdateHints())
+			}
+		})
+	}
+}
+
+func TestDetectFeedType(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected FeedType
+	}{
+		{"RSS 2.0", `<rss version="2.0"><channel/></rss>`, FeedTypeRSS},
+		{"Atom", `<feed xmlns="http://www.w3.org/2005/Atom"/>`, FeedTypeAtom},
+		{"RSS 1.0", `<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/"/>`, FeedTypeRDF},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			feedType, err := detectFeedType([]byte(tc.body))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if feedType != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, feedType)
+			}
+		})
+	}
+}
+
+func TestFetchFeed_JSONFeedAttachments(t *testing.T) {
+	body, err := os.ReadFile("testdata/jsonfeed.json")
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+
+	feed, err := parseJSONFeed(body)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []JSONFeedAttachment{
+		{
+			URL:               "https://cdn.example.com/episode-12.mp3",
+			MimeType:          "audio/mpeg",
+			SizeInBytes:       23456789,
+			DurationInSeconds: 1800,
+		},
+	}
+	if !reflect.DeepEqual(feed.items[0].GetAttachments(), expected) {
+		t.Errorf("expected attachments %+v, got %+v", expected, feed.items[0].GetAttachments())
+	}
+	if got := feed.items[0].GetContentHTML(); got != "<p>We talk about <em>feeds</em> &amp; readers.</p>" {
+		t.Errorf("expected raw content_html, got %q", got)
+	}
+}
+
+func TestParseMediaDuration(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{"2851", 2851 * time.Second},
+		{"47:31", 47*time.Minute + 31*time.Second},
+		{"1:02:03", time.Hour + 2*time.Minute + 3*time.Second},
+		{"90.4", 90 * time.Second},
+		{"", 0},
+		{"about an hour", 0},
+		{"1:2:3:4", 0},
+		{"-5", 0},
+	}
+
+	for _, tc := range tests {
+		if got := parseMediaDuration(tc.value); got != tc.expected {
+			t.Errorf("parseMediaDuration(%q) = %s, expected %s", tc.value, got, tc.expected)
+		}
+	}
+}
+
+func TestIsJSONFeed(t *testing.T) {
+	jsonFeed := `{"version": "https://jsonfeed.org/version/1.1", "title": "Feed", "items": []}`
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		expected    bool
+	}{
+		{"feed media type", "application/feed+json", `{"title": "Feed"}`, true},
+		{"plain JSON feed", "application/json; charset=utf-8", jsonFeed, true},
+		{"plain JSON", "application/json", `{"error": "not found"}`, false},
+		{"text JSON feed", "text/plain", jsonFeed, true},
+		{"XML", "application/json", `<rss version="2.0"></rss>`, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := isJSONFeed(tc.contentType, []byte(tc.body)); got != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestFetchFeed_JSONFeedPaging(t *testing.T) {
+	pages := map[string]string{
+		"/feed.json": `{"version": "https://jsonfeed.org/version/1.1", "title": "Paged", "next_url": "/page/2.json", "items": [
+			{"id": "1", "url": "https://example.com/1", "title": "One", "date_published": "2024-01-03T00:00:00Z"}]}`,
+		"/page/2.json": `{"version": "https://jsonfeed.org/version/1.1", "title": "Paged", "next_url": "3.json", "items": [
+			{"id": "2", "url": "https://example.com/2", "title": "Two", "date_published": "2024-01-02T00:00:00Z"}]}`,
+		"/page/3.json": `{"version": "https://jsonfeed.org/version/1.1", "title": "Paged", "next_url": "/feed.json", "items": [
+			{"id": "3", "url": "https://example.com/3", "title": "Three", "date_published": "2024-01-01T00:00:00Z"}]}`,
+	}
+
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		page, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/feed+json")
+		w.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	feed, err := FetchFeed(context.Background(), server.URL+"/feed.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var titles []string
+	for _, item := range feed.GetItems() {
+		titles = append(titles, item.GetTitle())
+	}
+	if !reflect.DeepEqual(titles, []string{"One", "Two", "Three"}) {
+		t.Errorf("expected items from all pages, got %v", titles)
+	}
+
+	// The last page links back to the first, which must not be fetched again
+	if requests != 3 {
+		t.Errorf("expected 3 requests, got %d", requests)
+	}
+
+	// A page that fails keeps the items gathered so far and reports why
+	delete(pages, "/page/3.json")
+	result, err := DefaultFetcher.FetchFeedWithConditionals(context.Background(), server.URL+"/feed.json", nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Feed.GetItems()) != 2 {
+		t.Errorf("expected the items of the first two pages, got %d", len(result.Feed.GetItems()))
+	}
+	var httpErr *HTTPError
+	if !errors.As(result.PageErr, &httpErr) || httpErr.StatusCode != http.StatusNotFound {
+		t.Errorf("expected the failed page to be reported, got %v", result.PageErr)
+	}
+}
+
+func TestFetchFeed_AtomRelativeToFeedURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/atom+xml")
+		w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+		<feed xmlns="http://www.w3.org/2005/Atom">
+			<title>Relative</title>
+			<link rel="alternate" href="../"/>
+			<entry>
+				<title>Entry</title>
+				<link href="entries/1"/>
+				<updated>2024-01-01T00:00:00Z</updated>
+			</entry>
+		</feed>`))
+	}))
+	defer server.Close()
+
+	feed, err := FetchFeed(context.Background(), server.URL+"/blog/feeds/all.atom")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if expected := server.URL + "/blog/"; feed.GetLink() != expected {
+		t.Errorf("expected feed link %s, got %s", expected, feed.GetLink())
+	}
+	if expected := server.URL + "/blog/feeds/entries/1"; feed.GetItems()[0].GetLink() != expected {
+		t.Errorf("expected item link %s, got %s", expected, feed.GetItems()[0].GetLink())
+	}
+}
+
+func TestFetchFeed_RSSGUID(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+		<rss version="2.0">
+			<channel>
+				<title>GUIDs</title>
+				<item>
+					<title>Permalink only</title>
+					<guid>https://example.com/posts/1</guid>
+				</item>
+				<item>
+					<title>Opaque GUID</title>
+					<link>https://example.com/posts/2?utm_source=rss</link>
+					<guid isPermaLink="false"> 7f3c9a </guid>
+				</item>
+				<item>
+					<title>Not a permalink</title>
+					<guid isPermaLink="false">https://example.com/posts/3</guid>
+				</item>
+			</channel>
+		</rss>`))
+	}))
+	defer server.Close()
+
+	feed, err := FetchFeed(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []struct{ guid, link string }{
+		{"https://example.com/posts/1", "https://example.com/posts/1"},
+		{"7f3c9a", "https://example.com/posts/2?utm_source=rss"},
+		{"https://example.com/posts/3", ""},
+	}
+	items := feed.GetItems()
+	if len(items) != len(expected) {
+		t.Fatalf("expected %d items, got %d", len(expected), len(items))
+	}
+	for i, item := range items {
+		if item.GetGUID() != expected[i].guid || item.GetLink() != expected[i].link {
+			t.Errorf("item %d: expected guid %q and link %q, got %q and %q", i, expected[i].guid, expected[i].link, item.GetGUID(), item.GetLink())
+		}
+	}
+}
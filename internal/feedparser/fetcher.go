@@ -0,0 +1,390 @@
+package feedparser
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultConnectTimeout      = 10 * time.Second
+	defaultHeaderTimeout       = 20 * time.Second
+	defaultTimeout             = 60 * time.Second
+	defaultMaxBodySize         = 10 << 20
+	defaultMaxDecompressedSize = 50 << 20
+	defaultMaxRedirects        = 5
+)
+
+// Fetcher downloads feeds and web pages with bounded time and memory, so a
+// slow or hostile server can't hang a refresh or exhaust memory. Fields left
+// at zero use the defaults, and a Fetcher must not be changed once used.
+type Fetcher struct {
+	// ConnectTimeout bounds dialing and the TLS handshake.
+	ConnectTimeout time.Duration
+	// HeaderTimeout bounds the wait for response headers once the request
+	// has been sent.
+	HeaderTimeout time.Duration
+	// Timeout bounds the whole request, including reading the body.
+	Timeout time.Duration
+	// MaxBodySize caps the bytes read off the wire.
+	MaxBodySize int64
+	// MaxDecompressedSize caps a gzip or deflate body once decompressed.
+	MaxDecompressedSize int64
+	// MaxRedirects caps how many redirects a request follows.
+	MaxRedirects int
+	// AllowedNetworks are the loopback, private and other non-public
+	// addresses feeds may still be fetched from, such as an intranet's.
+	// Everything else that isn't a public address is refused.
+	AllowedNetworks []netip.Prefix
+
+	once   sync.Once
+	client *http.Client
+}
+
+// DefaultFetcher is used by FetchFeed, FetchFeedWithConditionals and
+// DiscoverFeeds.
+var DefaultFetcher = &Fetcher{}
+
+// TimeoutError is returned when a request takes longer than one of the
+// Fetcher's timeouts or the context's deadline.
+type TimeoutError struct {
+	Err error
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("request timed out: %s", e.Err)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// BodyTooLargeError is returned when a response is bigger than the Fetcher
+// allows, either as sent or, when Decompressed is set, once decompressed.
+type BodyTooLargeError struct {
+	Limit        int64
+	Decompressed bool
+}
+
+func (e *BodyTooLargeError) Error() string {
+	if e.Decompressed {
+		return fmt.Sprintf("decompressed response body exceeds %d bytes", e.Limit)
+	}
+	return fmt.Sprintf("response body exceeds %d bytes", e.Limit)
+}
+
+// TooManyRedirectsError is returned when a request is redirected more often
+// than the Fetcher allows.
+type TooManyRedirectsError struct {
+	Limit int
+}
+
+func (e *TooManyRedirectsError) Error() string {
+	return fmt.Sprintf("stopped after %d redirects", e.Limit)
+}
+
+// response is a fetched resource. Body is only read for 200 responses.
+type response struct {
+	StatusCode int
+	Header     http.Header
+	// URL is where the request ended up after redirects.
+	URL string
+	// PermanentURL is where the permanent redirects the request started
+	// with led, or "" when it wasn't permanently redirected.
+	PermanentURL string
+	Body         []byte
+}
+
+func (f *Fetcher) httpClient() *http.Client {
+	f.once.Do(func() {
+		connectTimeout := orDefault(f.ConnectTimeout, defaultConnectTimeout)
+		maxRedirects := orDefault(f.MaxRedirects, defaultMaxRedirects)
+
+		f.client = &http.Client{
+			// Proxies aren't used, since they would connect on the Fetcher's
+			// behalf to addresses it can't vet
+			Transport: &http.Transport{
+				DialContext: (&net.Dialer{
+					Timeout:   connectTimeout,
+					KeepAlive: 30 * time.Second,
+					Control:   f.controlDial,
+				}).DialContext,
+				TLSHandshakeTimeout:   connectTimeout,
+				ResponseHeaderTimeout: orDefault(f.HeaderTimeout, defaultHeaderTimeout),
+				ForceAttemptHTTP2:     true,
+				MaxIdleConns:          100,
+				IdleConnTimeout:       90 * time.Second,
+				// Bodies are decompressed by get, which can bound them
+				DisableCompression: true,
+			},
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) > maxRedirects {
+					return &TooManyRedirectsError{Limit: maxRedirects}
+				}
+				// Credentials and custom headers are only meant for the host
+				// they were set for
+				if !sameHost(req.URL.String(), via[0].URL.String()) {
+					req.Header = http.Header{
+						"User-Agent":      {userAgent},
+						"Accept-Encoding": via[0].Header["Accept-Encoding"],
+					}
+				}
+				return checkScheme(req.URL.Scheme)
+			},
+		}
+	})
+	return f.client
+}
+
+// get fetches rawURL with the given extra headers.
+func (f *Fetcher) get(ctx context.Context, rawURL string, header http.Header) (*response, error) {
+	ctx, cancel := context.WithTimeout(ctx, orDefault(f.Timeout, defaultTimeout))
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkScheme(req.URL.Scheme); err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+
+	resp, err := f.httpClient().Do(req)
+	if err != nil {
+		return nil, fetchError(err)
+	}
+	defer resp.Body.Close()
+
+	result := &response{
+		StatusCode:   resp.StatusCode,
+		Header:       resp.Header,
+		URL:          resp.Request.URL.String(),
+		PermanentURL: permanentURL(resp.Request),
+	}
+	if resp.StatusCode != http.StatusOK {
+		return result, nil
+	}
+
+	result.Body, err = f.readBody(resp)
+	if err != nil {
+		return nil, fetchError(err)
+	}
+	return result, nil
+}
+
+// permanentURL follows the redirects that led to req from the first
+// request, returning where the leading 301 and 308 redirects ended. A
+// temporary redirect in between means the original URL is still the one to
+// use from then on.
+func permanentURL(req *http.Request) string {
+	var hops []*http.Request
+	for r := req; r.Response != nil; r = r.Response.Request {
+		hops = append(hops, r)
+	}
+	slices.Reverse(hops)
+
+	var permanent string
+	for _, hop := range hops {
+		if status := hop.Response.StatusCode; status != http.StatusMovedPermanently && status != http.StatusPermanentRedirect {
+			break
+		}
+		permanent = hop.URL.String()
+	}
+	return permanent
+}
+
+// readBody reads and decompresses a response body within the size limits.
+func (f *Fetcher) readBody(resp *http.Response) ([]byte, error) {
+	maxBodySize := orDefault(f.MaxBodySize, defaultMaxBodySize)
+	if resp.ContentLength > maxBodySize {
+		return nil, &BodyTooLargeError{Limit: maxBodySize}
+	}
+	body, err := readLimited(resp.Body, maxBodySize, &BodyTooLargeError{Limit: maxBodySize})
+	if err != nil {
+		return nil, err
+	}
+
+	var decoder io.Reader
+	switch strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))) {
+	case "", "identity":
+		return body, nil
+	case "gzip", "x-gzip":
+		decoder, err = gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress gzip body: %w", err)
+		}
+	case "deflate":
+		// deflate is meant to be zlib-wrapped, but some servers send it raw
+		decoder, err = zlib.NewReader(bytes.NewReader(body))
+		if err != nil {
+			decoder = flate.NewReader(bytes.NewReader(body))
+		}
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %q", resp.Header.Get("Content-Encoding"))
+	}
+
+	maxDecompressedSize := orDefault(f.MaxDecompressedSize, defaultMaxDecompressedSize)
+	tooLarge := &BodyTooLargeError{Limit: maxDecompressedSize, Decompressed: true}
+	decompressed, err := readLimited(decoder, maxDecompressedSize, tooLarge)
+	if err != nil && err != tooLarge {
+		return nil, fmt.Errorf("failed to decompress body: %w", err)
+	}
+	return decompressed, err
+}
+
+// readLimited reads all of r, returning tooLarge once it has read more than
+// limit bytes.
+func readLimited(r io.Reader, limit int64, tooLarge error) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > limit {
+		return nil, tooLarge
+	}
+	return body, nil
+}
+
+// fetchError unwraps the Fetcher's own errors from the *url.Error the client
+// wraps them in, and marks timeouts as such.
+func fetchError(err error) error {
+	var redirectErr *TooManyRedirectsError
+	if errors.As(err, &redirectErr) {
+		return redirectErr
+	}
+	var addressErr *ForbiddenAddressError
+	if errors.As(err, &addressErr) {
+		return addressErr
+	}
+	var schemeErr *UnsupportedSchemeError
+	if errors.As(err, &schemeErr) {
+		return schemeErr
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return &TimeoutError{Err: err}
+	}
+	return err
+}
+
+// sameHost reports whether two URLs point at the same host and port.
+func sameHost(a, b string) bool {
+	aURL, err := url.Parse(a)
+	if err != nil {
+		return false
+	}
+	bURL, err := url.Parse(b)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(aURL.Host, bURL.Host)
+}
+
+func orDefault[T int | int64 | time.Duration](value, def T) T {
+	if value <= 0 {
+		return def
+	}
+	return value
+}
+
+// FetchFeed fetches and parses the feed at feedURL.
+func (f *Fetcher) FetchFeed(ctx context.Context, feedURL string) (Feed, error) {
+	result, err := f.FetchFeedWithConditionals(ctx, feedURL, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return result.Feed, nil
+}
+
+// FetchFeedWithConditionals fetches and parses the feed at feedURL, sending
+// the validators from an earlier fetch so that an unchanged feed comes back
+// as NotModified.
+func (f *Fetcher) FetchFeedWithConditionals(ctx context.Context, feedURL string, etag, lastModified *string) (*FetchResult, error) {
+	return f.FetchFeedWithHeader(ctx, feedURL, nil, etag, lastModified)
+}
+
+// FetchFeedWithHeader is FetchFeedWithConditionals with extra request
+// headers, such as the credentials of a feed that isn't public. They are
+// sent to the feed's host only, including for further JSON Feed pages, and
+// dropped when a request is redirected elsewhere.
+func (f *Fetcher) FetchFeedWithHeader(ctx context.Context, feedURL string, extra http.Header, etag, lastModified *string) (*FetchResult, error) {
+	header := extra.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	if etag != nil && *etag != "" {
+		header.Set("If-None-Match", *etag)
+	}
+	if lastModified != nil && *lastModified != "" {
+		header.Set("If-Modified-Since", *lastModified)
+	}
+
+	resp, err := f.get(ctx, feedURL, header)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &FetchResult{
+		StatusCode:   resp.StatusCode,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		NotModified:  resp.StatusCode == http.StatusNotModified,
+		PermanentURL: resp.PermanentURL,
+	}
+
+	// Handle 304 Not Modified response
+	if resp.StatusCode == http.StatusNotModified {
+		return result, nil
+	}
+
+	// Handle other non-200 status codes
+	if resp.StatusCode != http.StatusOK {
+		return nil, &HTTPError{
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
+	}
+
+	feed, err := f.parseFeed(ctx, feedURL, extra, resp.Header.Get("Content-Type"), resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	result.Feed = feed
+	if jsonFeed, ok := feed.(*JSONFeed); ok {
+		result.PageErr = jsonFeed.PageErr()
+	}
+
+	return result, nil
+}
+
+// fetchPage fetches a follow-up page of a feed with the feed's extra
+// headers but without conditional ones.
+func (f *Fetcher) fetchPage(ctx context.Context, pageURL string, header http.Header) ([]byte, error) {
+	resp, err := f.get(ctx, pageURL, header)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &HTTPError{StatusCode: resp.StatusCode}
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,291 @@
+package feedparser
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// loopback lets tests fetch from httptest servers.
+var loopback = []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8"), netip.MustParsePrefix("::1/128")}
+
+func TestMain(m *testing.M) {
+	DefaultFetcher = &Fetcher{AllowedNetworks: loopback}
+	os.Exit(m.Run())
+}
+
+const fetcherRSS = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0">
+  <channel>
+    <title>Fetched Feed</title>
+  </channel>
+</rss>`
+
+func TestFetcher_Decompression(t *testing.T) {
+	var gzipped, deflated bytes.Buffer
+	gz := gzip.NewWriter(&gzipped)
+	gz.Write([]byte(fetcherRSS))
+	gz.Close()
+	fl, _ := flate.NewWriter(&deflated, flate.DefaultCompression)
+	fl.Write([]byte(fetcherRSS))
+	fl.Close()
+
+	tests := []struct {
+		name     string
+		encoding string
+		body     []byte
+	}{
+		{name: "gzip", encoding: "gzip", body: gzipped.Bytes()},
+		{name: "raw deflate", encoding: "deflate", body: deflated.Bytes()},
+		{name: "identity", encoding: "", body: []byte(fetcherRSS)},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Accept-Encoding") == "" {
+					t.Errorf("Expected Accept-Encoding to be sent")
+				}
+				w.Header().Set("Content-Type", "application/rss+xml")
+				if tc.encoding != "" {
+					w.Header().Set("Content-Encoding", tc.encoding)
+				}
+				w.Write(tc.body)
+			}))
+			defer server.Close()
+
+			feed, err := (&Fetcher{AllowedNetworks: loopback}).FetchFeed(context.Background(), server.URL)
+			if err != nil {
+				t.Fatalf("FetchFeed failed: %v", err)
+			}
+			if feed.GetTitle() != "Fetched Feed" {
+				t.Errorf("Expected title %q, got %q", "Fetched Feed", feed.GetTitle())
+			}
+		})
+	}
+}
+
+func TestFetcher_Limits(t *testing.T) {
+	var bomb bytes.Buffer
+	gz := gzip.NewWriter(&bomb)
+	gz.Write(bytes.Repeat([]byte(" "), 1<<20))
+	gz.Close()
+
+	tests := []struct {
+		name    string
+		fetcher *Fetcher
+		handler http.HandlerFunc
+		check   func(t *testing.T, err error)
+	}{
+		{
+			name:    "declared body too large",
+			fetcher: &Fetcher{MaxBodySize: 100, AllowedNetworks: loopback},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Length", "1000")
+				w.Write(bytes.Repeat([]byte(" "), 1000))
+			},
+			check: func(t *testing.T, err error) {
+				var tooLarge *BodyTooLargeError
+				if !errors.As(err, &tooLarge) || tooLarge.Limit != 100 || tooLarge.Decompressed {
+					t.Errorf("Expected a BodyTooLargeError, got %v", err)
+				}
+			},
+		},
+		{
+			name:    "streamed body too large",
+			fetcher: &Fetcher{MaxBodySize: 100, AllowedNetworks: loopback},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				for range 10 {
+					w.Write(bytes.Repeat([]byte(" "), 100))
+					w.(http.Flusher).Flush()
+				}
+			},
+			check: func(t *testing.T, err error) {
+				var tooLarge *BodyTooLargeError
+				if !errors.As(err, &tooLarge) || tooLarge.Decompressed {
+					t.Errorf("Expected a BodyTooLargeError, got %v", err)
+				}
+			},
+		},
+		{
+			name:    "decompressed body too large",
+			fetcher: &Fetcher{MaxDecompressedSize: 1 << 10, AllowedNetworks: loopback},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Encoding", "gzip")
+				w.Write(bomb.Bytes())
+			},
+			check: func(t *testing.T, err error) {
+				var tooLarge *BodyTooLargeError
+				if !errors.As(err, &tooLarge) || !tooLarge.Decompressed {
+					t.Errorf("Expected a decompressed BodyTooLargeError, got %v", err)
+				}
+			},
+		},
+		{
+			name:    "too many redirects",
+			fetcher: &Fetcher{MaxRedirects: 2, AllowedNetworks: loopback},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, r.URL.Path+"x", http.StatusFound)
+			},
+			check: func(t *testing.T, err error) {
+				var redirectErr *TooManyRedirectsError
+				if !errors.As(err, &redirectErr) || redirectErr.Limit != 2 {
+					t.Errorf("Expected a TooManyRedirectsError, got %v", err)
+				}
+			},
+		},
+		{
+			name:    "slow headers",
+			fetcher: &Fetcher{HeaderTimeout: 50 * time.Millisecond, AllowedNetworks: loopback},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(200 * time.Millisecond)
+			},
+			check: func(t *testing.T, err error) {
+				var timeoutErr *TimeoutError
+				if !errors.As(err, &timeoutErr) {
+					t.Errorf("Expected a TimeoutError, got %v", err)
+				}
+			},
+		},
+		{
+			name:    "slow body",
+			fetcher: &Fetcher{Timeout: 100 * time.Millisecond, AllowedNetworks: loopback},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				for range 10 {
+					fmt.Fprint(w, " ")
+					w.(http.Flusher).Flush()
+					time.Sleep(50 * time.Millisecond)
+				}
+			},
+			check: func(t *testing.T, err error) {
+				var timeoutErr *TimeoutError
+				if !errors.As(err, &timeoutErr) {
+					t.Errorf("Expected a TimeoutError, got %v", err)
+				}
+			},
+		},
+		{
+			name:    "HTTP error",
+			fetcher: &Fetcher{AllowedNetworks: loopback},
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			},
+			check: func(t *testing.T, err error) {
+				var httpErr *HTTPError
+				if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusServiceUnavailable {
+					t.Errorf("Expected an HTTPError, got %v", err)
+				}
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(tc.handler)
+			defer server.Close()
+
+			_, err := tc.fetcher.FetchFeed(context.Background(), server.URL+"/")
+			tc.check(t, err)
+		})
+	}
+}
+
+func TestFetcher_FollowsRedirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Count(r.URL.Path, "x") < 2 {
+			http.Redirect(w, r, r.URL.Path+"x", http.StatusFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/rss+xml")
+		fmt.Fprint(w, fetcherRSS)
+	}))
+	defer server.Close()
+
+	if _, err := (&Fetcher{MaxRedirects: 2, AllowedNetworks: loopback}).FetchFeed(context.Background(), server.URL+"/"); err != nil {
+		t.Errorf("Expected two redirects to be followed, got %v", err)
+	}
+}
+
+func TestFetcher_PermanentURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/moved":
+			http.Redirect(w, r, "/feed", http.StatusMovedPermanently)
+		case "/temporary":
+			http.Redirect(w, r, "/moved", http.StatusFound)
+		default:
+			w.Header().Set("Content-Type", "application/rss+xml")
+			fmt.Fprint(w, fetcherRSS)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/feed", want: ""},
+		{path: "/moved", want: server.URL + "/feed"},
+		{path: "/temporary", want: ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.path, func(t *testing.T) {
+			result, err := (&Fetcher{AllowedNetworks: loopback}).FetchFeedWithConditionals(context.Background(), server.URL+tc.path, nil, nil)
+			if err != nil {
+				t.Fatalf("FetchFeedWithConditionals failed: %v", err)
+			}
+			if result.PermanentURL != tc.want {
+				t.Errorf("Expected permanent URL %q, got %q", tc.want, result.PermanentURL)
+			}
+		})
+	}
+}
+
+func TestFetcher_FetchFeedWithHeader(t *testing.T) {
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" || r.Header.Get("X-Api-Key") != "" {
+			t.Errorf("Expected no credentials on another host, got %v", r.Header)
+		}
+		w.Header().Set("Content-Type", "application/rss+xml")
+		fmt.Fprint(w, fetcherRSS)
+	}))
+	defer other.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" || r.Header.Get("X-Api-Key") != "key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path == "/elsewhere" {
+			http.Redirect(w, r, other.URL+"/feed", http.StatusFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/rss+xml")
+		fmt.Fprint(w, fetcherRSS)
+	}))
+	defer server.Close()
+
+	header := http.Header{"Authorization": {"Bearer secret"}, "X-Api-Key": {"key"}}
+	fetcher := &Fetcher{AllowedNetworks: loopback}
+
+	if _, err := fetcher.FetchFeedWithHeader(context.Background(), server.URL+"/feed", header, nil, nil); err != nil {
+		t.Errorf("Expected the headers to be sent, got %v", err)
+	}
+	if _, err := fetcher.FetchFeedWithHeader(context.Background(), server.URL+"/elsewhere", header, nil, nil); err != nil {
+		t.Errorf("Expected the redirect to be followed, got %v", err)
+	}
+	if _, err := fetcher.FetchFeed(context.Background(), server.URL+"/feed"); err == nil {
+		t.Errorf("Expected the feed to need the headers")
+	}
+}
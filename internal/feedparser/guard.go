@@ -0,0 +1,147 @@
+package feedparser
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+	"syscall"
+)
+
+// reservedNetworks are blocked along with the loopback, link-local, private
+// and multicast ranges netip knows about. They are special-purpose ranges
+// that never hold a public feed.
+var reservedNetworks = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("192.88.99.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("2001:db8::/32"),
+}
+
+// IPv6 ranges that carry an IPv4 address, which is where a connection to
+// them ends up.
+var (
+	nat64Network      = netip.MustParsePrefix("64:ff9b::/96")
+	sixToFourNetwork  = netip.MustParsePrefix("2002::/16")
+	ipv4CompatNetwork = netip.MustParsePrefix("::/96")
+)
+
+// ForbiddenAddressError is returned when a URL, or one it redirects to,
+// resolves to an address feeds are not fetched from, such as localhost, the
+// cloud metadata service or a private network.
+type ForbiddenAddressError struct {
+	Addr netip.Addr
+}
+
+func (e *ForbiddenAddressError) Error() string {
+	return fmt.Sprintf("refusing to connect to non-public address %s", e.Addr)
+}
+
+// UnsupportedSchemeError is returned for URLs, including redirect targets,
+// that are not http or https.
+type UnsupportedSchemeError struct {
+	Scheme string
+}
+
+func (e *UnsupportedSchemeError) Error() string {
+	return fmt.Sprintf("unsupported URL scheme %q", e.Scheme)
+}
+
+// ParseNetworks reads a comma-separated list of IP addresses and CIDR
+// ranges, such as "10.1.2.3, 192.168.0.0/16", for Fetcher.AllowedNetworks.
+func ParseNetworks(value string) ([]netip.Prefix, error) {
+	var networks []netip.Prefix
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if !strings.Contains(field, "/") {
+			addr, err := netip.ParseAddr(field)
+			if err != nil {
+				return nil, fmt.Errorf("invalid network %q: %w", field, err)
+			}
+			networks = append(networks, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		network, err := netip.ParsePrefix(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid network %q: %w", field, err)
+		}
+		networks = append(networks, network.Masked())
+	}
+	return networks, nil
+}
+
+// checkScheme accepts only the schemes feeds are fetched over.
+func checkScheme(scheme string) error {
+	switch strings.ToLower(scheme) {
+	case "http", "https":
+		return nil
+	default:
+		return &UnsupportedSchemeError{Scheme: scheme}
+	}
+}
+
+// controlDial vets every address the Fetcher connects to once DNS has been
+// resolved, so neither a hostname pointing at an internal address nor a
+// redirect to one gets through.
+func (f *Fetcher) controlDial(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("unexpected dial address %q: %w", address, err)
+	}
+	if addr := addrPort.Addr().Unmap(); !f.isAllowed(addr) {
+		return &ForbiddenAddressError{Addr: addr}
+	}
+	return nil
+}
+
+// isAllowed reports whether addr is public or in one of the allowlisted
+// networks.
+func (f *Fetcher) isAllowed(addr netip.Addr) bool {
+	for _, network := range f.AllowedNetworks {
+		if network.Contains(addr) {
+			return true
+		}
+	}
+
+	if v4, ok := embeddedIPv4(addr); ok {
+		return f.isAllowed(v4)
+	}
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return false
+	}
+	for _, network := range reservedNetworks {
+		if network.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// embeddedIPv4 returns the IPv4 address inside a NAT64, 6to4 or
+// IPv4-compatible IPv6 address, so it can be checked in place of addr.
+func embeddedIPv4(addr netip.Addr) (netip.Addr, bool) {
+	if !addr.Is6() || addr.Is4In6() {
+		return netip.Addr{}, false
+	}
+
+	b := addr.As16()
+	switch {
+	case nat64Network.Contains(addr), ipv4CompatNetwork.Contains(addr):
+		return netip.AddrFrom4([4]byte(b[12:16])), true
+	case sixToFourNetwork.Contains(addr):
+		return netip.AddrFrom4([4]byte(b[2:6])), true
+	default:
+		return netip.Addr{}, false
+	}
+}
@@ -0,0 +1,157 @@
+package feedparser
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+func TestFetcher_IsAllowed(t *testing.T) {
+	tests := []struct {
+		addr    string
+		allowed bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:4700::6810:84e5", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"10.0.0.1", false},
+		{"172.16.5.4", false},
+		{"192.168.1.1", false},
+		{"fd00::1", false},
+		{"224.0.0.1", false},
+		{"ff02::1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"100.64.0.1", false},
+		{"255.255.255.255", false},
+		{"192.88.99.1", false},
+		{"198.51.100.7", false},
+		{"2001:db8::1", false},
+		{"64:ff9b:1::a00:1", false},
+		// IPv6 addresses are checked as the IPv4 address they carry
+		{"64:ff9b::7f00:1", false},
+		{"64:ff9b::a9fe:a9fe", false},
+		{"64:ff9b::5db8:d822", true},
+		{"2002:c0a8:101::1", false},
+		{"2002:7f00:1::", false},
+		{"2002:5db8:d822::1", true},
+		{"::7f00:1", false},
+		{"::a00:1", false},
+		{"::5db8:d822", true},
+		// An allowlisted intranet
+		{"10.20.30.40", true},
+		{"64:ff9b::a14:1e28", true},
+	}
+
+	fetcher := &Fetcher{AllowedNetworks: []netip.Prefix{netip.MustParsePrefix("10.20.0.0/16")}}
+	for _, tc := range tests {
+		if got := fetcher.isAllowed(netip.MustParseAddr(tc.addr)); got != tc.allowed {
+			t.Errorf("isAllowed(%s): expected %v, got %v", tc.addr, tc.allowed, got)
+		}
+	}
+}
+
+func TestFetcher_ControlDial(t *testing.T) {
+	fetcher := &Fetcher{}
+
+	// IPv4-mapped IPv6 addresses are checked as the IPv4 address they are
+	err := fetcher.controlDial("tcp6", "[::ffff:127.0.0.1]:80", nil)
+	var addressErr *ForbiddenAddressError
+	if !errors.As(err, &addressErr) || addressErr.Addr != netip.MustParseAddr("127.0.0.1") {
+		t.Errorf("Expected a ForbiddenAddressError for 127.0.0.1, got %v", err)
+	}
+
+	if err := fetcher.controlDial("tcp4", "93.184.216.34:443", nil); err != nil {
+		t.Errorf("Expected a public address to be allowed, got %v", err)
+	}
+}
+
+func TestFetcher_RefusesPrivateAddresses(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch r.URL.Path {
+		case "/elsewhere":
+			// Only 127.0.0.1 is allowlisted below
+			target, _ := url.Parse("http://" + r.Host)
+			http.Redirect(w, r, "http://127.0.0.2:"+target.Port()+"/feed.xml", http.StatusFound)
+		case "/ftp":
+			http.Redirect(w, r, "ftp://example.com/feed.xml", http.StatusFound)
+		default:
+			w.Header().Set("Content-Type", "application/rss+xml")
+			w.Write([]byte(fetcherRSS))
+		}
+	}))
+	defer server.Close()
+
+	t.Run("loopback", func(t *testing.T) {
+		_, err := (&Fetcher{}).FetchFeed(context.Background(), server.URL+"/feed.xml")
+		var addressErr *ForbiddenAddressError
+		if !errors.As(err, &addressErr) {
+			t.Errorf("Expected a ForbiddenAddressError, got %v", err)
+		}
+		if got := requests.Load(); got != 0 {
+			t.Errorf("Expected no request to reach the server, got %d", got)
+		}
+	})
+
+	allowed := &Fetcher{AllowedNetworks: []netip.Prefix{netip.MustParsePrefix("127.0.0.1/32")}}
+
+	t.Run("allowlisted", func(t *testing.T) {
+		if _, err := allowed.FetchFeed(context.Background(), server.URL+"/feed.xml"); err != nil {
+			t.Errorf("Expected an allowlisted address to be fetched, got %v", err)
+		}
+	})
+
+	t.Run("redirect to a private address", func(t *testing.T) {
+		_, err := allowed.FetchFeed(context.Background(), server.URL+"/elsewhere")
+		var addressErr *ForbiddenAddressError
+		if !errors.As(err, &addressErr) || addressErr.Addr != netip.MustParseAddr("127.0.0.2") {
+			t.Errorf("Expected a ForbiddenAddressError for 127.0.0.2, got %v", err)
+		}
+	})
+
+	t.Run("redirect to another scheme", func(t *testing.T) {
+		_, err := allowed.FetchFeed(context.Background(), server.URL+"/ftp")
+		var schemeErr *UnsupportedSchemeError
+		if !errors.As(err, &schemeErr) || schemeErr.Scheme != "ftp" {
+			t.Errorf("Expected an UnsupportedSchemeError, got %v", err)
+		}
+	})
+
+	t.Run("other scheme", func(t *testing.T) {
+		_, err := allowed.FetchFeed(context.Background(), "file:///etc/passwd")
+		var schemeErr *UnsupportedSchemeError
+		if !errors.As(err, &schemeErr) {
+			t.Errorf("Expected an UnsupportedSchemeError, got %v", err)
+		}
+	})
+}
+
+func TestParseNetworks(t *testing.T) {
+	networks, err := ParseNetworks(" 10.0.0.0/8, 192.168.1.20,fd12::/16 ,")
+	if err != nil {
+		t.Fatalf("ParseNetworks failed: %v", err)
+	}
+	expected := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("192.168.1.20/32"),
+		netip.MustParsePrefix("fd12::/16"),
+	}
+	if !reflect.DeepEqual(networks, expected) {
+		t.Errorf("Expected %v, got %v", expected, networks)
+	}
+
+	if _, err := ParseNetworks("intranet.example.com"); err == nil {
+		t.Errorf("Expected an error for a hostname")
+	}
+}
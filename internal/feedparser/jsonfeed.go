@@ -0,0 +1,355 @@
+package feedparser
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxJSONFeedPages bounds how many next_url pages are followed per fetch.
+const maxJSONFeedPages = 5
+
+// jsonFeedTitleLength is how much of an untitled item's text becomes its
+// title, since microblog feeds often leave titles out.
+const jsonFeedTitleLength = 80
+
+type jsonFeedJSON struct {
+	Version     string             `json:"version"`
+	Title       string             `json:"title"`
+	HomePageURL string             `json:"home_page_url"`
+	FeedURL     string             `json:"feed_url"`
+	Description string             `json:"description"`
+	NextURL     string             `json:"next_url"`
+	Authors     []jsonFeedAuthor   `json:"authors"`
+	Author      *jsonFeedAuthor    `json:"author"` // JSON Feed 1.0
+	Items       []jsonFeedItemJSON `json:"items"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+type jsonFeedItemJSON struct {
+	ID            json.RawMessage      `json:"id"`
+	URL           string               `json:"url"`
+	ExternalURL   string               `json:"external_url"`
+	Title         string               `json:"title"`
+	ContentHTML   string               `json:"content_html"`
+	ContentText   string               `json:"content_text"`
+	Summary       string               `json:"summary"`
+	Image         string               `json:"image"`
+	BannerImage   string               `json:"banner_image"`
+	DatePublished string               `json:"date_published"`
+	DateModified  string               `json:"date_modified"`
+	Authors       []jsonFeedAuthor     `json:"authors"`
+	Author        *jsonFeedAuthor      `json:"author"` // JSON Feed 1.0
+	Attachments   []JSONFeedAttachment `json:"attachments"`
+}
+
+// JSONFeedAttachment is a file attached to a JSON Feed item, such as a
+// podcast episode.
+type JSONFeedAttachment struct {
+	URL               string  `json:"url"`
+	MimeType          string  `json:"mime_type"`
+	Title             string  `json:"title"`
+	SizeInBytes       int64   `json:"size_in_bytes"`
+	DurationInSeconds float64 `json:"duration_in_seconds"`
+}
+
+type JSONFeed struct {
+	title       string
+	link        string
+	description string
+	nextURL     string
+	items       []*JSONFeedItem
+	pageErr     error
+}
+
+type JSONFeedItem struct {
+	guid        string
+	title       string
+	link        string
+	description string
+	contentHTML string
+	content     string
+	author      string
+	date        time.Time
+	attachments []JSONFeedAttachment
+	enclosures  []Enclosure
+	thumbnail   string
+}
+
+func (f *JSONFeed) GetTitle() string {
+	return f.title
+}
+
+func (f *JSONFeed) GetLink() string {
+	return f.link
+}
+
+func (f *JSONFeed) GetDescription() string {
+	return f.description
+}
+
+func (f *JSONFeed) GetItems() []Item {
+	items := make([]Item, len(f.items))
+	for i, item := range f.items {
+		items[i] = item
+	}
+	return items
+}
+
+// GetUpdateHints returns no hints, since JSON Feed has no way to give them.
+func (f *JSONFeed) GetUpdateHints() UpdateHints {
+	return UpdateHints{}
+}
+
+// GetNextURL returns the next_url of the last page that was fetched, or ""
+// when there are no more pages.
+func (f *JSONFeed) GetNextURL() string {
+	return f.nextURL
+}
+
+// PageErr returns why a later page couldn't be fetched, or nil. The items
+// of the pages before it are still returned.
+func (f *JSONFeed) PageErr() error {
+	return f.pageErr
+}
+
+func (i *JSONFeedItem) GetGUID() string {
+	return i.guid
+}
+
+func (i *JSONFeedItem) GetTitle() string {
+	return i.title
+}
+
+func (i *JSONFeedItem) GetLink() string {
+	return i.link
+}
+
+func (i *JSONFeedItem) GetDescription() *string {
+	return &i.description
+}
+
+func (i *JSONFeedItem) GetAuthor() string {
+	return i.author
+}
+
+func (i *JSONFeedItem) GetDate() time.Time {
+	return i.date
+}
+
+func (i *JSONFeedItem) GetContent() string {
+	return i.content
+}
+
+// GetContentHTML returns the item's content_html, unmodified.
+func (i *JSONFeedItem) GetContentHTML() string {
+	return i.contentHTML
+}
+
+func (i *JSONFeedItem) GetEnclosures() []Enclosure {
+	return i.enclosures
+}
+
+func (i *JSONFeedItem) GetThumbnail() string {
+	return i.thumbnail
+}
+
+func (i *JSONFeedItem) GetAttachments() []JSONFeedAttachment {
+	return i.attachments
+}
+
+// isJSONFeed reports whether a response is a JSON Feed, going by its
+// Content-Type or, since many servers send JSON Feeds as application/json or
+// text/plain, by sniffing the body for the JSON Feed version.
+func isJSONFeed(contentType string, body []byte) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "application/feed+json" {
+		return true
+	}
+
+	trimmed := bytes.TrimLeft(bytes.TrimPrefix(body, utf8BOM), " \t\r\n")
+	return bytes.HasPrefix(trimmed, []byte("{")) && bytes.Contains(body, []byte("jsonfeed.org/version"))
+}
+
+func parseJSONFeed(body []byte) (*JSONFeed, error) {
+	var jsonFeed jsonFeedJSON
+	if err := json.Unmarshal(bytes.TrimPrefix(body, utf8BOM), &jsonFeed); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON feed: %w", err)
+	}
+
+	feed := &JSONFeed{
+		title:       strings.TrimSpace(jsonFeed.Title),
+		link:        strings.TrimSpace(jsonFeed.HomePageURL),
+		description: strings.TrimSpace(jsonFeed.Description),
+		nextURL:     strings.TrimSpace(jsonFeed.NextURL),
+		items:       make([]*JSONFeedItem, 0, len(jsonFeed.Items)),
+	}
+
+	// Items inherit the feed's authors when they don't name their own
+	feedAuthor := joinJSONFeedAuthors(jsonFeed.Authors, jsonFeed.Author)
+
+	for _, item := range jsonFeed.Items {
+		// Items with unreadable dates keep a zero date rather than being dropped
+		parsedDate, _ := parseDate(firstNonEmpty(item.DatePublished, item.DateModified))
+
+		description := strings.TrimSpace(item.ContentText)
+		if description == "" {
+			description = htmlToText(item.ContentHTML)
+		}
+		if description == "" {
+			description = strings.TrimSpace(item.Summary)
+		}
+
+		title := strings.TrimSpace(item.Title)
+		if title == "" {
+			title = titleFromText(firstNonEmpty(item.Summary, description))
+		}
+
+		author := joinJSONFeedAuthors(item.Authors, item.Author)
+		if author == "" {
+			author = feedAuthor
+		}
+
+		var enclosures []Enclosure
+		for _, attachment := range item.Attachments {
+			enclosures = appendEnclosure(enclosures, Enclosure{
+				URL:      attachment.URL,
+				MimeType: attachment.MimeType,
+				Length:   attachment.SizeInBytes,
+				Duration: time.Duration(attachment.DurationInSeconds * float64(time.Second)).Round(time.Second),
+			})
+		}
+
+		thumbnail := strings.TrimSpace(firstNonEmpty(item.Image, item.BannerImage))
+		if !isHTTPURL(thumbnail) {
+			thumbnail = ""
+		}
+
+		link := strings.TrimSpace(firstNonEmpty(item.URL, item.ExternalURL))
+		content := sanitizeHTML(item.ContentHTML, link)
+		if content == "" {
+			content = textToHTML(item.ContentText)
+		}
+
+		feed.items = append(feed.items, &JSONFeedItem{
+			guid:        jsonFeedID(item.ID),
+			title:       title,
+			link:        link,
+			description: description,
+			contentHTML: item.ContentHTML,
+			content:     content,
+			author:      author,
+			date:        parsedDate,
+			attachments: item.Attachments,
+			enclosures:  enclosures,
+			thumbnail:   thumbnail,
+		})
+	}
+
+	return feed, nil
+}
+
+// jsonFeedID reads an item id, which should be a string but is sometimes
+// a number.
+func jsonFeedID(raw json.RawMessage) string {
+	var id string
+	if err := json.Unmarshal(raw, &id); err == nil {
+		return strings.TrimSpace(id)
+	}
+	var number json.Number
+	if err := json.Unmarshal(raw, &number); err == nil {
+		return number.String()
+	}
+	return ""
+}
+
+func joinJSONFeedAuthors(authors []jsonFeedAuthor, legacy *jsonFeedAuthor) string {
+	if len(authors) == 0 && legacy != nil {
+		authors = []jsonFeedAuthor{*legacy}
+	}
+
+	names := make([]string, len(authors))
+	for i, author := range authors {
+		names[i] = author.Name
+	}
+	return joinNames(names)
+}
+
+// titleFromText uses the first line of an item's text as its title.
+func titleFromText(text string) string {
+	text = strings.TrimSpace(text)
+	if line, _, found := strings.Cut(text, "\n"); found {
+		text = strings.TrimSpace(line)
+	}
+	if utf8.RuneCountInString(text) <= jsonFeedTitleLength {
+		return text
+	}
+	runes := []rune(text)
+	return strings.TrimSpace(string(runes[:jsonFeedTitleLength])) + "…"
+}
+
+// fetchJSONFeed parses the first page of a JSON Feed and then follows
+// next_url for up to maxJSONFeedPages pages. Later pages are best effort:
+// if one fails, the items gathered so far are returned and the failure is
+// kept as the feed's PageErr. header is only sent for pages on the feed's
+// own host.
+func (f *Fetcher) fetchJSONFeed(ctx context.Context, feedURL string, header http.Header, body []byte) (Feed, error) {
+	feed, err := parseJSONFeed(body)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{feedURL: true}
+	pageURL := feedURL
+	for page := 1; page < maxJSONFeedPages && feed.nextURL != ""; page++ {
+		nextURL, err := resolveURL(pageURL, feed.nextURL)
+		if err != nil || seen[nextURL] {
+			break
+		}
+		seen[nextURL] = true
+
+		var pageHeader http.Header
+		if sameHost(feedURL, nextURL) {
+			pageHeader = header
+		}
+		nextBody, err := f.fetchPage(ctx, nextURL, pageHeader)
+		if err != nil {
+			feed.pageErr = fmt.Errorf("failed to fetch JSON feed page %s: %w", nextURL, err)
+			break
+		}
+		next, err := parseJSONFeed(nextBody)
+		if err != nil {
+			feed.pageErr = fmt.Errorf("failed to parse JSON feed page %s: %w", nextURL, err)
+			break
+		}
+
+		feed.items = append(feed.items, next.items...)
+		feed.nextURL = next.nextURL
+		pageURL = nextURL
+	}
+
+	return feed, nil
+}
+
+func resolveURL(base, ref string) (string, error) {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+	return baseURL.ResolveReference(refURL).String(), nil
+}
@@ -0,0 +1,228 @@
+package feedparser
+
+import (
+	"mime"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Enclosure is a media file attached to an item, such as a podcast episode.
+// Length is in bytes; it and Duration are 0 when the feed doesn't give them.
+type Enclosure struct {
+	URL      string
+	MimeType string
+	Length   int64
+	Duration time.Duration
+}
+
+// mediaTypes fills in the MIME type of enclosures that come without one,
+// which the mime package can't be relied on for since it reads the system's
+// tables.
+var mediaTypes = map[string]string{
+	".mp3":  "audio/mpeg",
+	".m4a":  "audio/mp4",
+	".aac":  "audio/aac",
+	".ogg":  "audio/ogg",
+	".oga":  "audio/ogg",
+	".opus": "audio/ogg",
+	".wav":  "audio/wav",
+	".flac": "audio/flac",
+	".mp4":  "video/mp4",
+	".m4v":  "video/mp4",
+	".webm": "video/webm",
+	".mov":  "video/quicktime",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".png":  "image/png",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+}
+
+// enclosureXML is an RSS 2.0 <enclosure>.
+type enclosureXML struct {
+	URL    string `xml:"url,attr"`
+	Length string `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+type mediaContentXML struct {
+	URL        string              `xml:"url,attr"`
+	Type       string              `xml:"type,attr"`
+	FileSize   string              `xml:"fileSize,attr"`
+	Duration   string              `xml:"duration,attr"`
+	Thumbnails []mediaThumbnailXML `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+}
+
+type mediaThumbnailXML struct {
+	URL string `xml:"url,attr"`
+}
+
+type mediaGroupXML struct {
+	Content    []mediaContentXML   `xml:"http://search.yahoo.com/mrss/ content"`
+	Thumbnails []mediaThumbnailXML `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+}
+
+// mediaXML holds the Media RSS and iTunes elements podcasts and video feeds
+// describe their media with. Like dublinCoreXML, it must be embedded before
+// any un-namespaced title, description or author field so that media:title
+// or itunes:author don't land in the plain fields.
+type mediaXML struct {
+	MediaTitle       string              `xml:"http://search.yahoo.com/mrss/ title"`
+	MediaDescription string              `xml:"http://search.yahoo.com/mrss/ description"`
+	MediaContent     []mediaContentXML   `xml:"http://search.yahoo.com/mrss/ content"`
+	MediaGroups      []mediaGroupXML     `xml:"http://search.yahoo.com/mrss/ group"`
+	MediaThumbnails  []mediaThumbnailXML `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+	ITunesTitle      string              `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd title"`
+	ITunesAuthor     string              `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd author"`
+	ITunesDuration   string              `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd duration"`
+	ITunesImage      struct {
+		Href string `xml:"href,attr"`
+	} `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"`
+}
+
+// enclosures gathers an item's media, starting with the ones it already
+// found elsewhere, such as RSS enclosures. The same file listed twice is
+// kept once, with whatever details either listing gave.
+func (m mediaXML) enclosures(enclosures []Enclosure) []Enclosure {
+	contents := m.MediaContent
+	for _, group := range m.MediaGroups {
+		contents = append(contents, group.Content...)
+	}
+	for _, content := range contents {
+		enclosures = appendEnclosure(enclosures, Enclosure{
+			URL:      content.URL,
+			MimeType: content.Type,
+			Length:   parseLength(content.FileSize),
+			Duration: parseMediaDuration(content.Duration),
+		})
+	}
+
+	// itunes:duration describes the episode, which is its first playable file
+	if duration := parseMediaDuration(m.ITunesDuration); duration > 0 {
+		for i, enclosure := range enclosures {
+			if isPlayable(enclosure.MimeType) {
+				if enclosures[i].Duration == 0 {
+					enclosures[i].Duration = duration
+				}
+				break
+			}
+		}
+	}
+
+	return enclosures
+}
+
+// thumbnail picks the image that best represents the item, falling back to
+// the first image among its enclosures.
+func (m mediaXML) thumbnail(enclosures []Enclosure) string {
+	candidates := []string{m.ITunesImage.Href}
+	for _, thumbnail := range m.MediaThumbnails {
+		candidates = append(candidates, thumbnail.URL)
+	}
+	for _, group := range m.MediaGroups {
+		for _, thumbnail := range group.Thumbnails {
+			candidates = append(candidates, thumbnail.URL)
+		}
+		for _, content := range group.Content {
+			for _, thumbnail := range content.Thumbnails {
+				candidates = append(candidates, thumbnail.URL)
+			}
+		}
+	}
+	for _, content := range m.MediaContent {
+		for _, thumbnail := range content.Thumbnails {
+			candidates = append(candidates, thumbnail.URL)
+		}
+	}
+	for _, enclosure := range enclosures {
+		if strings.HasPrefix(enclosure.MimeType, "image/") {
+			candidates = append(candidates, enclosure.URL)
+		}
+	}
+
+	for _, candidate := range candidates {
+		if candidate = strings.TrimSpace(candidate); isHTTPURL(candidate) {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// appendEnclosure adds an enclosure unless its URL is unusable, merging it
+// into an earlier one with the same URL.
+func appendEnclosure(enclosures []Enclosure, enclosure Enclosure) []Enclosure {
+	enclosure.URL = strings.TrimSpace(enclosure.URL)
+	if !isHTTPURL(enclosure.URL) {
+		return enclosures
+	}
+	enclosure.MimeType = enclosureMimeType(enclosure.MimeType, enclosure.URL)
+
+	for i, existing := range enclosures {
+		if existing.URL != enclosure.URL {
+			continue
+		}
+		if existing.MimeType == "" {
+			enclosures[i].MimeType = enclosure.MimeType
+		}
+		if existing.Length == 0 {
+			enclosures[i].Length = enclosure.Length
+		}
+		if existing.Duration == 0 {
+			enclosures[i].Duration = enclosure.Duration
+		}
+		return enclosures
+	}
+
+	return append(enclosures, enclosure)
+}
+
+// enclosureMimeType normalizes a declared MIME type, guessing it from the
+// file extension when none was declared.
+func enclosureMimeType(declared, enclosureURL string) string {
+	if mediaType, _, err := mime.ParseMediaType(declared); err == nil {
+		return mediaType
+	}
+
+	filePath := enclosureURL
+	if i := strings.IndexAny(filePath, "?#"); i >= 0 {
+		filePath = filePath[:i]
+	}
+	return mediaTypes[strings.ToLower(path.Ext(filePath))]
+}
+
+func isPlayable(mimeType string) bool {
+	return strings.HasPrefix(mimeType, "audio/") || strings.HasPrefix(mimeType, "video/")
+}
+
+// parseLength reads a size in bytes, returning 0 when it is missing or
+// invalid.
+func parseLength(value string) int64 {
+	length, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || length < 0 {
+		return 0
+	}
+	return length
+}
+
+// parseMediaDuration reads a duration given as seconds or as the
+// "HH:MM:SS" and "MM:SS" forms itunes:duration allows. It returns 0 when
+// the duration is missing or invalid.
+func parseMediaDuration(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	parts := strings.Split(value, ":")
+	if value == "" || len(parts) > 3 {
+		return 0
+	}
+
+	var seconds float64
+	for _, part := range parts {
+		n, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || n < 0 {
+			return 0
+		}
+		seconds = seconds*60 + n
+	}
+	return time.Duration(seconds * float64(time.Second)).Round(time.Second)
+}
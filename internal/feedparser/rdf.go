@@ -0,0 +1,186 @@
+package feedparser
+
+import (
+	"encoding/xml"
+	"html"
+	"strings"
+	"time"
+)
+
+const (
+	rdfNamespace        = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+	dublinCoreNamespace = "http://purl.org/dc/elements/1.1/"
+)
+
+// dublinCoreXML holds the Dublin Core elements feeds use for metadata. RSS
+// 1.0 relies on them for dates and authors, and they often show up in RSS 2.0
+// too. It must be embedded before any un-namespaced title or description
+// field so that dc:title doesn't land in the plain title field.
+type dublinCoreXML struct {
+	DCTitle       string `xml:"http://purl.org/dc/elements/1.1/ title"`
+	DCCreator     string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	DCDate        string `xml:"http://purl.org/dc/elements/1.1/ date"`
+	DCDescription string `xml:"http://purl.org/dc/elements/1.1/ description"`
+}
+
+// rdfXML is an RSS 1.0 document. Unlike RSS 2.0, items are siblings of the
+// channel rather than children of it.
+type rdfXML struct {
+	XMLName xml.Name `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# RDF"`
+	Channel struct {
+		dublinCoreXML
+		Title       string `xml:"title"`
+		Link        string `xml:"link"`
+		Description string `xml:"description"`
+		syndicationXML
+	} `xml:"channel"`
+	Item []rdfItemXML `xml:"item"`
+}
+
+type rdfItemXML struct {
+	dublinCoreXML
+	mediaXML
+	ContentEncoded string `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	About          string `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# about,attr"`
+	Title          string `xml:"title"`
+	Link           string `xml:"link"`
+	Description    string `xml:"description"`
+}
+
+type RDFFeed struct {
+	title       string
+	link        string
+	description string
+	items       []*RDFItem
+	hints       UpdateHints
+}
+
+type RDFItem struct {
+	guid        string
+	title       string
+	link        string
+	description string
+	content     string
+	author      string
+	date        time.Time
+	enclosures  []Enclosure
+	thumbnail   string
+}
+
+func (f *RDFFeed) GetTitle() string {
+	return f.title
+}
+
+func (f *RDFFeed) GetLink() string {
+	return f.link
+}
+
+func (f *RDFFeed) GetDescription() string {
+	return f.description
+}
+
+func (f *RDFFeed) GetItems() []Item {
+	items := make([]Item, len(f.items))
+	for i, item := range f.items {
+		items[i] = item
+	}
+	return items
+}
+
+func (f *RDFFeed) GetUpdateHints() UpdateHints {
+	return f.hints
+}
+
+func (i *RDFItem) GetGUID() string {
+	return i.guid
+}
+
+func (i *RDFItem) GetTitle() string {
+	return i.title
+}
+
+func (i *RDFItem) GetLink() string {
+	return i.link
+}
+
+func (i *RDFItem) GetDescription() *string {
+	return &i.description
+}
+
+func (i *RDFItem) GetContent() string {
+	return i.content
+}
+
+func (i *RDFItem) GetAuthor() string {
+	return i.author
+}
+
+func (i *RDFItem) GetDate() time.Time {
+	return i.date
+}
+
+func (i *RDFItem) GetEnclosures() []Enclosure {
+	return i.enclosures
+}
+
+func (i *RDFItem) GetThumbnail() string {
+	return i.thumbnail
+}
+
+func parseRDFFeed(body []byte, feedURL string) (Feed, error) {
+	var xmlFeed rdfXML
+	if err := unmarshalXML(body, &xmlFeed); err != nil {
+		return nil, err
+	}
+
+	channel := xmlFeed.Channel
+	feed := &RDFFeed{
+		title:       html.UnescapeString(firstNonEmpty(channel.Title, channel.DCTitle)),
+		link:        strings.TrimSpace(channel.Link),
+		description: html.UnescapeString(firstNonEmpty(channel.Description, channel.DCDescription)),
+		items:       make([]*RDFItem, 0, len(xmlFeed.Item)),
+		hints: UpdateHints{
+			UpdatePeriod: channel.period(),
+		},
+	}
+
+	// dc:date is optional on items, so fall back to the channel's date, and
+	// to a zero date when neither can be read
+	channelDate, _ := parseDate(channel.DCDate)
+	channelBase := resolveBase(feedURL, channel.Link)
+
+	for _, item := range xmlFeed.Item {
+		parsedDate, err := parseDate(item.DCDate)
+		if err != nil {
+			parsedDate = channelDate
+		}
+
+		enclosures := item.enclosures(nil)
+		link := strings.TrimSpace(firstNonEmpty(item.Link, item.About))
+		description := firstNonEmpty(item.Description, item.DCDescription, item.ContentEncoded)
+
+		// rdf:about is the item's URI, which RSS 1.0 requires to be stable
+		feed.items = append(feed.items, &RDFItem{
+			guid:        strings.TrimSpace(item.About),
+			title:       html.UnescapeString(firstNonEmpty(item.Title, item.DCTitle)),
+			link:        link,
+			description: htmlToText(description),
+			content:     sanitizeHTML(firstNonEmpty(item.ContentEncoded, description), resolveBase(channelBase, link)),
+			author:      html.UnescapeString(strings.TrimSpace(item.DCCreator)),
+			date:        parsedDate,
+			enclosures:  enclosures,
+			thumbnail:   item.thumbnail(enclosures),
+		})
+	}
+
+	return feed, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,335 @@
+package feedparser
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// allowedElements lists the elements kept in sanitized content and the
+// attributes each may keep. Elements not listed are unwrapped, keeping
+// their text, unless droppedElements removes them entirely.
+var allowedElements = map[atom.Atom][]string{
+	atom.A:          {"href", "title"},
+	atom.Abbr:       {"title"},
+	atom.Audio:      {"src", "controls"},
+	atom.B:          nil,
+	atom.Blockquote: {"cite"},
+	atom.Br:         nil,
+	atom.Caption:    nil,
+	atom.Cite:       nil,
+	atom.Code:       nil,
+	atom.Dd:         nil,
+	atom.Del:        nil,
+	atom.Details:    nil,
+	atom.Div:        nil,
+	atom.Dl:         nil,
+	atom.Dt:         nil,
+	atom.Em:         nil,
+	atom.Figcaption: nil,
+	atom.Figure:     nil,
+	atom.H1:         nil,
+	atom.H2:         nil,
+	atom.H3:         nil,
+	atom.H4:         nil,
+	atom.H5:         nil,
+	atom.H6:         nil,
+	atom.Hr:         nil,
+	atom.I:          nil,
+	atom.Iframe:     {"src", "width", "height", "allowfullscreen"},
+	atom.Img:        {"src", "alt", "title", "width", "height"},
+	atom.Ins:        nil,
+	atom.Kbd:        nil,
+	atom.Li:         nil,
+	atom.Mark:       nil,
+	atom.Ol:         {"start"},
+	atom.P:          nil,
+	atom.Picture:    nil,
+	atom.Pre:        nil,
+	atom.Q:          {"cite"},
+	atom.S:          nil,
+	atom.Small:      nil,
+	atom.Source:     {"src", "type"},
+	atom.Span:       nil,
+	atom.Strong:     nil,
+	atom.Sub:        nil,
+	atom.Summary:    nil,
+	atom.Sup:        nil,
+	atom.Table:      nil,
+	atom.Tbody:      nil,
+	atom.Td:         {"colspan", "rowspan"},
+	atom.Tfoot:      nil,
+	atom.Th:         {"colspan", "rowspan", "scope"},
+	atom.Thead:      nil,
+	atom.Tr:         nil,
+	atom.U:          nil,
+	atom.Ul:         nil,
+	atom.Video:      {"src", "controls", "poster", "width", "height"},
+}
+
+// droppedElements are removed along with everything inside them.
+var droppedElements = map[atom.Atom]bool{
+	atom.Button:   true,
+	atom.Embed:    true,
+	atom.Form:     true,
+	atom.Head:     true,
+	atom.Input:    true,
+	atom.Link:     true,
+	atom.Math:     true,
+	atom.Meta:     true,
+	atom.Noscript: true,
+	atom.Object:   true,
+	atom.Script:   true,
+	atom.Select:   true,
+	atom.Style:    true,
+	atom.Svg:      true,
+	atom.Template: true,
+	atom.Textarea: true,
+	atom.Title:    true,
+}
+
+// iframeHosts are the video players whose embeds are kept. Iframes from
+// anywhere else are dropped.
+var iframeHosts = map[string]bool{
+	"www.youtube.com":          true,
+	"www.youtube-nocookie.com": true,
+	"player.vimeo.com":         true,
+}
+
+// urlAttributes hold URLs, which are resolved against the content's base
+// and dropped unless they use a safe scheme.
+var urlAttributes = map[string]bool{
+	"href":   true,
+	"src":    true,
+	"poster": true,
+	"cite":   true,
+}
+
+// blockElements separate lines of text when content is flattened.
+var blockElements = map[atom.Atom]bool{
+	atom.Blockquote: true,
+	atom.Br:         true,
+	atom.Dd:         true,
+	atom.Div:        true,
+	atom.Dt:         true,
+	atom.Figcaption: true,
+	atom.H1:         true,
+	atom.H2:         true,
+	atom.H3:         true,
+	atom.H4:         true,
+	atom.H5:         true,
+	atom.H6:         true,
+	atom.Hr:         true,
+	atom.Li:         true,
+	atom.P:          true,
+	atom.Pre:        true,
+	atom.Tr:         true,
+}
+
+// lineBreaks in the source of HTML are only whitespace.
+var lineBreaks = strings.NewReplacer("\r", " ", "\n", " ")
+
+// sanitizeHTML keeps the parts of an HTML fragment that are safe to show
+// in the reader: allowlisted elements and attributes, with relative URLs
+// resolved against base. Scripts, styles, event handlers and iframes from
+// unknown hosts are removed.
+func sanitizeHTML(content, base string) string {
+	nodes, err := parseFragment(content)
+	if err != nil {
+		return ""
+	}
+
+	var sb strings.Builder
+	for _, node := range nodes {
+		writeSanitized(&sb, node, base)
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// textToHTML turns plain text into HTML, keeping its paragraphs.
+func textToHTML(text string) string {
+	var paragraphs []string
+	for _, paragraph := range strings.Split(strings.TrimSpace(text), "\n\n") {
+		if paragraph = strings.TrimSpace(paragraph); paragraph != "" {
+			escaped := html.EscapeString(paragraph)
+			paragraphs = append(paragraphs, "<p>"+strings.ReplaceAll(escaped, "\n", "<br>")+"</p>")
+		}
+	}
+	return strings.Join(paragraphs, "\n")
+}
+
+// htmlToText flattens an HTML fragment to plain text, one line per block
+// and with entities decoded.
+func htmlToText(content string) string {
+	nodes, err := parseFragment(content)
+	if err != nil {
+		return ""
+	}
+
+	var sb strings.Builder
+	for _, node := range nodes {
+		writeText(&sb, node)
+	}
+
+	var lines []string
+	for _, line := range strings.Split(sb.String(), "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func parseFragment(content string) ([]*html.Node, error) {
+	return html.ParseFragment(strings.NewReader(content), &html.Node{
+		Type:     html.ElementNode,
+		Data:     "body",
+		DataAtom: atom.Body,
+	})
+}
+
+func writeSanitized(sb *strings.Builder, node *html.Node, base string) {
+	switch node.Type {
+	case html.TextNode:
+		sb.WriteString(html.EscapeString(node.Data))
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+
+	if droppedElements[node.DataAtom] {
+		return
+	}
+	attributes, allowed := allowedElements[node.DataAtom]
+	if !allowed {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			writeSanitized(sb, child, base)
+		}
+		return
+	}
+
+	attrs := sanitizeAttributes(node, attributes, base)
+	if node.DataAtom == atom.Iframe && !isAllowedIframe(attrs) {
+		return
+	}
+	// Images are useless without their source
+	if (node.DataAtom == atom.Img || node.DataAtom == atom.Source) && !hasAttribute(attrs, "src") {
+		return
+	}
+	if node.DataAtom == atom.A && hasAttribute(attrs, "href") {
+		attrs = append(attrs,
+			html.Attribute{Key: "rel", Val: "noopener noreferrer nofollow"},
+			html.Attribute{Key: "target", Val: "_blank"},
+		)
+	}
+
+	sb.WriteString("<" + node.Data)
+	for _, attr := range attrs {
+		sb.WriteString(" " + attr.Key + `="` + html.EscapeString(attr.Val) + `"`)
+	}
+	sb.WriteString(">")
+
+	if isVoidElement(node.DataAtom) {
+		return
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		writeSanitized(sb, child, base)
+	}
+	sb.WriteString("</" + node.Data + ">")
+}
+
+func sanitizeAttributes(node *html.Node, allowed []string, base string) []html.Attribute {
+	var attrs []html.Attribute
+	for _, attr := range node.Attr {
+		if attr.Namespace != "" || !slices.Contains(allowed, attr.Key) {
+			continue
+		}
+		if urlAttributes[attr.Key] {
+			safe, ok := safeURL(attr.Val, base, attr.Key == "href")
+			if !ok {
+				continue
+			}
+			attr.Val = safe
+		}
+		attrs = append(attrs, html.Attribute{Key: attr.Key, Val: attr.Val})
+	}
+	return attrs
+}
+
+// safeURL resolves a URL against base and accepts it only with an http or
+// https scheme, or mailto for links.
+func safeURL(value, base string, isLink bool) (string, bool) {
+	if strings.TrimSpace(value) == "" {
+		return "", false
+	}
+	resolved := resolveBase(base, value)
+	parsed, err := url.Parse(resolved)
+	if err != nil {
+		return "", false
+	}
+	switch strings.ToLower(parsed.Scheme) {
+	case "http", "https":
+		return parsed.String(), true
+	case "mailto":
+		return parsed.String(), isLink
+	default:
+		return "", false
+	}
+}
+
+func isAllowedIframe(attrs []html.Attribute) bool {
+	for _, attr := range attrs {
+		if attr.Key != "src" {
+			continue
+		}
+		parsed, err := url.Parse(attr.Val)
+		return err == nil && parsed.Scheme == "https" && iframeHosts[strings.ToLower(parsed.Host)]
+	}
+	return false
+}
+
+func hasAttribute(attrs []html.Attribute, key string) bool {
+	for _, attr := range attrs {
+		if attr.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func isVoidElement(a atom.Atom) bool {
+	switch a {
+	case atom.Br, atom.Hr, atom.Img, atom.Source:
+		return true
+	}
+	return false
+}
+
+func writeText(sb *strings.Builder, node *html.Node) {
+	switch node.Type {
+	case html.TextNode:
+		sb.WriteString(lineBreaks.Replace(node.Data))
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+
+	if droppedElements[node.DataAtom] || node.DataAtom == atom.Iframe {
+		return
+	}
+	block := blockElements[node.DataAtom]
+	if block {
+		sb.WriteString("\n")
+	}
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		writeText(sb, child)
+	}
+	if block {
+		sb.WriteString("\n")
+	}
+}
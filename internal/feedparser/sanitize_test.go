@@ -0,0 +1,146 @@
+package feedparser
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSanitizeHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "keeps allowed markup",
+			content:  `<p>Some <strong>bold</strong> and <em>italic</em> text</p>`,
+			expected: `<p>Some <strong>bold</strong> and <em>italic</em> text</p>`,
+		},
+		{
+			name:     "drops scripts and styles with their contents",
+			content:  `<p>Before</p><script>alert(1)</script><style>p{}</style><p>After</p>`,
+			expected: `<p>Before</p><p>After</p>`,
+		},
+		{
+			name:     "drops event handlers and unknown attributes",
+			content:  `<p onclick="alert(1)" class="lead" style="color:red">Text</p><img src="https://example.com/a.png" onerror="alert(1)">`,
+			expected: `<p>Text</p><img src="https://example.com/a.png">`,
+		},
+		{
+			name:     "unwraps unknown elements",
+			content:  `<article><section><p>Kept</p></section></article><font>Plain</font>`,
+			expected: `<p>Kept</p>Plain`,
+		},
+		{
+			name:     "resolves relative URLs and marks links external",
+			content:  `<a href="../other">Link</a><img src="/images/a.png" alt="A">`,
+			expected: `<a href="https://example.com/other" rel="noopener noreferrer nofollow" target="_blank">Link</a><img src="https://example.com/images/a.png" alt="A">`,
+		},
+		{
+			name:     "drops unsafe URLs",
+			content:  `<a href="javascript:alert(1)">Link</a><img src="data:image/png;base64,AAAA"><a href="mailto:me@example.com">Mail</a>`,
+			expected: `<a>Link</a><a href="mailto:me@example.com" rel="noopener noreferrer nofollow" target="_blank">Mail</a>`,
+		},
+		{
+			name:     "keeps iframes only from known players",
+			content:  `<iframe src="https://www.youtube.com/embed/abc" width="560"></iframe><iframe src="https://evil.example.com/"></iframe><iframe src="http://www.youtube.com/embed/abc"></iframe>`,
+			expected: `<iframe src="https://www.youtube.com/embed/abc" width="560"></iframe>`,
+		},
+		{
+			name:     "escapes text",
+			content:  `<p>1 &lt; 2 &amp; "quotes"</p>`,
+			expected: `<p>1 &lt; 2 &amp; &#34;quotes&#34;</p>`,
+		},
+		{
+			name:     "closes unbalanced markup",
+			content:  `<p>Open <b>bold`,
+			expected: `<p>Open <b>bold</b></p>`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := sanitizeHTML(tc.content, "https://example.com/posts/1"); got != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestHTMLToText(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"plain text", "Just text", "Just text"},
+		{"entities", "Fish &amp; chips &lt;3", "Fish & chips <3"},
+		{"escaped markup stays text", "&lt;br&gt; is a tag", "<br> is a tag"},
+		{"blocks become lines", "<p>One</p><p>Two <b>bold</b></p><ul><li>Three</li></ul>", "One\nTwo bold\nThree"},
+		{"scripts are dropped", "<p>Text</p><script>var x = 1;</script>", "Text"},
+		{"whitespace is collapsed", "  Lots   of\n\tspace  ", "Lots of space"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := htmlToText(tc.content); got != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestFetchFeed_RSSContent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+		<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/">
+			<channel>
+				<title>Content</title>
+				<link>https://blog.example.com/</link>
+				<item>
+					<title>Full content</title>
+					<link>https://blog.example.com/posts/1/</link>
+					<description>A &lt;em&gt;short&lt;/em&gt; summary.</description>
+					<content:encoded><![CDATA[<p>The <a href="../2/">whole</a> post.</p><script>track()</script><img src="photo.jpg">]]></content:encoded>
+				</item>
+				<item>
+					<title>Description only</title>
+					<link>https://blog.example.com/posts/3/</link>
+					<description>&lt;p&gt;Escaped &lt;b&gt;HTML&lt;/b&gt; body&lt;/p&gt;</description>
+				</item>
+			</channel>
+		</rss>`))
+	}))
+	defer server.Close()
+
+	feed, err := FetchFeed(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []struct{ description, content string }{
+		{
+			"A short summary.",
+			`<p>The <a href="https://blog.example.com/posts/2/" rel="noopener noreferrer nofollow" target="_blank">whole</a> post.</p><img src="https://blog.example.com/posts/1/photo.jpg">`,
+		},
+		{
+			"Escaped HTML body",
+			`<p>Escaped <b>HTML</b> body</p>`,
+		},
+	}
+	items := feed.GetItems()
+	if len(items) != len(expected) {
+		t.Fatalf("expected %d items, got %d", len(expected), len(items))
+	}
+	for i, item := range items {
+		if got := *item.GetDescription(); got != expected[i].description {
+			t.Errorf("item %d: expected description %q, got %q", i, expected[i].description, got)
+		}
+		if got := item.GetContent(); got != expected[i].content {
+			t.Errorf("item %d: expected content %s, got %s", i, expected[i].content, got)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package feedparser
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+const syndicationNamespace = "http://purl.org/rss/1.0/modules/syndication/"
+
+// UpdateHints are the publisher's own suggestions for how often a feed should
+// be polled. Zero values mean the feed gave no hint.
+type UpdateHints struct {
+	// TTL is the RSS <ttl>: how long the feed may be cached.
+	TTL time.Duration
+	// UpdatePeriod comes from sy:updatePeriod divided by sy:updateFrequency.
+	UpdatePeriod time.Duration
+	// SkipHours are GMT hours (0-23) during which the feed should not be polled.
+	SkipHours []int
+	// SkipDays are days on which the feed should not be polled.
+	SkipDays []time.Weekday
+}
+
+// MinInterval returns the longest interval the publisher asked for, which is
+// the shortest interval a polite reader should use.
+func (h UpdateHints) MinInterval() time.Duration {
+	return max(h.TTL, h.UpdatePeriod)
+}
+
+// syndicationXML holds the RSS 1.0 syndication module elements, which also
+// show up in RSS 2.0 and Atom feeds.
+type syndicationXML struct {
+	UpdatePeriod    string `xml:"http://purl.org/rss/1.0/modules/syndication/ updatePeriod"`
+	UpdateFrequency string `xml:"http://purl.org/rss/1.0/modules/syndication/ updateFrequency"`
+}
+
+func (s syndicationXML) period() time.Duration {
+	var period time.Duration
+	switch strings.ToLower(strings.TrimSpace(s.UpdatePeriod)) {
+	case "hourly":
+		period = time.Hour
+	case "daily":
+		period = 24 * time.Hour
+	case "weekly":
+		period = 7 * 24 * time.Hour
+	case "monthly":
+		period = 30 * 24 * time.Hour
+	case "yearly":
+		period = 365 * 24 * time.Hour
+	default:
+		return 0
+	}
+
+	frequency, err := strconv.Atoi(strings.TrimSpace(s.UpdateFrequency))
+	if err != nil || frequency < 1 {
+		frequency = 1
+	}
+
+	return period / time.Duration(frequency)
+}
+
+func parseTTL(ttl string) time.Duration {
+	minutes, err := strconv.Atoi(strings.TrimSpace(ttl))
+	if err != nil || minutes <= 0 {
+		return 0
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+func parseSkipHours(hours []string) []int {
+	var parsed []int
+	for _, hour := range hours {
+		h, err := strconv.Atoi(strings.TrimSpace(hour))
+		if err != nil || h < 0 || h > 24 {
+			continue
+		}
+		// Some feeds use 1-24 instead of 0-23
+		parsed = append(parsed, h%24)
+	}
+	return parsed
+}
+
+func parseSkipDays(days []string) []time.Weekday {
+	var parsed []time.Weekday
+	for _, day := range days {
+		for d := time.Sunday; d <= time.Saturday; d++ {
+			if strings.EqualFold(strings.TrimSpace(day), d.String()) {
+				parsed = append(parsed, d)
+				break
+			}
+		}
+	}
+	return parsed
+}
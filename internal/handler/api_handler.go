@@ -0,0 +1,273 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/nrbernard/gator/internal/models"
+	"github.com/nrbernard/gator/internal/service"
+)
+
+// APIHandler serves the versioned JSON API under /api/v1. Errors are returned
+// as echo.HTTPError so they render as {"message": "..."}.
+type APIHandler struct {
+	PostService      *service.PostService
+	FeedService      *service.FeedService
+	SavedPostService *service.SavedPostService
+	ReadPostService  *service.ReadPostService
+}
+
+func NewAPIHandler(postService *service.PostService, feedService *service.FeedService, savedPostService *service.SavedPostService, readPostService *service.ReadPostService) (*APIHandler, error) {
+	if postService == nil || feedService == nil || savedPostService == nil || readPostService == nil {
+		return nil, fmt.Errorf("all services must be provided")
+	}
+	return &APIHandler{
+		PostService:      postService,
+		FeedService:      feedService,
+		SavedPostService: savedPostService,
+		ReadPostService:  readPostService,
+	}, nil
+}
+
+type apiFeed struct {
+	ID            uuid.UUID  `json:"id"`
+	Name          string     `json:"name"`
+	Description   string     `json:"description"`
+	URL           string     `json:"url"`
+	LastFetchedAt *time.Time `json:"last_fetched_at,omitempty"`
+	DeadAt        *time.Time `json:"dead_at,omitempty"`
+}
+
+type apiPost struct {
+	ID          uuid.UUID `json:"id"`
+	Title       string    `json:"title"`
+	URL         string    `json:"url"`
+	Description string    `json:"description"`
+	PublishedAt time.Time `json:"published_at"`
+	FeedID      uuid.UUID `json:"feed_id"`
+	FeedName    string    `json:"feed_name"`
+	Saved       bool      `json:"saved"`
+	Read        bool      `json:"read"`
+}
+
+type apiFeedsResponse struct {
+	Feeds []apiFeed `json:"feeds"`
+}
+
+type apiPostsResponse struct {
+	Posts []apiPost `json:"posts"`
+}
+
+func toAPIFeed(feed models.Feed) apiFeed {
+	out := apiFeed{
+		ID:            feed.ID,
+		Name:          feed.Name,
+		URL:           feed.Url,
+		LastFetchedAt: feed.LastFetchedAt,
+		DeadAt:        feed.DeadAt,
+	}
+	if feed.Description != nil {
+		out.Description = *feed.Description
+	}
+	return out
+}
+
+func toAPIPosts(posts []models.Post) []apiPost {
+	out := make([]apiPost, 0, len(posts))
+	for _, post := range posts {
+		out = append(out, apiPost{
+			ID:          post.ID,
+			Title:       post.Title,
+			URL:         post.Link,
+			Description: post.Description,
+			PublishedAt: post.PublishedAt,
+			FeedID:      post.FeedID,
+			FeedName:    post.FeedName,
+			Saved:       post.IsSaved,
+			Read:        post.IsRead,
+		})
+	}
+	return out
+}
+
+func apiUserID(c echo.Context) (uuid.UUID, error) {
+	userID, ok := c.Get("userID").(uuid.UUID)
+	if !ok {
+		return uuid.Nil, fmt.Errorf("failed to get user from context")
+	}
+	return userID, nil
+}
+
+func apiPostID(c echo.Context) (uuid.UUID, error) {
+	postID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return uuid.Nil, echo.NewHTTPError(http.StatusBadRequest, "invalid post id")
+	}
+	return postID, nil
+}
+
+func (h *APIHandler) ListFeeds(c echo.Context) error {
+	userID, err := apiUserID(c)
+	if err != nil {
+		return err
+	}
+
+	feeds, err := h.FeedService.ListFeeds(c.Request().Context(), userID)
+	if err != nil {
+		return fmt.Errorf("failed to get feeds: %s", err)
+	}
+
+	resp := apiFeedsResponse{Feeds: make([]apiFeed, 0, len(feeds))}
+	for _, feed := range feeds {
+		resp.Feeds = append(resp.Feeds, toAPIFeed(feed))
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+type createFeedRequest struct {
+	URL string `json:"url" form:"url"`
+}
+
+func (h *APIHandler) CreateFeed(c echo.Context) error {
+	userID, err := apiUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req createFeedRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+	if req.URL == "" {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, "url is required")
+	}
+
+	feed, err := h.FeedService.CreateFeed(c.Request().Context(), service.CreateFeedParams{
+		Url:    req.URL,
+		UserID: userID,
+	})
+	if isFeedRequestError(err) {
+		return echo.NewHTTPError(http.StatusUnprocessableEntity, err.Error())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create feed: %s", err)
+	}
+
+	return c.JSON(http.StatusCreated, toAPIFeed(feed))
+}
+
+// isFeedRequestError reports whether err is down to the feed the caller
+// asked for rather than to the server, so its message can be shown to them.
+func isFeedRequestError(err error) bool {
+	var fetchErr *service.FetchError
+	var choiceErr *service.FeedChoiceError
+	var credsErr *service.CredentialsError
+	return errors.As(err, &fetchErr) ||
+		errors.As(err, &choiceErr) ||
+		errors.As(err, &credsErr) ||
+		errors.Is(err, service.ErrNoFeedFound) ||
+		errors.Is(err, service.ErrPrivateFeed)
+}
+
+func (h *APIHandler) DeleteFeed(c echo.Context) error {
+	userID, err := apiUserID(c)
+	if err != nil {
+		return err
+	}
+
+	feedID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid feed id")
+	}
+
+	if err := h.FeedService.Unsubscribe(c.Request().Context(), userID, feedID); err != nil {
+		if errors.Is(err, service.ErrNotFollowing) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return fmt.Errorf("failed to unsubscribe from feed: %s", err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ListPosts returns the posts in the caller's feeds. The status query
+// parameter is "unread" (the default), "saved" or "all".
+func (h *APIHandler) ListPosts(c echo.Context) error {
+	var options service.SearchOptions
+	switch c.QueryParam("status") {
+	case "", "unread":
+		options.Unread = true
+	case "saved":
+		options.Saved = true
+	case "all":
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, "status must be one of unread, saved or all")
+	}
+
+	return h.searchPosts(c, options)
+}
+
+func (h *APIHandler) Search(c echo.Context) error {
+	query := c.QueryParam("q")
+	if query == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "q is required")
+	}
+
+	return h.searchPosts(c, service.SearchOptions{Query: &query})
+}
+
+func (h *APIHandler) searchPosts(c echo.Context, options service.SearchOptions) error {
+	userID, err := apiUserID(c)
+	if err != nil {
+		return err
+	}
+
+	posts, err := h.PostService.SearchPosts(c.Request().Context(), userID, options)
+	if err != nil {
+		return fmt.Errorf("failed to get posts: %s", err)
+	}
+
+	return c.JSON(http.StatusOK, apiPostsResponse{Posts: toAPIPosts(posts)})
+}
+
+func (h *APIHandler) SavePost(c echo.Context) error {
+	return h.setPostState(c, h.SavedPostService.SavePost)
+}
+
+func (h *APIHandler) UnsavePost(c echo.Context) error {
+	return h.setPostState(c, h.SavedPostService.UnsavePost)
+}
+
+func (h *APIHandler) MarkRead(c echo.Context) error {
+	return h.setPostState(c, h.ReadPostService.Save)
+}
+
+func (h *APIHandler) MarkUnread(c echo.Context) error {
+	return h.setPostState(c, h.ReadPostService.Delete)
+}
+
+func (h *APIHandler) setPostState(c echo.Context, update func(ctx context.Context, postID uuid.UUID, userID uuid.UUID) error) error {
+	userID, err := apiUserID(c)
+	if err != nil {
+		return err
+	}
+
+	postID, err := apiPostID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := update(c.Request().Context(), postID, userID); err != nil {
+		if errors.Is(err, service.ErrPostNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return fmt.Errorf("failed to update post: %s", err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/nrbernard/gator/internal/middleware"
+	"github.com/nrbernard/gator/internal/service"
+)
+
+type AuthHandler struct {
+	UserService    *service.UserService
+	SessionService *service.SessionService
+}
+
+func NewAuthHandler(userService *service.UserService, sessionService *service.SessionService) (*AuthHandler, error) {
+	if userService == nil || sessionService == nil {
+		return nil, fmt.Errorf("all services must be provided")
+	}
+	return &AuthHandler{UserService: userService, SessionService: sessionService}, nil
+}
+
+func (h *AuthHandler) LoginPage(c echo.Context) error {
+	return c.Render(http.StatusOK, "login.html", NewFormData())
+}
+
+func (h *AuthHandler) Login(c echo.Context) error {
+	name := c.FormValue("name")
+	password := c.FormValue("password")
+
+	user, err := h.UserService.Authenticate(c.Request().Context(), name, password)
+	if err != nil {
+		if !errors.Is(err, service.ErrInvalidCredentials) {
+			return fmt.Errorf("failed to log in: %s", err)
+		}
+
+		formData := NewFormData()
+		formData.Errors["form"] = err.Error()
+		formData.Values["name"] = name
+		return c.Render(http.StatusUnprocessableEntity, "login.html", formData)
+	}
+
+	if err := h.startSession(c, user.ID); err != nil {
+		return err
+	}
+	return c.Redirect(http.StatusSeeOther, "/posts")
+}
+
+func (h *AuthHandler) SignupPage(c echo.Context) error {
+	return c.Render(http.StatusOK, "signup.html", NewFormData())
+}
+
+func (h *AuthHandler) Signup(c echo.Context) error {
+	name := c.FormValue("name")
+	password := c.FormValue("password")
+
+	formData := NewFormData()
+	formData.Values["name"] = name
+
+	if password != c.FormValue("confirm") {
+		formData.Errors["confirm"] = "passwords do not match"
+		return c.Render(http.StatusUnprocessableEntity, "signup.html", formData)
+	}
+
+	user, err := h.UserService.Register(c.Request().Context(), name, password)
+	if err != nil {
+		var validationErr *service.ValidationError
+		switch {
+		case errors.As(err, &validationErr):
+			formData.Errors[validationErr.Field] = validationErr.Message
+		case errors.Is(err, service.ErrUserExists):
+			formData.Errors["name"] = err.Error()
+		default:
+			return fmt.Errorf("failed to sign up: %s", err)
+		}
+		return c.Render(http.StatusUnprocessableEntity, "signup.html", formData)
+	}
+
+	if err := h.startSession(c, user.ID); err != nil {
+		return err
+	}
+	return c.Redirect(http.StatusSeeOther, "/posts")
+}
+
+func (h *AuthHandler) Logout(c echo.Context) error {
+	if cookie, err := c.Cookie(middleware.SessionCookieName); err == nil && cookie.Value != "" {
+		if err := h.SessionService.Delete(c.Request().Context(), cookie.Value); err != nil {
+			return err
+		}
+	}
+
+	c.SetCookie(sessionCookie(c, "", -1))
+	return c.Redirect(http.StatusSeeOther, "/login")
+}
+
+func (h *AuthHandler) startSession(c echo.Context, userID uuid.UUID) error {
+	token, err := h.SessionService.Create(c.Request().Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	c.SetCookie(sessionCookie(c, token, int(service.SessionDuration/time.Second)))
+	return nil
+}
+
+func sessionCookie(c echo.Context, value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     middleware.SessionCookieName,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   c.Scheme() == "https",
+		SameSite: http.SameSiteLaxMode,
+	}
+}
@@ -1,11 +1,17 @@
 package handler
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	"github.com/nrbernard/gator/internal/feedparser"
 	"github.com/nrbernard/gator/internal/models"
 	"github.com/nrbernard/gator/internal/service"
 )
@@ -34,8 +40,83 @@ func NewFormData() FormData {
 	}
 }
 
+// FeedFormData is the feed form's state, including the feeds to choose
+// between when the URL was a web page offering several, and the preview of
+// the feed about to be added.
+type FeedFormData struct {
+	FormData
+	Candidates  []feedparser.FeedLink
+	Preview     *models.FeedPreview
+	Credentials CredentialsFields
+}
+
+// CredentialsFields fill in the credentials part of the feed forms. The
+// secrets in them are only ever what the user has just submitted, never
+// what is stored.
+type CredentialsFields struct {
+	Username string
+	Password string
+	Token    string
+	Cookie   string
+	Headers  string
+	// Auth describes the feed's stored credentials when editing it.
+	Auth  *models.FeedAuth
+	Error string
+}
+
+func submittedCredentials(c echo.Context) CredentialsFields {
+	return CredentialsFields{
+		Username: strings.TrimSpace(c.FormValue("username")),
+		Password: c.FormValue("password"),
+		Token:    strings.TrimSpace(c.FormValue("token")),
+		Cookie:   strings.TrimSpace(c.FormValue("cookie")),
+		Headers:  c.FormValue("headers"),
+	}
+}
+
+// credentials reads the submitted fields. Extra headers are written one
+// "Name: value" per line, and are nil when left blank.
+func (f CredentialsFields) credentials() (models.FeedCredentials, error) {
+	creds := models.FeedCredentials{
+		Username: f.Username,
+		Password: f.Password,
+		Token:    f.Token,
+		Cookie:   f.Cookie,
+	}
+	for _, line := range strings.Split(f.Headers, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return creds, &service.CredentialsError{Reason: fmt.Sprintf("write headers as \"Name: value\", not %q", line)}
+		}
+		if creds.Headers == nil {
+			creds.Headers = map[string]string{}
+		}
+		creds.Headers[http.CanonicalHeaderKey(strings.TrimSpace(name))] = strings.TrimSpace(value)
+	}
+	return creds, nil
+}
+
+// credentialsErrorMessage returns what to show next to the credentials
+// fields for err, or false when err isn't about them.
+func credentialsErrorMessage(err error) (string, bool) {
+	var credsErr *service.CredentialsError
+	if errors.As(err, &credsErr) || errors.Is(err, service.ErrNoCredentialsKey) {
+		return err.Error(), true
+	}
+	return "", false
+}
+
+func NewFeedFormData() FeedFormData {
+	return FeedFormData{FormData: NewFormData()}
+}
+
 type PageData struct {
-	FormData FormData
+	FormData FeedFormData
+	Import   FeedImportData
 	Feeds    []models.Feed
 }
 
@@ -51,7 +132,7 @@ func (h *FeedHandler) Index(c echo.Context) error {
 	}
 
 	return c.Render(http.StatusOK, "feeds-index.html", PageData{
-		FormData: NewFormData(),
+		FormData: NewFeedFormData(),
 		Feeds:    feeds,
 	})
 }
@@ -63,42 +144,311 @@ func (h *FeedHandler) Create(c echo.Context) error {
 	}
 
 	url := c.FormValue("url")
+	fields := submittedCredentials(c)
 
-	feed, err := h.FeedService.CreateFeed(c.Request().Context(), service.CreateFeedParams{
-		Url:    url,
-		UserID: userID,
-	})
+	creds, err := fields.credentials()
+	if err == nil {
+		_, err = h.FeedService.CreateFeed(c.Request().Context(), service.CreateFeedParams{
+			Url:         chosenFeedURL(c),
+			UserID:      userID,
+			Credentials: creds,
+		})
+	}
 	if err != nil {
-		formData := FormData{
-			Errors: map[string]string{
-				"url": err.Error(),
-			},
-			Values: map[string]string{
-				"url": url,
-			},
-		}
-
-		return c.Render(http.StatusUnprocessableEntity, "feed-form", formData)
+		return c.Render(http.StatusUnprocessableEntity, "feed-form", feedFormError(url, fields, err))
 	}
 
-	formData := NewFormData()
+	formData := NewFeedFormData()
 	renderErr := c.Render(http.StatusOK, "feed-form", formData)
 	if renderErr != nil {
 		return renderErr
 	}
 
-	return c.Render(http.StatusOK, "oob-feed", feed)
+	// Re-render the whole list: following a feed twice must not duplicate it
+	feeds, err := h.FeedService.ListFeeds(c.Request().Context(), userID)
+	if err != nil {
+		return fmt.Errorf("failed to get feeds: %s", err)
+	}
+
+	return c.Render(http.StatusOK, "oob-feeds", feeds)
+}
+
+// Preview shows what the feed at the submitted URL looks like without
+// subscribing to it.
+func (h *FeedHandler) Preview(c echo.Context) error {
+	url := c.FormValue("url")
+	fields := submittedCredentials(c)
+
+	creds, err := fields.credentials()
+	var preview models.FeedPreview
+	if err == nil {
+		preview, err = h.FeedService.PreviewFeed(c.Request().Context(), chosenFeedURL(c), creds)
+	}
+	if err != nil {
+		return c.Render(http.StatusUnprocessableEntity, "feed-form", feedFormError(url, fields, err))
+	}
+
+	// The credentials go along with the subscription, so they are kept
+	formData := NewFeedFormData()
+	formData.Values["url"] = url
+	formData.Preview = &preview
+	formData.Credentials = fields
+	return c.Render(http.StatusOK, "feed-form", formData)
+}
+
+// chosenFeedURL returns the feed the form was submitted for: the one picked
+// among those discovered on the page at url, or url itself. A pick is
+// ignored once url has been changed.
+func chosenFeedURL(c echo.Context) string {
+	url := c.FormValue("url")
+	if feedURL := c.FormValue("feed_url"); feedURL != "" && c.FormValue("feed_source") == url {
+		return feedURL
+	}
+	return url
+}
+
+func feedFormError(url string, fields CredentialsFields, err error) FeedFormData {
+	formData := NewFeedFormData()
+	formData.Values["url"] = url
+	formData.Credentials = fields
+
+	var choiceErr *service.FeedChoiceError
+	if errors.As(err, &choiceErr) {
+		formData.Errors["url"] = "This site has several feeds, choose one."
+		formData.Candidates = choiceErr.Candidates
+	} else if message, ok := credentialsErrorMessage(err); ok {
+		formData.Credentials.Error = message
+	} else {
+		formData.Errors["url"] = err.Error()
+	}
+	return formData
 }
 
 func (h *FeedHandler) Delete(c echo.Context) error {
-	feedID := c.Param("id")
-	if feedID == "" {
-		return fmt.Errorf("failed to get feed id")
+	userID, ok := c.Get("userID").(uuid.UUID)
+	if !ok {
+		return fmt.Errorf("failed to get user from context")
+	}
+
+	feedID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.String(http.StatusNotFound, "feed not found")
 	}
 
-	if err := h.FeedService.DeleteFeed(c.Request().Context(), uuid.MustParse(feedID)); err != nil {
-		return c.String(http.StatusNotFound, "failed to delete feed")
+	if err := h.FeedService.Unsubscribe(c.Request().Context(), userID, feedID); err != nil {
+		return c.String(http.StatusNotFound, "failed to unsubscribe from feed")
 	}
 
 	return c.NoContent(http.StatusOK)
 }
+
+type IntervalOption struct {
+	Value    string
+	Label    string
+	Selected bool
+}
+
+// intervalChoices are the polling intervals offered as manual overrides.
+var intervalChoices = []struct {
+	Label    string
+	Interval time.Duration
+}{
+	{"Every 15 minutes", 15 * time.Minute},
+	{"Every 30 minutes", 30 * time.Minute},
+	{"Every hour", time.Hour},
+	{"Every 3 hours", 3 * time.Hour},
+	{"Every 6 hours", 6 * time.Hour},
+	{"Every 12 hours", 12 * time.Hour},
+	{"Once a day", 24 * time.Hour},
+}
+
+func intervalOptions(override *time.Duration) []IntervalOption {
+	options := []IntervalOption{{Value: "", Label: "Automatic", Selected: override == nil}}
+	for _, choice := range intervalChoices {
+		options = append(options, IntervalOption{
+			Value:    choice.Interval.String(),
+			Label:    choice.Label,
+			Selected: override != nil && *override == choice.Interval,
+		})
+	}
+	return options
+}
+
+type FeedSettingsData struct {
+	Feed            models.Feed
+	IntervalOptions []IntervalOption
+	FormData        FormData
+	Credentials     CredentialsFields
+	Saved           bool
+}
+
+// feedSettings loads the settings of a feed the user follows, answering 404
+// for any other feed.
+func (h *FeedHandler) feedSettings(c echo.Context) (FeedSettingsData, error) {
+	userID, ok := c.Get("userID").(uuid.UUID)
+	if !ok {
+		return FeedSettingsData{}, fmt.Errorf("failed to get user from context")
+	}
+
+	feedID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return FeedSettingsData{}, echo.NewHTTPError(http.StatusNotFound, "feed not found")
+	}
+
+	feed, err := h.FeedService.GetFeed(c.Request().Context(), userID, feedID)
+	if errors.Is(err, service.ErrNotFollowing) {
+		return FeedSettingsData{}, echo.NewHTTPError(http.StatusNotFound, "feed not found")
+	}
+	if err != nil {
+		return FeedSettingsData{}, err
+	}
+
+	data := FeedSettingsData{
+		Feed:            feed,
+		IntervalOptions: intervalOptions(feed.FetchIntervalOverride),
+		FormData:        NewFormData(),
+		Credentials:     CredentialsFields{Auth: feed.Auth},
+	}
+	if feed.Auth != nil {
+		data.Credentials.Username = feed.Auth.Username
+	}
+	return data, nil
+}
+
+func (h *FeedHandler) Edit(c echo.Context) error {
+	data, err := h.feedSettings(c)
+	if err != nil {
+		return err
+	}
+
+	return c.Render(http.StatusOK, "feed-edit.html", data)
+}
+
+func (h *FeedHandler) Update(c echo.Context) error {
+	data, err := h.feedSettings(c)
+	if err != nil {
+		return err
+	}
+
+	var override *time.Duration
+	if value := c.FormValue("interval"); value != "" {
+		interval, err := time.ParseDuration(value)
+		if err != nil {
+			data.FormData.Errors["interval"] = "invalid interval"
+			return c.Render(http.StatusUnprocessableEntity, "feed-settings-form", data)
+		}
+		override = &interval
+	}
+
+	userID := c.Get("userID").(uuid.UUID)
+	if err := h.FeedService.SetFetchIntervalOverride(c.Request().Context(), userID, data.Feed.ID, override); err != nil {
+		data.FormData.Errors["interval"] = err.Error()
+		return c.Render(http.StatusUnprocessableEntity, "feed-settings-form", data)
+	}
+
+	markUnread := c.FormValue("mark_unread_on_update") == "on"
+	if err := h.FeedService.SetMarkUnreadOnUpdate(c.Request().Context(), userID, data.Feed.ID, markUnread); err != nil {
+		return err
+	}
+
+	if err := h.updateCredentials(c, userID, data.Feed.ID); err != nil {
+		message, ok := credentialsErrorMessage(err)
+		if !ok {
+			return err
+		}
+		fields := submittedCredentials(c)
+		fields.Auth = data.Credentials.Auth
+		fields.Error = message
+		data.Credentials = fields
+		return c.Render(http.StatusUnprocessableEntity, "feed-settings-form", data)
+	}
+
+	if c.FormValue("revive") == "on" {
+		if err := h.FeedService.ReviveFeed(c.Request().Context(), userID, data.Feed.ID); err != nil {
+			return err
+		}
+	}
+
+	data, err = h.feedSettings(c)
+	if err != nil {
+		return err
+	}
+	data.Saved = true
+
+	return c.Render(http.StatusOK, "feed-settings-form", data)
+}
+
+// updateCredentials saves the credentials fields of the settings form, or
+// removes the feed's credentials when asked to.
+func (h *FeedHandler) updateCredentials(c echo.Context, userID, feedID uuid.UUID) error {
+	if c.FormValue("clear_credentials") == "on" {
+		return h.FeedService.ClearCredentials(c.Request().Context(), userID, feedID)
+	}
+
+	creds, err := submittedCredentials(c).credentials()
+	if err != nil {
+		return err
+	}
+	return h.FeedService.UpdateCredentials(c.Request().Context(), userID, feedID, creds)
+}
+
+// maxOPMLSize bounds uploaded OPML files; real subscription lists are a few
+// hundred kilobytes at most.
+const maxOPMLSize = 5 << 20
+
+type FeedImportData struct {
+	Summary service.ImportSummary
+	Error   string
+}
+
+func (h *FeedHandler) Import(c echo.Context) error {
+	userID, ok := c.Get("userID").(uuid.UUID)
+	if !ok {
+		return fmt.Errorf("failed to get user from context")
+	}
+
+	fileHeader, err := c.FormFile("opml")
+	if err != nil {
+		return c.Render(http.StatusUnprocessableEntity, "feed-import", FeedImportData{Error: "choose an OPML file to import"})
+	}
+	if fileHeader.Size > maxOPMLSize {
+		return c.Render(http.StatusUnprocessableEntity, "feed-import", FeedImportData{Error: "OPML file is too large"})
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open uploaded file: %s", err)
+	}
+	defer file.Close()
+
+	summary, err := h.FeedService.ImportOPML(c.Request().Context(), userID, io.LimitReader(file, maxOPMLSize))
+	if err != nil {
+		return c.Render(http.StatusUnprocessableEntity, "feed-import", FeedImportData{Error: err.Error()})
+	}
+
+	if err := c.Render(http.StatusOK, "feed-import", FeedImportData{Summary: summary}); err != nil {
+		return err
+	}
+
+	feeds, err := h.FeedService.ListFeeds(c.Request().Context(), userID)
+	if err != nil {
+		return fmt.Errorf("failed to get feeds: %s", err)
+	}
+
+	return c.Render(http.StatusOK, "oob-feeds", feeds)
+}
+
+func (h *FeedHandler) Export(c echo.Context) error {
+	userID, ok := c.Get("userID").(uuid.UUID)
+	if !ok {
+		return fmt.Errorf("failed to get user from context")
+	}
+
+	var buf bytes.Buffer
+	if err := h.FeedService.ExportOPML(c.Request().Context(), userID, &buf); err != nil {
+		return fmt.Errorf("failed to export feeds: %s", err)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="gator-subscriptions.opml"`)
+	return c.Blob(http.StatusOK, "text/x-opml; charset=utf-8", buf.Bytes())
+}
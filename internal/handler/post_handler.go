@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -101,8 +102,35 @@ func (h *PostHandler) Search(c echo.Context) error {
 	})
 }
 
+// Content renders a post's full body, which the list loads when a post is
+// expanded.
+func (h *PostHandler) Content(c echo.Context) error {
+	userID, ok := c.Get("userID").(uuid.UUID)
+	if !ok {
+		return fmt.Errorf("failed to get user from context")
+	}
+
+	postID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusNotFound, "post not found")
+	}
+
+	content, err := h.PostService.GetContent(c.Request().Context(), userID, postID)
+	if err != nil {
+		if errors.Is(err, service.ErrPostNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		return fmt.Errorf("failed to get post content: %s", err)
+	}
+
+	return c.Render(http.StatusOK, "post-content", map[string]interface{}{
+		"Content": content,
+	})
+}
+
 func (h *PostHandler) Refresh(c echo.Context) error {
-	if err := h.FeedService.ScrapeFeeds(c.Request().Context()); err != nil {
+	summary, err := h.FeedService.ScrapeFeeds(c.Request().Context())
+	if err != nil {
 		return fmt.Errorf("failed to scrape feeds: %s", err)
 	}
 
@@ -117,6 +145,7 @@ func (h *PostHandler) Refresh(c echo.Context) error {
 
 	c.Render(http.StatusOK, "posts-refresh", map[string]interface{}{
 		"LastRefresh": time.Now().Format(time.RFC3339),
+		"Failed":      summary.Failed,
 	})
 
 	return c.Render(http.StatusOK, "oob-posts", map[string]interface{}{
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 
@@ -37,6 +38,9 @@ func (h *ReadPostHandler) Save(c echo.Context) error {
 
 	err = h.ReadPostService.Save(context.Background(), postID, userID)
 	if err != nil {
+		if errors.Is(err, service.ErrPostNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
 		return fmt.Errorf("failed to save read post: %s", err)
 	}
 
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 
@@ -39,6 +40,9 @@ func (h *SavedPostHandler) Save(c echo.Context) error {
 
 	err = h.SavedPostService.SavePost(context.Background(), postID, userID)
 	if err != nil {
+		if errors.Is(err, service.ErrPostNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
 		return fmt.Errorf("failed to save post: %s", err)
 	}
 
@@ -60,6 +64,9 @@ func (h *SavedPostHandler) Delete(c echo.Context) error {
 
 	err = h.SavedPostService.UnsavePost(context.Background(), postID, userID)
 	if err != nil {
+		if errors.Is(err, service.ErrPostNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
 		return fmt.Errorf("failed to delete post save: %s", err)
 	}
 
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/nrbernard/gator/internal/models"
+	"github.com/nrbernard/gator/internal/service"
+)
+
+type TokenHandler struct {
+	APITokenService *service.APITokenService
+}
+
+func NewTokenHandler(apiTokenService *service.APITokenService) (*TokenHandler, error) {
+	if apiTokenService == nil {
+		return nil, fmt.Errorf("all services must be provided")
+	}
+	return &TokenHandler{APITokenService: apiTokenService}, nil
+}
+
+type TokensPageData struct {
+	FormData FormData
+	Tokens   []models.APIToken
+}
+
+func (h *TokenHandler) Index(c echo.Context) error {
+	userID, ok := c.Get("userID").(uuid.UUID)
+	if !ok {
+		return fmt.Errorf("failed to get user from context")
+	}
+
+	tokens, err := h.APITokenService.List(c.Request().Context(), userID)
+	if err != nil {
+		return fmt.Errorf("failed to get API tokens: %s", err)
+	}
+
+	return c.Render(http.StatusOK, "tokens-index.html", TokensPageData{
+		FormData: NewFormData(),
+		Tokens:   tokens,
+	})
+}
+
+func (h *TokenHandler) Create(c echo.Context) error {
+	userID, ok := c.Get("userID").(uuid.UUID)
+	if !ok {
+		return fmt.Errorf("failed to get user from context")
+	}
+
+	name := c.FormValue("name")
+
+	token, secret, err := h.APITokenService.Create(c.Request().Context(), userID, name)
+	if err != nil {
+		var validationErr *service.ValidationError
+		if !errors.As(err, &validationErr) {
+			return fmt.Errorf("failed to create API token: %s", err)
+		}
+
+		formData := NewFormData()
+		formData.Errors[validationErr.Field] = validationErr.Message
+		formData.Values["name"] = name
+		return c.Render(http.StatusUnprocessableEntity, "token-form", formData)
+	}
+
+	formData := NewFormData()
+	formData.Values["token"] = secret
+	if err := c.Render(http.StatusOK, "token-form", formData); err != nil {
+		return err
+	}
+
+	return c.Render(http.StatusOK, "oob-token", token)
+}
+
+func (h *TokenHandler) Delete(c echo.Context) error {
+	userID, ok := c.Get("userID").(uuid.UUID)
+	if !ok {
+		return fmt.Errorf("failed to get user from context")
+	}
+
+	tokenID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return fmt.Errorf("failed to parse token ID: %s", err)
+	}
+
+	if err := h.APITokenService.Delete(c.Request().Context(), userID, tokenID); err != nil {
+		return fmt.Errorf("failed to delete API token: %s", err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
@@ -1,22 +1,73 @@
 package middleware
 
 import (
-	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"strings"
 
 	"github.com/labstack/echo/v4"
 	"github.com/nrbernard/gator/internal/service"
 )
 
-func CurrentUser(userService *service.UserService) echo.MiddlewareFunc {
+const SessionCookieName = "gator_session"
+
+// CurrentUser loads the user for the request's session cookie. Anonymous
+// requests are sent to the login page; htmx requests get an HX-Redirect so the
+// whole page navigates instead of swapping the login form into a fragment.
+func CurrentUser(sessionService *service.SessionService) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			user, err := userService.GetUser(context.Background(), "nick")
+			cookie, err := c.Cookie(SessionCookieName)
+			if err != nil || cookie.Value == "" {
+				return redirectToLogin(c)
+			}
+
+			user, err := sessionService.GetUser(c.Request().Context(), cookie.Value)
+			if err != nil {
+				if errors.Is(err, service.ErrSessionNotFound) {
+					return redirectToLogin(c)
+				}
+				return fmt.Errorf("failed to get user from session: %w", err)
+			}
+
+			c.Set("userID", user.ID)
+			c.Set("userName", user.Name)
+			return next(c)
+		}
+	}
+}
+
+func redirectToLogin(c echo.Context) error {
+	if c.Request().Header.Get("HX-Request") == "true" {
+		c.Response().Header().Set("HX-Redirect", "/login")
+		return c.NoContent(http.StatusUnauthorized)
+	}
+	return c.Redirect(http.StatusSeeOther, "/login")
+}
+
+// APIToken authenticates /api requests with an "Authorization: Bearer" token
+// and answers 401 instead of redirecting.
+func APIToken(tokenService *service.APITokenService) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			scheme, token, found := strings.Cut(c.Request().Header.Get(echo.HeaderAuthorization), " ")
+			if !found || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+				return echo.NewHTTPError(http.StatusUnauthorized, "missing bearer token")
+			}
+
+			user, err := tokenService.GetUser(c.Request().Context(), strings.TrimSpace(token))
 			if err != nil {
-				return fmt.Errorf("failed to get user from database: %w", err)
+				if errors.Is(err, service.ErrInvalidAPIToken) {
+					c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer error="invalid_token"`)
+					return echo.NewHTTPError(http.StatusUnauthorized, "invalid bearer token")
+				}
+				return fmt.Errorf("failed to get user from API token: %w", err)
 			}
 
 			c.Set("userID", user.ID)
+			c.Set("userName", user.Name)
 			return next(c)
 		}
 	}
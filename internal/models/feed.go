@@ -1,10 +1,105 @@
 package models
 
-import "github.com/google/uuid"
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type Feed struct {
-	ID          uuid.UUID
+	ID                    uuid.UUID
+	Name                  string
+	Description           *string
+	Url                   string
+	Folder                string
+	FetchInterval         time.Duration
+	FetchIntervalOverride *time.Duration
+	LastFetchedAt         *time.Time
+	NextFetchAt           *time.Time
+	MarkUnreadOnUpdate    bool
+	// DeadAt is when the feed was retired because it is gone or kept
+	// failing; dead feeds are no longer fetched.
+	DeadAt    *time.Time
+	LastError string
+	// Auth describes the feed's stored credentials, or is nil when it has
+	// none.
+	Auth *FeedAuth
+}
+
+// FeedCredentials authenticate the requests for a feed that isn't public,
+// with HTTP Basic auth, a bearer token or a cookie, and add any extra
+// headers the publisher asks for.
+type FeedCredentials struct {
+	Username string            `json:"username,omitempty"`
+	Password string            `json:"password,omitempty"`
+	Token    string            `json:"token,omitempty"`
+	Cookie   string            `json:"cookie,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty"`
+}
+
+func (c FeedCredentials) IsZero() bool {
+	return c.Username == "" && c.Password == "" && c.Token == "" && c.Cookie == "" && len(c.Headers) == 0
+}
+
+// FeedAuth describes a feed's credentials without their secrets, so they
+// can be shown without being revealed.
+type FeedAuth struct {
+	Username    string
+	HasPassword bool
+	HasToken    bool
+	HasCookie   bool
+	HeaderNames []string
+}
+
+// FeedPreview describes a feed that hasn't been subscribed to yet.
+type FeedPreview struct {
+	Url         string
 	Name        string
-	Description *string
+	Description string
+	Link        string
+	ItemCount   int
+	// PostInterval is the typical time between posts, or 0 when the feed
+	// has too few dated items to tell.
+	PostInterval time.Duration
+	// Items are the latest few items, newest first.
+	Items []FeedPreviewItem
+}
+
+type FeedPreviewItem struct {
+	Title       string
 	Url         string
+	PublishedAt *time.Time
+}
+
+// Frequency describes how often the feed posts, such as "about 3 posts a
+// week", or returns "" when that isn't known.
+func (p FeedPreview) Frequency() string {
+	const (
+		day   = 24 * time.Hour
+		week  = 7 * day
+		month = 30 * day
+	)
+
+	switch {
+	case p.PostInterval <= 0:
+		return ""
+	case p.PostInterval <= day:
+		return postsPer(day, p.PostInterval, "day")
+	case p.PostInterval <= week:
+		return postsPer(week, p.PostInterval, "week")
+	case p.PostInterval <= month:
+		return postsPer(month, p.PostInterval, "month")
+	default:
+		return "less than one post a month"
+	}
+}
+
+func postsPer(period, interval time.Duration, unit string) string {
+	count := int(math.Round(float64(period) / float64(interval)))
+	if count <= 1 {
+		return "about one post a " + unit
+	}
+	return fmt.Sprintf("about %d posts a %s", count, unit)
 }
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"html/template"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -16,4 +18,25 @@ type Post struct {
 	FeedName    string
 	IsSaved     bool
 	IsRead      bool
+	// HasContent is set when the post's full body can be shown in place.
+	HasContent bool
+	Thumbnail  string
+	// Enclosure is the post's audio or video, if it has any.
+	Enclosure *Enclosure
+
+	// TitleHighlight and Snippet are set for full-text search results, with
+	// the matched terms wrapped in <mark>.
+	TitleHighlight template.HTML
+	Snippet        template.HTML
+}
+
+// Enclosure is a media file attached to a post, such as a podcast episode.
+type Enclosure struct {
+	URL      string
+	MimeType string
+	Duration time.Duration
+}
+
+func (e *Enclosure) IsVideo() bool {
+	return strings.HasPrefix(e.MimeType, "video/")
 }
@@ -0,0 +1,152 @@
+package opml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Subscription is a single feed from an OPML document, with the folder it
+// was filed under, if any.
+type Subscription struct {
+	Title       string
+	Description string
+	XMLURL      string
+	HTMLURL     string
+	Folder      string
+}
+
+type opmlXML struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    headXML  `xml:"head"`
+	Body    bodyXML  `xml:"body"`
+}
+
+type headXML struct {
+	Title       string `xml:"title,omitempty"`
+	DateCreated string `xml:"dateCreated,omitempty"`
+}
+
+type bodyXML struct {
+	Outlines []outlineXML `xml:"outline"`
+}
+
+type outlineXML struct {
+	Text        string       `xml:"text,attr"`
+	Title       string       `xml:"title,attr,omitempty"`
+	Type        string       `xml:"type,attr,omitempty"`
+	Description string       `xml:"description,attr,omitempty"`
+	XMLURL      string       `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL     string       `xml:"htmlUrl,attr,omitempty"`
+	Category    string       `xml:"category,attr,omitempty"`
+	Outlines    []outlineXML `xml:"outline"`
+}
+
+func (o outlineXML) name() string {
+	if o.Title != "" {
+		return strings.TrimSpace(o.Title)
+	}
+	return strings.TrimSpace(o.Text)
+}
+
+// Parse reads an OPML document and returns every outline that points at a
+// feed. Outlines without an xmlUrl are treated as folders; a feed takes the
+// name of the nearest folder above it, or the first entry of its category
+// attribute when it is not nested.
+func Parse(r io.Reader) ([]Subscription, error) {
+	var doc opmlXML
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse OPML: %w", err)
+	}
+
+	var subscriptions []Subscription
+	var walk func(outlines []outlineXML, folder string)
+	walk = func(outlines []outlineXML, folder string) {
+		for _, outline := range outlines {
+			xmlURL := strings.TrimSpace(outline.XMLURL)
+			if xmlURL == "" {
+				walk(outline.Outlines, outline.name())
+				continue
+			}
+
+			subFolder := folder
+			if subFolder == "" {
+				subFolder = categoryFolder(outline.Category)
+			}
+
+			subscriptions = append(subscriptions, Subscription{
+				Title:       outline.name(),
+				Description: outline.Description,
+				XMLURL:      xmlURL,
+				HTMLURL:     strings.TrimSpace(outline.HTMLURL),
+				Folder:      subFolder,
+			})
+		}
+	}
+	walk(doc.Body.Outlines, "")
+
+	return subscriptions, nil
+}
+
+// categoryFolder turns an OPML category such as "/Tech/Go,/News" into a
+// folder name, using the last path segment of the first category.
+func categoryFolder(category string) string {
+	first, _, _ := strings.Cut(category, ",")
+	first = strings.Trim(strings.TrimSpace(first), "/")
+	if i := strings.LastIndex(first, "/"); i >= 0 {
+		first = first[i+1:]
+	}
+	return strings.TrimSpace(first)
+}
+
+// Write encodes subscriptions as an OPML 2.0 document. Feeds that share a
+// folder are nested under a single outline in the order they are given;
+// feeds without a folder are written at the top level.
+func Write(w io.Writer, title string, created time.Time, subscriptions []Subscription) error {
+	doc := opmlXML{
+		Version: "2.0",
+		Head: headXML{
+			Title:       title,
+			DateCreated: created.UTC().Format(time.RFC1123Z),
+		},
+	}
+
+	folders := map[string]int{}
+	for _, sub := range subscriptions {
+		outline := outlineXML{
+			Text:        sub.Title,
+			Title:       sub.Title,
+			Type:        "rss",
+			Description: sub.Description,
+			XMLURL:      sub.XMLURL,
+			HTMLURL:     sub.HTMLURL,
+		}
+
+		if sub.Folder == "" {
+			doc.Body.Outlines = append(doc.Body.Outlines, outline)
+			continue
+		}
+
+		i, ok := folders[sub.Folder]
+		if !ok {
+			i = len(doc.Body.Outlines)
+			folders[sub.Folder] = i
+			doc.Body.Outlines = append(doc.Body.Outlines, outlineXML{Text: sub.Folder, Title: sub.Folder})
+		}
+		doc.Body.Outlines[i].Outlines = append(doc.Body.Outlines[i].Outlines, outline)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to write OPML: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,101 @@
+package opml
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	input := `<?xml version="1.0" encoding="UTF-8"?>
+<opml version="2.0">
+  <head><title>Subscriptions</title></head>
+  <body>
+    <outline text="Loose Feed" type="rss" xmlUrl="https://loose.example.com/feed.xml" htmlUrl="https://loose.example.com/"/>
+    <outline text="Tech">
+      <outline text="go" title="The Go Blog" type="rss" xmlUrl=" https://go.dev/blog/feed.atom "/>
+      <outline text="Nested">
+        <outline text="Deep" type="rss" xmlUrl="https://deep.example.com/rss"/>
+      </outline>
+    </outline>
+    <outline text="Categorised" type="rss" xmlUrl="https://cat.example.com/rss" category="/News/World,/Other"/>
+    <outline text="Not a feed"/>
+  </body>
+</opml>`
+
+	got, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	want := []Subscription{
+		{Title: "Loose Feed", XMLURL: "https://loose.example.com/feed.xml", HTMLURL: "https://loose.example.com/"},
+		{Title: "The Go Blog", XMLURL: "https://go.dev/blog/feed.atom", Folder: "Tech"},
+		{Title: "Deep", XMLURL: "https://deep.example.com/rss", Folder: "Nested"},
+		{Title: "Categorised", XMLURL: "https://cat.example.com/rss", Folder: "World"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"not xml", "hello"},
+		{"wrong root", `<rss version="2.0"><channel></channel></rss>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(strings.NewReader(tt.input)); err == nil {
+				t.Error("Parse() expected an error")
+			}
+		})
+	}
+}
+
+func TestWrite_RoundTrip(t *testing.T) {
+	subscriptions := []Subscription{
+		{Title: "Alpha", XMLURL: "https://alpha.example.com/feed", Folder: "Tech"},
+		{Title: "Beta & Co", Description: "News <daily>", XMLURL: "https://beta.example.com/feed?a=1&b=2"},
+		{Title: "Gamma", XMLURL: "https://gamma.example.com/feed", Folder: "Tech"},
+	}
+
+	var buf bytes.Buffer
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	if err := Write(&buf, "Gator subscriptions", created, subscriptions); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	out := buf.String()
+	for _, fragment := range []string{
+		`<?xml version="1.0" encoding="UTF-8"?>`,
+		`<opml version="2.0">`,
+		`<dateCreated>Wed, 01 May 2024 12:00:00 +0000</dateCreated>`,
+		`<outline text="Tech" title="Tech">`,
+	} {
+		if !strings.Contains(out, fragment) {
+			t.Errorf("Write() output missing %q:\n%s", fragment, out)
+		}
+	}
+
+	got, err := Parse(&buf)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	// Feeds in the same folder are grouped together
+	want := []Subscription{
+		{Title: "Alpha", XMLURL: "https://alpha.example.com/feed", Folder: "Tech"},
+		{Title: "Gamma", XMLURL: "https://gamma.example.com/feed", Folder: "Tech"},
+		{Title: "Beta & Co", Description: "News <daily>", XMLURL: "https://beta.example.com/feed?a=1&b=2"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,75 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nrbernard/gator/internal/service"
+)
+
+// FeedScraper is the part of service.FeedService the scheduler depends on.
+type FeedScraper interface {
+	ScrapeFeeds(ctx context.Context) (service.ScrapeSummary, error)
+}
+
+// SessionPurger is the part of service.SessionService the scheduler uses to
+// clean up expired sessions.
+type SessionPurger interface {
+	DeleteExpired(ctx context.Context) error
+}
+
+// Scheduler periodically refreshes feeds in the background so posts stay
+// current even when nobody has the page open.
+type Scheduler struct {
+	Scraper  FeedScraper
+	Interval time.Duration
+	// Sessions, when set, has its expired sessions deleted on every run.
+	Sessions SessionPurger
+}
+
+func NewScheduler(scraper FeedScraper, interval time.Duration) (*Scheduler, error) {
+	if scraper == nil {
+		return nil, fmt.Errorf("a feed scraper must be provided")
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("interval must be positive, got %s", interval)
+	}
+	return &Scheduler{Scraper: scraper, Interval: interval}, nil
+}
+
+// Run scrapes feeds immediately and then once per interval until ctx is
+// cancelled. Which feeds are actually fetched on each tick is still decided
+// by the scraper, so a short interval does not bypass per-feed limits.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	s.scrape(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			fmt.Println("feed scheduler stopped")
+			return
+		case <-ticker.C:
+			s.scrape(ctx)
+		}
+	}
+}
+
+func (s *Scheduler) scrape(ctx context.Context) {
+	if _, err := s.Scraper.ScrapeFeeds(ctx); err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		fmt.Printf("scheduled feed refresh failed: %s\n", err)
+	}
+
+	if s.Sessions == nil {
+		return
+	}
+	if err := s.Sessions.DeleteExpired(ctx); err != nil && ctx.Err() == nil {
+		fmt.Printf("failed to clean up sessions: %s\n", err)
+	}
+}
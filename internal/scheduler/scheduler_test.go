@@ -0,0 +1,78 @@
+package scheduler
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nrbernard/gator/internal/service"
+)
+
+type countingScraper struct {
+	calls atomic.Int32
+}
+
+func (s *countingScraper) ScrapeFeeds(ctx context.Context) (service.ScrapeSummary, error) {
+	s.calls.Add(1)
+	return service.ScrapeSummary{}, nil
+}
+
+type countingPurger struct {
+	calls atomic.Int32
+}
+
+func (p *countingPurger) DeleteExpired(ctx context.Context) error {
+	p.calls.Add(1)
+	return nil
+}
+
+func TestNewScheduler_Validation(t *testing.T) {
+	if _, err := NewScheduler(nil, time.Minute); err == nil {
+		t.Error("Expected error for nil scraper")
+	}
+
+	if _, err := NewScheduler(&countingScraper{}, 0); err == nil {
+		t.Error("Expected error for zero interval")
+	}
+}
+
+func TestScheduler_Run(t *testing.T) {
+	scraper := &countingScraper{}
+	s, err := NewScheduler(scraper, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Failed to create scheduler: %v", err)
+	}
+	purger := &countingPurger{}
+	s.Sessions = purger
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+
+	time.Sleep(55 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected scheduler to stop after context cancellation")
+	}
+
+	// One immediate run plus several ticks
+	if calls := scraper.calls.Load(); calls < 2 {
+		t.Errorf("Expected at least 2 scrapes, got %d", calls)
+	}
+	if purges := purger.calls.Load(); purges < 2 {
+		t.Errorf("Expected expired sessions to be purged on every run, got %d purges", purges)
+	}
+
+	calls := scraper.calls.Load()
+	time.Sleep(30 * time.Millisecond)
+	if after := scraper.calls.Load(); after != calls {
+		t.Errorf("Expected no scrapes after stop, got %d more", after-calls)
+	}
+}
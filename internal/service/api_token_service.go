@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nrbernard/gator/internal/database"
+	"github.com/nrbernard/gator/internal/models"
+)
+
+// apiTokenPrefix makes tokens easy to recognise in config files and secret
+// scanners.
+const apiTokenPrefix = "gtr_"
+
+var ErrInvalidAPIToken = errors.New("invalid API token")
+
+type APITokenService struct {
+	Repo *database.Queries
+}
+
+// Create issues a new token for the user. The plaintext token is returned
+// once and only its hash is stored.
+func (s *APITokenService) Create(ctx context.Context, userID uuid.UUID, name string) (models.APIToken, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return models.APIToken{}, "", &ValidationError{Field: "name", Message: "name is required"}
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return models.APIToken{}, "", fmt.Errorf("failed to generate API token: %s", err)
+	}
+	token := apiTokenPrefix + base64.RawURLEncoding.EncodeToString(raw)
+
+	dbToken, err := s.Repo.CreateAPIToken(ctx, database.CreateAPITokenParams{
+		ID:        uuid.New().String(),
+		Name:      name,
+		TokenHash: hashToken(token),
+		UserID:    userID.String(),
+	})
+	if err != nil {
+		return models.APIToken{}, "", fmt.Errorf("failed to create API token: %s", err)
+	}
+
+	return toAPITokenModel(dbToken), token, nil
+}
+
+func (s *APITokenService) List(ctx context.Context, userID uuid.UUID) ([]models.APIToken, error) {
+	dbTokens, err := s.Repo.GetAPITokensByUser(ctx, userID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := make([]models.APIToken, 0, len(dbTokens))
+	for _, dbToken := range dbTokens {
+		tokens = append(tokens, toAPITokenModel(dbToken))
+	}
+	return tokens, nil
+}
+
+func (s *APITokenService) Delete(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	return s.Repo.DeleteAPIToken(ctx, database.DeleteAPITokenParams{
+		ID:     id.String(),
+		UserID: userID.String(),
+	})
+}
+
+// GetUser returns the owner of a bearer token and records that it was used.
+func (s *APITokenService) GetUser(ctx context.Context, token string) (models.User, error) {
+	tokenHash := hashToken(token)
+
+	dbUser, err := s.Repo.GetAPITokenUser(ctx, tokenHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.User{}, ErrInvalidAPIToken
+		}
+		return models.User{}, fmt.Errorf("failed to get API token: %s", err)
+	}
+
+	if err := s.Repo.TouchAPIToken(ctx, database.TouchAPITokenParams{
+		LastUsedAt: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		TokenHash:  tokenHash,
+	}); err != nil {
+		return models.User{}, fmt.Errorf("failed to update API token: %s", err)
+	}
+
+	return toUserModel(dbUser), nil
+}
+
+func toAPITokenModel(dbToken database.ApiToken) models.APIToken {
+	token := models.APIToken{
+		ID:        uuid.MustParse(dbToken.ID),
+		Name:      dbToken.Name,
+		CreatedAt: dbToken.CreatedAt,
+	}
+	if dbToken.LastUsedAt.Valid {
+		token.LastUsedAt = &dbToken.LastUsedAt.Time
+	}
+	return token
+}
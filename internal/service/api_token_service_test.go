@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestAPITokenService(t *testing.T) {
+	queries := setupTestDB(t)
+	userService := &UserService{Repo: queries}
+	tokenService := &APITokenService{Repo: queries}
+	ctx := context.Background()
+
+	alice, err := userService.Register(ctx, "alice", "correct horse")
+	if err != nil {
+		t.Fatalf("Failed to register user: %v", err)
+	}
+	bob, err := userService.Register(ctx, "bob", "battery staple")
+	if err != nil {
+		t.Fatalf("Failed to register user: %v", err)
+	}
+
+	var validationErr *ValidationError
+	if _, _, err := tokenService.Create(ctx, alice.ID, " "); !errors.As(err, &validationErr) {
+		t.Errorf("Expected a ValidationError for an empty name, got %v", err)
+	}
+
+	token, secret, err := tokenService.Create(ctx, alice.ID, "laptop")
+	if err != nil {
+		t.Fatalf("Failed to create token: %v", err)
+	}
+	if !strings.HasPrefix(secret, apiTokenPrefix) {
+		t.Errorf("Expected token to start with %q, got %q", apiTokenPrefix, secret)
+	}
+	if token.LastUsedAt != nil {
+		t.Errorf("Expected new token to be unused, got %v", token.LastUsedAt)
+	}
+
+	dbTokens, err := queries.GetAPITokensByUser(ctx, alice.ID.String())
+	if err != nil {
+		t.Fatalf("Failed to get tokens: %v", err)
+	}
+	if len(dbTokens) != 1 || dbTokens[0].TokenHash == secret {
+		t.Fatalf("Expected one token stored hashed, got %+v", dbTokens)
+	}
+
+	user, err := tokenService.GetUser(ctx, secret)
+	if err != nil {
+		t.Fatalf("Failed to authenticate token: %v", err)
+	}
+	if user.ID != alice.ID {
+		t.Errorf("Expected user %s, got %s", alice.ID, user.ID)
+	}
+
+	tokens, err := tokenService.List(ctx, alice.ID)
+	if err != nil {
+		t.Fatalf("Failed to list tokens: %v", err)
+	}
+	if len(tokens) != 1 || tokens[0].LastUsedAt == nil {
+		t.Errorf("Expected token to record its last use, got %+v", tokens)
+	}
+
+	if _, err := tokenService.GetUser(ctx, secret+"x"); !errors.Is(err, ErrInvalidAPIToken) {
+		t.Errorf("Expected ErrInvalidAPIToken for unknown token, got %v", err)
+	}
+
+	// Another user cannot revoke the token
+	if err := tokenService.Delete(ctx, bob.ID, token.ID); err != nil {
+		t.Fatalf("Failed to delete token: %v", err)
+	}
+	if _, err := tokenService.GetUser(ctx, secret); err != nil {
+		t.Errorf("Expected token to survive another user's delete, got %v", err)
+	}
+
+	if err := tokenService.Delete(ctx, alice.ID, token.ID); err != nil {
+		t.Fatalf("Failed to delete token: %v", err)
+	}
+	if _, err := tokenService.GetUser(ctx, secret); !errors.Is(err, ErrInvalidAPIToken) {
+		t.Errorf("Expected ErrInvalidAPIToken after revoking, got %v", err)
+	}
+}
@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/nrbernard/gator/internal/database"
+	"github.com/nrbernard/gator/internal/models"
+	"golang.org/x/net/http/httpguts"
+)
+
+var (
+	ErrNoCredentialsKey = errors.New("no key is configured for feed credentials")
+	ErrPrivateFeed      = errors.New("this feed is fetched with another subscriber's credentials and can't be shared")
+)
+
+// sharedFeedReason explains why credentials can't be added to a feed that
+// other users follow: they would read what it fetches.
+const sharedFeedReason = "other subscribers share this feed, so it can't have credentials of its own"
+
+// CredentialsError is returned for credentials that can't be sent, such as
+// both a password and a token or a header the fetcher sets itself.
+type CredentialsError struct {
+	Reason string
+}
+
+func (e *CredentialsError) Error() string {
+	return fmt.Sprintf("invalid credentials: %s", e.Reason)
+}
+
+// reservedHeaders are set by the fetcher itself and can't be replaced by a
+// feed's extra headers.
+var reservedHeaders = []string{
+	"Accept-Encoding",
+	"Connection",
+	"Content-Length",
+	"Host",
+	"If-Modified-Since",
+	"If-None-Match",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+	"User-Agent",
+}
+
+// UpdateCredentials stores new credentials for a feed the user follows.
+// Secrets left blank keep their stored value, so forms can leave them out
+// instead of showing them again: a username without a password keeps the
+// stored password, no username keeps the stored token, and a blank cookie or
+// nil headers keep the stored ones.
+func (s *FeedService) UpdateCredentials(ctx context.Context, userID uuid.UUID, id uuid.UUID, creds models.FeedCredentials) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	feed, err := s.followedFeed(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if creds.IsZero() && len(feed.Credentials) == 0 {
+		return nil
+	}
+	shared, err := s.followedByOthers(ctx, userID, feed.ID)
+	if err != nil {
+		return err
+	}
+	if shared {
+		return &CredentialsError{Reason: sharedFeedReason}
+	}
+	stored, err := s.openCredentials(feed.ID, feed.Credentials)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case creds.Token != "" || creds.Password != "":
+		// New secrets replace the stored ones
+	case creds.Username != "":
+		creds.Password = stored.Password
+	default:
+		creds.Token = stored.Token
+	}
+	if creds.Cookie == "" {
+		creds.Cookie = stored.Cookie
+	}
+	if creds.Headers == nil {
+		creds.Headers = stored.Headers
+	}
+
+	return s.setCredentials(ctx, feed.ID, creds)
+}
+
+// ClearCredentials removes the credentials of a feed the user follows, so
+// it is fetched like a public feed again.
+func (s *FeedService) ClearCredentials(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	feed, err := s.followedFeed(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if err := s.Repo.SetFeedCredentials(ctx, database.SetFeedCredentialsParams{
+		Credentials: nil,
+		ID:          feed.ID,
+	}); err != nil {
+		return fmt.Errorf("failed to clear feed credentials: %s", err)
+	}
+	return nil
+}
+
+// setCredentials encrypts and stores a feed's credentials. Callers must
+// hold writeMu.
+func (s *FeedService) setCredentials(ctx context.Context, feedID string, creds models.FeedCredentials) error {
+	sealed, err := s.sealCredentials(feedID, creds)
+	if err != nil {
+		return err
+	}
+	if err := s.Repo.SetFeedCredentials(ctx, database.SetFeedCredentialsParams{
+		Credentials: sealed,
+		ID:          feedID,
+	}); err != nil {
+		return fmt.Errorf("failed to update feed credentials: %s", err)
+	}
+	return nil
+}
+
+// followedByOthers reports whether anyone besides the user follows the feed.
+func (s *FeedService) followedByOthers(ctx context.Context, userID uuid.UUID, feedID string) (bool, error) {
+	count, err := s.Repo.CountOtherFeedFollowers(ctx, database.CountOtherFeedFollowersParams{
+		FeedID: feedID,
+		UserID: userID.String(),
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to count feed followers: %s", err)
+	}
+	return count > 0, nil
+}
+
+// validateCredentials checks that the credentials can be sent as headers.
+func validateCredentials(creds models.FeedCredentials) error {
+	if creds.Token != "" && (creds.Username != "" || creds.Password != "") {
+		return &CredentialsError{Reason: "use either a username and password or a token, not both"}
+	}
+	if strings.Contains(creds.Username, ":") {
+		return &CredentialsError{Reason: "the username can't contain a colon"}
+	}
+	for name, value := range map[string]string{"username": creds.Username, "password": creds.Password, "token": creds.Token, "cookie": creds.Cookie} {
+		if !httpguts.ValidHeaderFieldValue(value) {
+			return &CredentialsError{Reason: fmt.Sprintf("the %s contains characters that can't be sent in a header", name)}
+		}
+	}
+	for name, value := range creds.Headers {
+		if !httpguts.ValidHeaderFieldName(name) {
+			return &CredentialsError{Reason: fmt.Sprintf("%q isn't a valid header name", name)}
+		}
+		if slices.Contains(reservedHeaders, http.CanonicalHeaderKey(name)) {
+			return &CredentialsError{Reason: fmt.Sprintf("the %s header can't be changed", http.CanonicalHeaderKey(name))}
+		}
+		if !httpguts.ValidHeaderFieldValue(value) {
+			return &CredentialsError{Reason: fmt.Sprintf("the %s header has an invalid value", name)}
+		}
+	}
+	return nil
+}
+
+// credentialHeader turns credentials into the headers to fetch a feed with.
+// The dedicated fields win over extra headers of the same name.
+func credentialHeader(creds models.FeedCredentials) http.Header {
+	if creds.IsZero() {
+		return nil
+	}
+
+	header := http.Header{}
+	for name, value := range creds.Headers {
+		header.Set(name, value)
+	}
+	switch {
+	case creds.Token != "":
+		header.Set("Authorization", "Bearer "+creds.Token)
+	case creds.Username != "" || creds.Password != "":
+		auth := base64.StdEncoding.EncodeToString([]byte(creds.Username + ":" + creds.Password))
+		header.Set("Authorization", "Basic "+auth)
+	}
+	if creds.Cookie != "" {
+		header.Set("Cookie", creds.Cookie)
+	}
+	return header
+}
+
+// feedHeader returns the extra headers to fetch feed with, or nil when it
+// has no credentials.
+func (s *FeedService) feedHeader(feed database.Feed) (http.Header, error) {
+	creds, err := s.openCredentials(feed.ID, feed.Credentials)
+	if err != nil {
+		return nil, err
+	}
+	return credentialHeader(creds), nil
+}
+
+// toFeedAuth describes the credentials without their secrets, or returns
+// nil when there are none.
+func toFeedAuth(creds models.FeedCredentials) *models.FeedAuth {
+	if creds.IsZero() {
+		return nil
+	}
+
+	auth := &models.FeedAuth{
+		Username:    creds.Username,
+		HasPassword: creds.Password != "",
+		HasToken:    creds.Token != "",
+		HasCookie:   creds.Cookie != "",
+	}
+	for name := range creds.Headers {
+		auth.HeaderNames = append(auth.HeaderNames, name)
+	}
+	slices.Sort(auth.HeaderNames)
+	return auth
+}
+
+// sealCredentials encrypts the credentials for the feed with the server's
+// key. The feed's id is authenticated along with them, so they can't be
+// copied onto another feed. Empty credentials are stored as nil.
+func (s *FeedService) sealCredentials(feedID string, creds models.FeedCredentials) ([]byte, error) {
+	if creds.IsZero() {
+		return nil, nil
+	}
+	if err := validateCredentials(creds); err != nil {
+		return nil, err
+	}
+
+	aead, err := s.credentialsAEAD()
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := json.Marshal(creds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode feed credentials: %s", err)
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %s", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, []byte(feedID)), nil
+}
+
+// openCredentials decrypts credentials stored by sealCredentials. Missing
+// credentials are returned empty.
+func (s *FeedService) openCredentials(feedID string, sealed []byte) (models.FeedCredentials, error) {
+	var creds models.FeedCredentials
+	if len(sealed) == 0 {
+		return creds, nil
+	}
+
+	aead, err := s.credentialsAEAD()
+	if err != nil {
+		return creds, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return creds, errors.New("failed to decrypt feed credentials: too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(feedID))
+	if err != nil {
+		return creds, fmt.Errorf("failed to decrypt feed credentials: %s", err)
+	}
+	if err := json.Unmarshal(plaintext, &creds); err != nil {
+		return creds, fmt.Errorf("failed to decode feed credentials: %s", err)
+	}
+	return creds, nil
+}
+
+func (s *FeedService) credentialsAEAD() (cipher.AEAD, error) {
+	if len(s.CredentialsKey) == 0 {
+		return nil, ErrNoCredentialsKey
+	}
+	block, err := aes.NewCipher(s.CredentialsKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid feed credentials key: %s", err)
+	}
+	return cipher.NewGCM(block)
+}
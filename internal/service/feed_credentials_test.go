@@ -0,0 +1,226 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nrbernard/gator/internal/database"
+	"github.com/nrbernard/gator/internal/models"
+)
+
+var testCredentialsKey = bytes.Repeat([]byte{7}, 32)
+
+func TestFeedService_Credentials(t *testing.T) {
+	queries := setupTestDB(t)
+	ctx := context.Background()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != "reader" || password != "s3cret" || r.Header.Get("X-Tenant") != "acme" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/rss+xml")
+		fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"><channel><title>Private</title></channel></rss>`)
+	}))
+	defer server.Close()
+
+	userID := uuid.New()
+	if _, err := queries.CreateUser(ctx, database.CreateUserParams{ID: userID.String(), Name: "Test User"}); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	creds := models.FeedCredentials{
+		Username: "reader",
+		Password: "s3cret",
+		Headers:  map[string]string{"X-Tenant": "acme"},
+	}
+
+	// Credentials can't be stored without a key
+	noKey := &FeedService{Repo: queries}
+	if _, err := noKey.CreateFeed(ctx, CreateFeedParams{Url: server.URL, UserID: userID, Credentials: creds}); !errors.Is(err, ErrNoCredentialsKey) {
+		t.Fatalf("Expected ErrNoCredentialsKey, got %v", err)
+	}
+
+	feedService := &FeedService{Repo: queries, CredentialsKey: testCredentialsKey}
+	created, err := feedService.CreateFeed(ctx, CreateFeedParams{Url: server.URL, UserID: userID, Credentials: creds})
+	if err != nil {
+		t.Fatalf("CreateFeed failed: %v", err)
+	}
+	if created.Name != "Private" {
+		t.Errorf("Expected the private feed, got %+v", created)
+	}
+
+	dbFeed, err := queries.GetFeedByID(ctx, created.ID.String())
+	if err != nil {
+		t.Fatalf("Failed to get feed: %v", err)
+	}
+	if len(dbFeed.Credentials) == 0 || bytes.Contains(dbFeed.Credentials, []byte("s3cret")) {
+		t.Errorf("Expected the credentials to be stored encrypted, got %q", dbFeed.Credentials)
+	}
+
+	// Refreshing sends the stored credentials
+	if _, err := feedService.scrapeFeed(ctx, dbFeed); err != nil {
+		t.Errorf("Expected the refresh to authenticate, got %v", err)
+	}
+
+	// Settings describe the credentials without revealing them
+	feed, err := feedService.GetFeed(ctx, userID, created.ID)
+	if err != nil {
+		t.Fatalf("Failed to get feed: %v", err)
+	}
+	if feed.Auth == nil || feed.Auth.Username != "reader" || !feed.Auth.HasPassword || feed.Auth.HasToken ||
+		len(feed.Auth.HeaderNames) != 1 || feed.Auth.HeaderNames[0] != "X-Tenant" {
+		t.Errorf("Unexpected credentials summary %+v", feed.Auth)
+	}
+
+	// Blank secrets keep the stored ones
+	if err := feedService.UpdateCredentials(ctx, userID, created.ID, models.FeedCredentials{Username: "reader"}); err != nil {
+		t.Fatalf("UpdateCredentials failed: %v", err)
+	}
+	dbFeed, _ = queries.GetFeedByID(ctx, created.ID.String())
+	if _, err := feedService.scrapeFeed(ctx, dbFeed); err != nil {
+		t.Errorf("Expected the stored password and headers to be kept, got %v", err)
+	}
+
+	// Credentials are bound to their feed
+	if _, err := feedService.openCredentials(uuid.New().String(), dbFeed.Credentials); err == nil {
+		t.Errorf("Expected credentials copied onto another feed not to decrypt")
+	}
+
+	if err := feedService.ClearCredentials(ctx, userID, created.ID); err != nil {
+		t.Fatalf("ClearCredentials failed: %v", err)
+	}
+	dbFeed, _ = queries.GetFeedByID(ctx, created.ID.String())
+	if dbFeed.Credentials != nil {
+		t.Errorf("Expected the credentials to be removed")
+	}
+	if _, err := feedService.scrapeFeed(ctx, dbFeed); err == nil {
+		t.Errorf("Expected the refresh to fail without credentials")
+	}
+}
+
+func TestFeedService_CredentialedFeedsArePrivate(t *testing.T) {
+	queries := setupTestDB(t)
+	ctx := context.Background()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/private.xml" {
+			if username, password, ok := r.BasicAuth(); !ok || username != "alice" || password != "s3cret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+		}
+		w.Header().Set("Content-Type", "application/rss+xml")
+		fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"><channel><title>%s</title>
+<item><title>Members only</title><link>https://example.com%s/1</link></item>
+</channel></rss>`, r.URL.Path, r.URL.Path)
+	}))
+	defer server.Close()
+
+	alice, bob := uuid.New(), uuid.New()
+	for _, user := range []struct {
+		id   uuid.UUID
+		name string
+	}{{alice, "alice"}, {bob, "bob"}} {
+		if _, err := queries.CreateUser(ctx, database.CreateUserParams{ID: user.id.String(), Name: user.name}); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+
+	feedService := &FeedService{Repo: queries, CredentialsKey: testCredentialsKey}
+	postService := &PostService{Repo: queries}
+	privateURL := server.URL + "/private.xml"
+	aliceCreds := models.FeedCredentials{Username: "alice", Password: "s3cret"}
+
+	private, err := feedService.CreateFeed(ctx, CreateFeedParams{Url: privateURL, UserID: alice, Credentials: aliceCreds})
+	if err != nil {
+		t.Fatalf("CreateFeed failed: %v", err)
+	}
+	dbFeed, _ := queries.GetFeedByID(ctx, private.ID.String())
+	if _, err := feedService.scrapeFeed(ctx, dbFeed); err != nil {
+		t.Fatalf("Failed to refresh feed: %v", err)
+	}
+	if posts, _ := postService.SearchPosts(ctx, alice, SearchOptions{}); len(posts) != 1 {
+		t.Fatalf("Expected alice to see the private post, got %d", len(posts))
+	}
+
+	// Bob can't follow it, with or without credentials of their own
+	for _, creds := range []models.FeedCredentials{{}, {Username: "bob", Password: "hunter2"}} {
+		if _, err := feedService.CreateFeed(ctx, CreateFeedParams{Url: privateURL, UserID: bob, Credentials: creds}); !errors.Is(err, ErrPrivateFeed) {
+			t.Errorf("Expected ErrPrivateFeed, got %v", err)
+		}
+	}
+	if posts, _ := postService.SearchPosts(ctx, bob, SearchOptions{}); len(posts) != 0 {
+		t.Errorf("Expected bob not to see posts fetched with alice's credentials, got %d", len(posts))
+	}
+
+	// Nor read or change its credentials
+	if _, err := feedService.GetFeed(ctx, bob, private.ID); !errors.Is(err, ErrNotFollowing) {
+		t.Errorf("Expected ErrNotFollowing, got %v", err)
+	}
+	if err := feedService.UpdateCredentials(ctx, bob, private.ID, models.FeedCredentials{Token: "t"}); !errors.Is(err, ErrNotFollowing) {
+		t.Errorf("Expected ErrNotFollowing, got %v", err)
+	}
+	if err := feedService.ClearCredentials(ctx, bob, private.ID); !errors.Is(err, ErrNotFollowing) {
+		t.Errorf("Expected ErrNotFollowing, got %v", err)
+	}
+	if dbFeed, _ := queries.GetFeedByID(ctx, private.ID.String()); len(dbFeed.Credentials) == 0 {
+		t.Errorf("Expected alice's credentials to be kept")
+	}
+
+	// Credentials can't be added to a feed that others follow
+	publicURL := server.URL + "/public.xml"
+	for _, userID := range []uuid.UUID{alice, bob} {
+		if _, err := feedService.CreateFeed(ctx, CreateFeedParams{Url: publicURL, UserID: userID}); err != nil {
+			t.Fatalf("CreateFeed failed: %v", err)
+		}
+	}
+	public, _ := queries.GetFeedByUrl(ctx, publicURL)
+	var credsErr *CredentialsError
+	if err := feedService.UpdateCredentials(ctx, alice, uuid.MustParse(public.ID), aliceCreds); !errors.As(err, &credsErr) {
+		t.Errorf("Expected a CredentialsError, got %v", err)
+	}
+	if _, err := feedService.CreateFeed(ctx, CreateFeedParams{Url: publicURL, UserID: alice, Credentials: aliceCreds}); !errors.As(err, &credsErr) {
+		t.Errorf("Expected a CredentialsError, got %v", err)
+	}
+	if public, _ := queries.GetFeedByUrl(ctx, publicURL); public.Credentials != nil {
+		t.Errorf("Expected the shared feed to stay without credentials")
+	}
+}
+
+func TestValidateCredentials(t *testing.T) {
+	tests := []struct {
+		name  string
+		creds models.FeedCredentials
+		valid bool
+	}{
+		{name: "basic auth", creds: models.FeedCredentials{Username: "a", Password: "b"}, valid: true},
+		{name: "token and cookie", creds: models.FeedCredentials{Token: "t", Cookie: "session=1"}, valid: true},
+		{name: "password and token", creds: models.FeedCredentials{Password: "b", Token: "t"}},
+		{name: "colon in username", creds: models.FeedCredentials{Username: "a:b"}},
+		{name: "newline in token", creds: models.FeedCredentials{Token: "t\r\nX-Evil: 1"}},
+		{name: "reserved header", creds: models.FeedCredentials{Headers: map[string]string{"Host": "example.com"}}},
+		{name: "invalid header name", creds: models.FeedCredentials{Headers: map[string]string{"Bad Name": "1"}}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateCredentials(tc.creds)
+			var credsErr *CredentialsError
+			if tc.valid && err != nil {
+				t.Errorf("Expected valid credentials, got %v", err)
+			}
+			if !tc.valid && !errors.As(err, &credsErr) {
+				t.Errorf("Expected a CredentialsError, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nrbernard/gator/internal/feedparser"
+	"github.com/nrbernard/gator/internal/models"
+)
+
+const (
+	// previewItemCount is how many of a feed's latest items a preview shows.
+	previewItemCount = 5
+	// previewTTL is how long a previewed feed is kept for subscribing to it
+	// without fetching it again.
+	previewTTL = 10 * time.Minute
+	// maxCachedPreviews bounds how many previewed feeds are kept in memory.
+	maxCachedPreviews = 100
+)
+
+// PreviewFeed fetches the feed at feedURL with the given credentials
+// without subscribing to it, discovering it first when the URL is a web page
+// as CreateFeed does. The fetched feed is kept for a few minutes so that
+// subscribing to it right after the preview doesn't fetch it again.
+func (s *FeedService) PreviewFeed(ctx context.Context, feedURL string, creds models.FeedCredentials) (models.FeedPreview, error) {
+	if err := validateCredentials(creds); err != nil {
+		return models.FeedPreview{}, err
+	}
+
+	feed, err := s.fetchFeed(ctx, feedURL, creds)
+	if errors.Is(err, feedparser.ErrNotFeed) {
+		feedURL, err = s.discoverFeed(ctx, feedURL)
+		if err != nil {
+			return models.FeedPreview{}, err
+		}
+		feed, err = s.fetchFeed(ctx, feedURL, creds)
+	}
+	if err != nil {
+		return models.FeedPreview{}, fmt.Errorf("failed to fetch feed: %s", err)
+	}
+
+	s.previews.put(previewKey(feedURL, creds), feed, time.Now())
+	return toFeedPreview(feedURL, feed), nil
+}
+
+// fetchNewFeed fetches a feed that is about to be added, reusing the one a
+// preview fetched moments ago if there is one.
+func (s *FeedService) fetchNewFeed(ctx context.Context, feedURL string, creds models.FeedCredentials) (feedparser.Feed, error) {
+	if feed, ok := s.previews.take(previewKey(feedURL, creds), time.Now()); ok {
+		return feed, nil
+	}
+	return s.fetchFeed(ctx, feedURL, creds)
+}
+
+// fetchFeed fetches a feed that hasn't been added with the credentials
+// given for it.
+func (s *FeedService) fetchFeed(ctx context.Context, feedURL string, creds models.FeedCredentials) (feedparser.Feed, error) {
+	result, err := s.fetcher().FetchFeedWithHeader(ctx, feedURL, credentialHeader(creds), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return result.Feed, nil
+}
+
+func toFeedPreview(feedURL string, feed feedparser.Feed) models.FeedPreview {
+	items := feed.GetItems()
+	preview := models.FeedPreview{
+		Url:         feedURL,
+		Name:        feed.GetTitle(),
+		Description: feed.GetDescription(),
+		Link:        feed.GetLink(),
+		ItemCount:   len(items),
+	}
+
+	var dates []time.Time
+	for _, item := range items {
+		if date := item.GetDate(); !date.IsZero() {
+			dates = append(dates, date)
+		}
+	}
+	if gap, ok := medianPostGap(dates); ok {
+		preview.PostInterval = gap
+	}
+
+	// Newest first, with undated items after the dated ones in feed order
+	latest := slices.Clone(items)
+	slices.SortStableFunc(latest, func(a, b feedparser.Item) int {
+		aDate, bDate := a.GetDate(), b.GetDate()
+		if aDate.IsZero() || bDate.IsZero() {
+			return boolCompare(aDate.IsZero(), bDate.IsZero())
+		}
+		return bDate.Compare(aDate)
+	})
+	for _, item := range latest[:min(len(latest), previewItemCount)] {
+		previewItem := models.FeedPreviewItem{
+			Title: strings.TrimSpace(item.GetTitle()),
+			Url:   item.GetLink(),
+		}
+		if previewItem.Title == "" {
+			previewItem.Title = previewItem.Url
+		}
+		if date := item.GetDate(); !date.IsZero() {
+			previewItem.PublishedAt = &date
+		}
+		preview.Items = append(preview.Items, previewItem)
+	}
+
+	return preview
+}
+
+// boolCompare orders false before true.
+func boolCompare(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	default:
+		return -1
+	}
+}
+
+// previewKey is what a preview is cached under. A feed previewed with
+// credentials is only reused with the same credentials, so no one else gets
+// it by subscribing to its URL.
+func previewKey(feedURL string, creds models.FeedCredentials) string {
+	if creds.IsZero() {
+		return feedURL
+	}
+	encoded, _ := json.Marshal(creds)
+	sum := sha256.Sum256(encoded)
+	return feedURL + " " + hex.EncodeToString(sum[:])
+}
+
+// previewCache holds recently previewed feeds by previewKey. The zero value
+// is ready to use.
+type previewCache struct {
+	mu    sync.Mutex
+	feeds map[string]cachedPreview
+}
+
+type cachedPreview struct {
+	feed      feedparser.Feed
+	expiresAt time.Time
+}
+
+func (c *previewCache) put(key string, feed feedparser.Feed, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.feeds == nil {
+		c.feeds = make(map[string]cachedPreview)
+	}
+	for cachedKey, cached := range c.feeds {
+		if !now.Before(cached.expiresAt) {
+			delete(c.feeds, cachedKey)
+		}
+	}
+	// Make room by forgetting an arbitrary preview, which at worst means
+	// fetching that feed again
+	for cachedKey := range c.feeds {
+		if len(c.feeds) < maxCachedPreviews {
+			break
+		}
+		delete(c.feeds, cachedKey)
+	}
+
+	c.feeds[key] = cachedPreview{feed: feed, expiresAt: now.Add(previewTTL)}
+}
+
+// take returns the feed previewed under key unless it has expired, and
+// forgets it so that it is used at most once.
+func (c *previewCache) take(key string, now time.Time) (feedparser.Feed, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached, ok := c.feeds[key]
+	if !ok {
+		return nil, false
+	}
+	delete(c.feeds, key)
+	if !now.Before(cached.expiresAt) {
+		return nil, false
+	}
+	return cached.feed, true
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nrbernard/gator/internal/database"
+	"github.com/nrbernard/gator/internal/models"
+)
+
+func TestFeedService_PreviewFeed(t *testing.T) {
+	queries := setupTestDB(t)
+	ctx := context.Background()
+
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, `<html><head><link rel="alternate" type="application/rss+xml" href="/feed.xml"></head></html>`)
+			return
+		}
+
+		fetches.Add(1)
+		w.Header().Set("Content-Type", "application/rss+xml")
+		fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0">
+  <channel>
+    <title>Daily Notes</title>
+    <link>https://example.com/</link>
+    <description>One note a day</description>
+    <item><title>Undated</title><link>https://example.com/undated</link></item>`)
+		start := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+		for day := range 7 {
+			fmt.Fprintf(w, `
+    <item><title>Note %d</title><link>https://example.com/%d</link><pubDate>%s</pubDate></item>`,
+				day+1, day+1, start.AddDate(0, 0, day).Format(time.RFC1123Z))
+		}
+		fmt.Fprint(w, `
+  </channel>
+</rss>`)
+	}))
+	defer server.Close()
+
+	userID := uuid.New()
+	if _, err := queries.CreateUser(ctx, database.CreateUserParams{ID: userID.String(), Name: "Test User"}); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	feedService := &FeedService{Repo: queries}
+
+	// The feed of a web page is previewed
+	preview, err := feedService.PreviewFeed(ctx, server.URL+"/", models.FeedCredentials{})
+	if err != nil {
+		t.Fatalf("PreviewFeed failed: %v", err)
+	}
+	if preview.Url != server.URL+"/feed.xml" || preview.Name != "Daily Notes" || preview.Description != "One note a day" {
+		t.Errorf("Unexpected preview %+v", preview)
+	}
+	if preview.ItemCount != 8 {
+		t.Errorf("Expected 8 items, got %d", preview.ItemCount)
+	}
+	if preview.PostInterval != 24*time.Hour || preview.Frequency() != "about one post a day" {
+		t.Errorf("Expected a post a day, got %s (%q)", preview.PostInterval, preview.Frequency())
+	}
+	if len(preview.Items) != previewItemCount {
+		t.Fatalf("Expected %d items, got %d", previewItemCount, len(preview.Items))
+	}
+	if preview.Items[0].Title != "Note 7" || preview.Items[4].Title != "Note 3" {
+		t.Errorf("Expected the newest items first, got %+v", preview.Items)
+	}
+
+	feeds, err := feedService.ListFeeds(ctx, userID)
+	if err != nil {
+		t.Fatalf("Failed to list feeds: %v", err)
+	}
+	if len(feeds) != 0 {
+		t.Errorf("Expected previewing not to subscribe, got %d feeds", len(feeds))
+	}
+
+	// Subscribing right after reuses the fetched feed, but only once
+	feed, err := feedService.CreateFeed(ctx, CreateFeedParams{Url: preview.Url, UserID: userID})
+	if err != nil {
+		t.Fatalf("CreateFeed failed: %v", err)
+	}
+	if feed.Name != "Daily Notes" {
+		t.Errorf("Expected the previewed feed, got %+v", feed)
+	}
+	if got := fetches.Load(); got != 1 {
+		t.Errorf("Expected the feed to be fetched once, got %d", got)
+	}
+	if _, ok := feedService.previews.take(preview.Url, time.Now()); ok {
+		t.Errorf("Expected the preview to be used up")
+	}
+}
+
+func TestPreviewCache_Expiry(t *testing.T) {
+	var cache previewCache
+	now := time.Now()
+
+	cache.put("https://example.com/a.xml", nil, now)
+	if _, ok := cache.take("https://example.com/a.xml", now.Add(previewTTL)); ok {
+		t.Errorf("Expected an expired preview to be ignored")
+	}
+
+	cache.put("https://example.com/b.xml", nil, now)
+	if _, ok := cache.take("https://example.com/b.xml", now.Add(previewTTL-time.Second)); !ok {
+		t.Errorf("Expected a recent preview to be reused")
+	}
+}
+
+func TestFeedPreview_Frequency(t *testing.T) {
+	tests := []struct {
+		interval time.Duration
+		expected string
+	}{
+		{0, ""},
+		{6 * time.Hour, "about 4 posts a day"},
+		{20 * time.Hour, "about one post a day"},
+		{48 * time.Hour, "about 4 posts a week"},
+		{7 * 24 * time.Hour, "about one post a week"},
+		{10 * 24 * time.Hour, "about 3 posts a month"},
+		{60 * 24 * time.Hour, "less than one post a month"},
+	}
+
+	for _, tc := range tests {
+		preview := models.FeedPreview{PostInterval: tc.interval}
+		if got := preview.Frequency(); got != tc.expected {
+			t.Errorf("Frequency for %s: expected %q, got %q", tc.interval, tc.expected, got)
+		}
+	}
+}
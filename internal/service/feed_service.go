@@ -3,8 +3,13 @@ package service
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
+	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,165 +18,877 @@ import (
 	"github.com/nrbernard/gator/internal/models"
 )
 
+const (
+	defaultMaxConcurrency = 8
+	defaultMaxPerHost     = 2
+	defaultFetchTimeout   = 30 * time.Second
+
+	minBackoff    = 5 * time.Minute
+	maxBackoff    = 24 * time.Hour
+	maxRetryAfter = 7 * 24 * time.Hour
+
+	// redirectStableFetches is how many fetches in a row must be permanently
+	// redirected to the same URL before the feed is moved there.
+	redirectStableFetches = 3
+	defaultDeadAfter      = 30 * 24 * time.Hour
+)
+
+var (
+	ErrNotFollowing = errors.New("not following this feed")
+	ErrNoFeedFound  = errors.New("no feed found at this address")
+)
+
+// FeedChoiceError is returned when a web page offers several feeds and the
+// user has to pick the one to subscribe to.
+type FeedChoiceError struct {
+	Candidates []feedparser.FeedLink
+}
+
+func (e *FeedChoiceError) Error() string {
+	urls := make([]string, len(e.Candidates))
+	for i, candidate := range e.Candidates {
+		urls[i] = candidate.URL
+	}
+	return fmt.Sprintf("found several feeds, choose one of: %s", strings.Join(urls, ", "))
+}
+
+// FetchError is returned when the feed being added, or the page it was to
+// be discovered from, can't be fetched or isn't a feed.
+type FetchError struct {
+	Op  string
+	Err error
+}
+
+func (e *FetchError) Error() string {
+	return fmt.Sprintf("failed to %s: %s", e.Op, e.Err)
+}
+
+func (e *FetchError) Unwrap() error {
+	return e.Err
+}
+
 type FeedService struct {
 	Repo *database.Queries
+
+	// MaxConcurrency caps how many feeds are fetched at once.
+	MaxConcurrency int
+	// MaxPerHost caps how many feeds on the same host are fetched at once.
+	MaxPerHost int
+	// FetchTimeout bounds each individual feed fetch.
+	FetchTimeout time.Duration
+	// MinFetchInterval and MaxFetchInterval bound how often each feed is
+	// polled, whatever its publishing cadence or manual override.
+	MinFetchInterval time.Duration
+	MaxFetchInterval time.Duration
+	// Fetcher downloads feeds, defaulting to feedparser.DefaultFetcher.
+	Fetcher *feedparser.Fetcher
+	// DeadAfter is how long a feed may fail without a single successful
+	// fetch before it is marked dead.
+	DeadAfter time.Duration
+	// CredentialsKey is the AES-256 key feed credentials are encrypted
+	// with. Without it, feeds can't be given credentials.
+	CredentialsKey []byte
+	// DB is the database behind Repo, for writes that must happen together
+	// in a transaction.
+	DB *sql.DB
+
+	writeMu  sync.Mutex
+	previews previewCache
 }
 
 type CreateFeedParams struct {
 	Url    string
 	UserID uuid.UUID
+	// Folder optionally files the new subscription, e.g. from an OPML import.
+	Folder string
+	// Credentials are used to fetch a feed that isn't public and stored
+	// with it. They are ignored when the feed has already been added.
+	Credentials models.FeedCredentials
 }
 
+// ListFeeds returns the feeds the user follows.
 func (s *FeedService) ListFeeds(ctx context.Context, userID uuid.UUID) ([]models.Feed, error) {
-	dbFeeds, err := s.Repo.GetFeeds(ctx)
+	dbFeeds, err := s.Repo.GetFollowedFeedsForUser(ctx, userID.String())
 	if err != nil {
 		return nil, err
 	}
 
 	var feeds []models.Feed
 	for _, dbFeed := range dbFeeds {
-		feeds = append(feeds, models.Feed{
+		feed := models.Feed{
 			ID:          uuid.MustParse(dbFeed.ID),
 			Name:        dbFeed.Name,
 			Description: &dbFeed.Description.String,
 			Url:         dbFeed.Url,
-		})
+			Folder:      dbFeed.Folder.String,
+			LastError:   dbFeed.LastError.String,
+		}
+		if dbFeed.LastFetchedAt.Valid {
+			feed.LastFetchedAt = &dbFeed.LastFetchedAt.Time
+		}
+		if dbFeed.DeadAt.Valid {
+			feed.DeadAt = &dbFeed.DeadAt.Time
+		}
+		feeds = append(feeds, feed)
 	}
 	return feeds, nil
 }
 
+// CreateFeed subscribes the user to the feed at params.Url. A feed that
+// someone has already added is followed without fetching it again, and
+// following a feed twice is a no-op. When the URL is a web page, its feed
+// is discovered instead, or a *FeedChoiceError lists them if it has several.
 func (s *FeedService) CreateFeed(ctx context.Context, params CreateFeedParams) (models.Feed, error) {
+	return s.createFeed(ctx, params, true)
+}
+
+func (s *FeedService) createFeed(ctx context.Context, params CreateFeedParams, discover bool) (models.Feed, error) {
+	if !params.Credentials.IsZero() {
+		if err := validateCredentials(params.Credentials); err != nil {
+			return models.Feed{}, err
+		}
+		if _, err := s.credentialsAEAD(); err != nil {
+			return models.Feed{}, err
+		}
+	}
+
 	feedUrl := params.Url
-	_, err := s.Repo.GetFeedByUrl(ctx, feedUrl)
+	dbFeed, err := s.Repo.GetFeedByUrl(ctx, feedUrl)
 	if err == nil {
-		return models.Feed{}, fmt.Errorf("a feed with URL %s already exists", feedUrl)
+		s.writeMu.Lock()
+		defer s.writeMu.Unlock()
+
+		return s.followExisting(ctx, params, dbFeed)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return models.Feed{}, fmt.Errorf("failed to look up feed: %s", err)
 	}
 
-	feedData, err := feedparser.FetchFeed(context.Background(), feedUrl)
+	feedData, err := s.fetchNewFeed(ctx, feedUrl, params.Credentials)
+	if errors.Is(err, feedparser.ErrNotFeed) && discover {
+		return s.createDiscoveredFeed(ctx, params)
+	}
 	if err != nil {
-		return models.Feed{}, fmt.Errorf("failed to fetch feed: %s", err)
+		return models.Feed{}, &FetchError{Op: "fetch feed", Err: err}
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	// Someone else may have added the same URL while it was being fetched
+	dbFeed, err = s.Repo.GetFeedByUrl(ctx, feedUrl)
+	if err == nil {
+		return s.followExisting(ctx, params, dbFeed)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		dbFeed, err = s.insertFeed(ctx, params, feedUrl, feedData)
+	}
+	if err != nil {
+		return models.Feed{}, err
+	}
+
+	if _, err := s.follow(ctx, params.UserID, dbFeed.ID, params.Folder); err != nil {
+		return models.Feed{}, err
+	}
+
+	return toFeedModel(dbFeed), nil
+}
+
+// followExisting subscribes the user to a feed someone already added. A
+// feed with credentials is private to the one user following it, so it
+// can't be followed by others, and credentials can't be added to a feed
+// that others follow. A dead feed gets another try. Callers must hold
+// writeMu.
+func (s *FeedService) followExisting(ctx context.Context, params CreateFeedParams, dbFeed database.Feed) (models.Feed, error) {
+	if len(dbFeed.Credentials) > 0 || !params.Credentials.IsZero() {
+		shared, err := s.followedByOthers(ctx, params.UserID, dbFeed.ID)
+		if err != nil {
+			return models.Feed{}, err
+		}
+		if shared && len(dbFeed.Credentials) > 0 {
+			return models.Feed{}, ErrPrivateFeed
+		}
+		if shared {
+			return models.Feed{}, &CredentialsError{Reason: sharedFeedReason}
+		}
+	}
+	if !params.Credentials.IsZero() {
+		if err := s.setCredentials(ctx, dbFeed.ID, params.Credentials); err != nil {
+			return models.Feed{}, err
+		}
+	}
+
+	if _, err := s.follow(ctx, params.UserID, dbFeed.ID, params.Folder); err != nil {
+		return models.Feed{}, err
+	}
+	if dbFeed.DeadAt.Valid {
+		if err := s.Repo.ReviveFeed(ctx, dbFeed.ID); err != nil {
+			return models.Feed{}, fmt.Errorf("failed to revive feed: %s", err)
+		}
+		dbFeed.DeadAt = sql.NullTime{}
+		dbFeed.LastError = sql.NullString{}
+	}
+	return toFeedModel(dbFeed), nil
+}
+
+// insertFeed adds a newly fetched feed along with its credentials. Callers
+// must hold writeMu.
+func (s *FeedService) insertFeed(ctx context.Context, params CreateFeedParams, feedUrl string, feedData feedparser.Feed) (database.Feed, error) {
+	id := uuid.New().String()
+	sealed, err := s.sealCredentials(id, params.Credentials)
+	if err != nil {
+		return database.Feed{}, err
 	}
 
 	dbFeed, err := s.Repo.CreateFeed(ctx, database.CreateFeedParams{
-		ID:          uuid.New().String(),
+		ID:          id,
 		Name:        feedData.GetTitle(),
 		Description: sql.NullString{String: feedData.GetDescription(), Valid: true},
 		Url:         feedUrl,
-		UserID:      params.UserID.String(),
+		UserID:      sql.NullString{String: params.UserID.String(), Valid: true},
 	})
+	if err != nil || sealed == nil {
+		return dbFeed, err
+	}
+
+	if err := s.Repo.SetFeedCredentials(ctx, database.SetFeedCredentialsParams{
+		Credentials: sealed,
+		ID:          id,
+	}); err != nil {
+		return database.Feed{}, fmt.Errorf("failed to store feed credentials: %s", err)
+	}
+	dbFeed.Credentials = sealed
+	return dbFeed, nil
+}
+
+// createDiscoveredFeed subscribes the user to the feed of the web page at
+// params.Url. Discovered URLs aren't discovered from again, so pages
+// linking to each other can't loop.
+func (s *FeedService) createDiscoveredFeed(ctx context.Context, params CreateFeedParams) (models.Feed, error) {
+	feedURL, err := s.discoverFeed(ctx, params.Url)
 	if err != nil {
 		return models.Feed{}, err
 	}
+	params.Url = feedURL
+	return s.createFeed(ctx, params, false)
+}
+
+// discoverFeed returns the URL of the only feed of the web page at pageURL.
+// It returns ErrNoFeedFound when the page has none and a *FeedChoiceError
+// when it has several.
+func (s *FeedService) discoverFeed(ctx context.Context, pageURL string) (string, error) {
+	candidates, err := s.fetcher().DiscoverFeeds(ctx, pageURL)
+	if err != nil {
+		return "", &FetchError{Op: "discover feeds", Err: err}
+	}
+
+	switch len(candidates) {
+	case 0:
+		return "", ErrNoFeedFound
+	case 1:
+		return candidates[0].URL, nil
+	default:
+		return "", &FeedChoiceError{Candidates: candidates}
+	}
+}
+
+func (s *FeedService) fetcher() *feedparser.Fetcher {
+	if s.Fetcher != nil {
+		return s.Fetcher
+	}
+	return feedparser.DefaultFetcher
+}
 
-	if _, err := s.Repo.CreateFeedFollow(context.Background(), database.CreateFeedFollowParams{
+// follow subscribes the user to a feed and reports whether they were not
+// already following it. Callers must hold writeMu.
+func (s *FeedService) follow(ctx context.Context, userID uuid.UUID, feedID string, folder string) (bool, error) {
+	followed, err := s.Repo.FollowFeed(ctx, database.FollowFeedParams{
 		ID:     uuid.New().String(),
-		UserID: params.UserID.String(),
-		FeedID: dbFeed.ID,
-	}); err != nil {
-		return models.Feed{}, err
+		UserID: userID.String(),
+		FeedID: feedID,
+		Folder: sql.NullString{String: folder, Valid: folder != ""},
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to follow feed: %s", err)
 	}
+	return followed > 0, nil
+}
 
+func toFeedModel(dbFeed database.Feed) models.Feed {
 	feed := models.Feed{
 		ID:          uuid.MustParse(dbFeed.ID),
 		Name:        dbFeed.Name,
 		Description: &dbFeed.Description.String,
 		Url:         dbFeed.Url,
+		LastError:   dbFeed.LastError.String,
+	}
+	if dbFeed.DeadAt.Valid {
+		feed.DeadAt = &dbFeed.DeadAt.Time
+	}
+	return feed
+}
+
+// followedFeed returns the feed with the given id, or ErrNotFollowing when
+// the user doesn't follow it. Feeds are shared, so only their followers may
+// see or change their settings.
+func (s *FeedService) followedFeed(ctx context.Context, userID uuid.UUID, id uuid.UUID) (database.Feed, error) {
+	dbFeed, err := s.Repo.GetFollowedFeed(ctx, database.GetFollowedFeedParams{
+		ID:     id.String(),
+		UserID: userID.String(),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.Feed{}, ErrNotFollowing
+	}
+	if err != nil {
+		return database.Feed{}, fmt.Errorf("failed to get feed: %s", err)
+	}
+	return dbFeed, nil
+}
+
+// GetFeed returns a feed the user follows along with its settings.
+func (s *FeedService) GetFeed(ctx context.Context, userID uuid.UUID, id uuid.UUID) (models.Feed, error) {
+	dbFeed, err := s.followedFeed(ctx, userID, id)
+	if err != nil {
+		return models.Feed{}, err
+	}
+
+	follow, err := s.Repo.GetFeedFollow(ctx, database.GetFeedFollowParams{
+		UserID: userID.String(),
+		FeedID: dbFeed.ID,
+	})
+	if err != nil {
+		return models.Feed{}, fmt.Errorf("failed to get feed follow: %s", err)
+	}
+
+	feed := models.Feed{
+		ID:                 uuid.MustParse(dbFeed.ID),
+		Name:               dbFeed.Name,
+		Description:        &dbFeed.Description.String,
+		Url:                dbFeed.Url,
+		FetchInterval:      s.effectiveInterval(dbFeed),
+		MarkUnreadOnUpdate: follow.MarkUnreadOnUpdate,
+		LastError:          dbFeed.LastError.String,
+	}
+	if dbFeed.DeadAt.Valid {
+		feed.DeadAt = &dbFeed.DeadAt.Time
+	}
+	if creds, err := s.openCredentials(dbFeed.ID, dbFeed.Credentials); err != nil {
+		// Still show that there are credentials, so they can be replaced
+		fmt.Printf("failed to read credentials of feed %s: %s\n", dbFeed.Name, err)
+		feed.Auth = &models.FeedAuth{}
+	} else {
+		feed.Auth = toFeedAuth(creds)
+	}
+	if follow.FetchIntervalOverrideSeconds.Valid {
+		override := time.Duration(follow.FetchIntervalOverrideSeconds.Int64) * time.Second
+		feed.FetchIntervalOverride = &override
+	}
+	if dbFeed.LastFetchedAt.Valid {
+		feed.LastFetchedAt = &dbFeed.LastFetchedAt.Time
+	}
+	if dbFeed.NextFetchAfter.Valid {
+		feed.NextFetchAt = &dbFeed.NextFetchAfter.Time
 	}
 
 	return feed, nil
 }
 
-func (s *FeedService) DeleteFeed(ctx context.Context, id uuid.UUID) error {
-	if err := s.Repo.DeleteFeed(ctx, id.String()); err != nil {
+// SetFetchIntervalOverride sets how often the user wants a feed polled, or
+// returns them to the automatic schedule when override is nil. Feeds are
+// shared, so a feed is polled at the shortest interval any of its followers
+// asked for.
+func (s *FeedService) SetFetchIntervalOverride(ctx context.Context, userID uuid.UUID, id uuid.UUID, override *time.Duration) error {
+	dbFeed, err := s.followedFeed(ctx, userID, id)
+	if err != nil {
 		return err
 	}
 
+	params := database.SetFeedFollowFetchIntervalOverrideParams{
+		UserID: userID.String(),
+		FeedID: dbFeed.ID,
+	}
+	if override != nil {
+		minInterval, maxInterval := s.fetchIntervalBounds()
+		if *override < minInterval || *override > maxInterval {
+			return fmt.Errorf("interval must be between %s and %s", minInterval, maxInterval)
+		}
+		params.FetchIntervalOverrideSeconds = sql.NullInt64{Int64: int64(*override / time.Second), Valid: true}
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	if err := s.Repo.SetFeedFollowFetchIntervalOverride(ctx, params); err != nil {
+		return fmt.Errorf("failed to set fetch interval: %s", err)
+	}
+	return s.syncFetchIntervalOverride(ctx, dbFeed)
+}
+
+// syncFetchIntervalOverride polls the feed at the shortest interval its
+// followers asked for. The next fetch is moved earlier if the new interval
+// calls for it, unless the feed is backing off after failures.
+func (s *FeedService) syncFetchIntervalOverride(ctx context.Context, dbFeed database.Feed) error {
+	shortest, err := s.Repo.GetShortestFetchIntervalOverride(ctx, dbFeed.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to get fetch interval: %s", err)
+	}
+
+	params := database.SetFeedFetchIntervalOverrideParams{
+		FetchIntervalOverrideSeconds: shortest,
+		NextFetchAfter:               dbFeed.NextFetchAfter,
+		ID:                           dbFeed.ID,
+	}
+	dbFeed.FetchIntervalOverrideSeconds = shortest
+
+	if dbFeed.LastFetchedAt.Valid && dbFeed.ConsecutiveFailures == 0 {
+		next := nextFetchTime(dbFeed.LastFetchedAt.Time, s.effectiveInterval(dbFeed), parseSkipHours(dbFeed.SkipHours), parseSkipDays(dbFeed.SkipDays))
+		if !params.NextFetchAfter.Valid || next.Before(params.NextFetchAfter.Time) {
+			params.NextFetchAfter = sql.NullTime{Time: next, Valid: true}
+		}
+	}
+
+	if err := s.Repo.SetFeedFetchIntervalOverride(ctx, params); err != nil {
+		return fmt.Errorf("failed to set fetch interval: %s", err)
+	}
 	return nil
 }
 
-func (s *FeedService) ScrapeFeeds(ctx context.Context) error {
-	// Change from 24 hours to 1 hour to respect the "once per hour" limit
-	cutoff := time.Now().Add(-1 * time.Hour)
-	feeds, err := s.Repo.GetFeedsToFetch(ctx, sql.NullTime{Time: cutoff, Valid: true})
+// SetMarkUnreadOnUpdate sets whether posts the publisher edits are marked
+// unread again for the user once they have read them. Other followers of
+// the feed keep their own setting.
+func (s *FeedService) SetMarkUnreadOnUpdate(ctx context.Context, userID uuid.UUID, id uuid.UUID, markUnread bool) error {
+	dbFeed, err := s.followedFeed(ctx, userID, id)
 	if err != nil {
-		return fmt.Errorf("failed to get feeds: %s", err)
+		return err
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	return s.Repo.SetFeedFollowMarkUnreadOnUpdate(ctx, database.SetFeedFollowMarkUnreadOnUpdateParams{
+		MarkUnreadOnUpdate: markUnread,
+		UserID:             userID.String(),
+		FeedID:             dbFeed.ID,
+	})
+}
+
+// ReviveFeed puts a dead feed back on the refresh schedule, so it is fetched
+// again on the next run. It is marked dead again if it keeps failing.
+func (s *FeedService) ReviveFeed(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	dbFeed, err := s.followedFeed(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if !dbFeed.DeadAt.Valid {
+		return nil
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	if err := s.Repo.ReviveFeed(ctx, dbFeed.ID); err != nil {
+		return fmt.Errorf("failed to revive feed: %s", err)
+	}
+	return nil
+}
+
+// Unsubscribe removes the user's follow of a feed. The feed itself, with its
+// posts, is deleted once nobody follows it.
+func (s *FeedService) Unsubscribe(ctx context.Context, userID uuid.UUID, feedID uuid.UUID) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	unfollowed, err := s.Repo.DeleteFeedFollow(ctx, database.DeleteFeedFollowParams{
+		UserID: userID.String(),
+		FeedID: feedID.String(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to unfollow feed: %s", err)
+	}
+	if unfollowed == 0 {
+		return ErrNotFollowing
+	}
+
+	if err := s.Repo.DeleteFeedIfUnfollowed(ctx, feedID.String()); err != nil {
+		return fmt.Errorf("failed to delete unfollowed feed: %s", err)
+	}
+
+	// The user's interval no longer counts towards how often the feed is
+	// polled by the followers left
+	dbFeed, err := s.Repo.GetFeedByID(ctx, feedID.String())
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get feed: %s", err)
+	}
+	return s.syncFetchIntervalOverride(ctx, dbFeed)
+}
+
+// ScrapeSummary reports what happened to each feed during a ScrapeFeeds run.
+type ScrapeSummary struct {
+	Succeeded []string
+	Skipped   []string
+	Failed    []FeedFailure
+}
+
+// FeedFailure describes a feed that could not be refreshed.
+type FeedFailure struct {
+	FeedName string
+	Err      error
+}
+
+func (s ScrapeSummary) String() string {
+	return fmt.Sprintf("%d succeeded, %d skipped, %d failed", len(s.Succeeded), len(s.Skipped), len(s.Failed))
+}
+
+// ScrapeFeeds fetches every feed that is due. A feed that fails is recorded
+// on its row and in the summary without stopping the others; the returned
+// error is only set when the run itself could not start.
+func (s *FeedService) ScrapeFeeds(ctx context.Context) (ScrapeSummary, error) {
+	var summary ScrapeSummary
+
+	// Feeds that have never been scheduled fall back to the default interval
+	now := time.Now().UTC()
+	cutoff := now.Add(-defaultFetchInterval)
+	feeds, err := s.Repo.GetFeedsToFetch(ctx, database.GetFeedsToFetchParams{
+		Cutoff: sql.NullTime{Time: cutoff, Valid: true},
+		Now:    sql.NullTime{Time: now, Valid: true},
+	})
+	if err != nil {
+		return summary, fmt.Errorf("failed to get feeds: %s", err)
 	}
 
 	if len(feeds) == 0 {
 		fmt.Println("no feeds to fetch")
-		return nil
+		return summary, nil
 	}
 
-	for _, feed := range feeds {
-		fmt.Printf("fetching feed: %s\n", feed.Name)
+	maxConcurrency := s.MaxConcurrency
+	if maxConcurrency <= 0 {
+		maxConcurrency = defaultMaxConcurrency
+	}
+	maxPerHost := s.MaxPerHost
+	if maxPerHost <= 0 {
+		maxPerHost = defaultMaxPerHost
+	}
 
-		// Extract conditional headers from database
-		var etag, lastModified *string
-		if feed.Etag.Valid && feed.Etag.String != "" {
-			etag = &feed.Etag.String
-		}
-		if feed.LastModified.Valid && feed.LastModified.String != "" {
-			lastModified = &feed.LastModified.String
+	var (
+		wg        sync.WaitGroup
+		summaryMu sync.Mutex
+		workers   = make(chan struct{}, maxConcurrency)
+		hosts     = make(map[string]chan struct{})
+	)
+
+	for _, feed := range feeds {
+		host := feedHost(feed.Url)
+		hostSlots, ok := hosts[host]
+		if !ok {
+			hostSlots = make(chan struct{}, maxPerHost)
+			hosts[host] = hostSlots
 		}
 
-		// Use conditional request
-		result, err := feedparser.FetchFeedWithConditionals(context.Background(), feed.Url, etag, lastModified)
-		if err != nil {
-			// Handle rate limiting (429) with exponential backoff
-			if strings.Contains(err.Error(), "status code: 429") {
-				fmt.Printf("Rate limited for feed %s, skipping for now\n", feed.Name)
-				continue
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			hostSlots <- struct{}{}
+			defer func() { <-hostSlots }()
+			workers <- struct{}{}
+			defer func() { <-workers }()
+
+			skipped, err := s.scrapeFeed(ctx, feed)
+			if err != nil && ctx.Err() == nil {
+				fmt.Printf("failed to refresh feed %s: %s\n", feed.Name, err)
+				if recordErr := s.recordFailure(ctx, feed, err); recordErr != nil {
+					fmt.Printf("failed to record error for feed %s: %s\n", feed.Name, recordErr)
+				}
 			}
-			return fmt.Errorf("failed to fetch feed: %s", err)
-		}
-
-		// Handle 304 Not Modified response
-		if result.NotModified {
-			fmt.Printf("Feed %s not modified, updating headers only\n", feed.Name)
-			if err := s.Repo.UpdateFeedConditionalHeadersNoFetch(context.Background(), database.UpdateFeedConditionalHeadersNoFetchParams{
-				Etag:         sql.NullString{String: result.ETag, Valid: result.ETag != ""},
-				LastModified: sql.NullString{String: result.LastModified, Valid: result.LastModified != ""},
-				ID:           feed.ID,
-			}); err != nil {
-				return fmt.Errorf("failed to update feed headers: %s", err)
+
+			summaryMu.Lock()
+			defer summaryMu.Unlock()
+			switch {
+			case err != nil:
+				summary.Failed = append(summary.Failed, FeedFailure{FeedName: feed.Name, Err: err})
+			case skipped:
+				summary.Skipped = append(summary.Skipped, feed.Name)
+			default:
+				summary.Succeeded = append(summary.Succeeded, feed.Name)
 			}
-			continue
-		}
+		}()
+	}
 
-		// Handle successful response with new content
-		if result.Feed == nil {
-			fmt.Printf("No feed data received for %s\n", feed.Name)
-			continue
-		}
+	wg.Wait()
+
+	fmt.Printf("refreshed feeds: %s\n", summary)
+	return summary, nil
+}
+
+// scrapeFeed fetches a single feed and stores any new posts. It reports
+// whether the feed was skipped without being refreshed. It is safe to call
+// from multiple goroutines.
+func (s *FeedService) scrapeFeed(ctx context.Context, feed database.Feed) (bool, error) {
+	fetchTimeout := s.FetchTimeout
+	if fetchTimeout <= 0 {
+		fetchTimeout = defaultFetchTimeout
+	}
+
+	fmt.Printf("fetching feed: %s\n", feed.Name)
+
+	// Extract conditional headers from database
+	var etag, lastModified *string
+	if feed.Etag.Valid && feed.Etag.String != "" {
+		etag = &feed.Etag.String
+	}
+	if feed.LastModified.Valid && feed.LastModified.String != "" {
+		lastModified = &feed.LastModified.String
+	}
 
-		// Update conditional headers and fetch timestamp
-		if err := s.Repo.UpdateFeedConditionalHeaders(context.Background(), database.UpdateFeedConditionalHeadersParams{
+	header, err := s.feedHeader(feed)
+	if err != nil {
+		return false, err
+	}
+
+	// Use conditional request, bounded so one slow host can't stall the run
+	fetchCtx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+	result, err := s.fetcher().FetchFeedWithHeader(fetchCtx, feed.Url, header, etag, lastModified)
+	if err != nil {
+		return false, fmt.Errorf("failed to fetch feed: %w", err)
+	}
+
+	// SQLite allows a single writer, so serialize writes across workers
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	if err := s.trackRedirect(ctx, feed, result.PermanentURL); err != nil {
+		return false, err
+	}
+
+	// Handle 304 Not Modified response
+	if result.NotModified {
+		fmt.Printf("Feed %s not modified, updating headers only\n", feed.Name)
+		if err := s.Repo.UpdateFeedConditionalHeadersNoFetch(ctx, database.UpdateFeedConditionalHeadersNoFetchParams{
 			Etag:         sql.NullString{String: result.ETag, Valid: result.ETag != ""},
 			LastModified: sql.NullString{String: result.LastModified, Valid: result.LastModified != ""},
 			ID:           feed.ID,
 		}); err != nil {
-			return fmt.Errorf("failed to update feed headers: %s", err)
-		}
-
-		// Process new posts
-		for _, item := range result.Feed.GetItems() {
-			post, err := s.Repo.CreatePost(ctx, database.CreatePostParams{
-				ID:          uuid.New().String(),
-				Title:       item.GetTitle(),
-				Url:         item.GetLink(),
-				Description: sql.NullString{String: *item.GetDescription(), Valid: item.GetDescription() != nil},
-				PublishedAt: item.GetDate(),
-				FeedID:      feed.ID,
-			})
-			if err != nil {
-				if !strings.Contains(err.Error(), "duplicate key value violates unique constraint") {
-					fmt.Printf("post already exists: %s\n", item.GetLink())
-				}
-			} else {
-				fmt.Printf("created post with URL %s\n", post.Url)
-			}
+			return false, fmt.Errorf("failed to update feed headers: %s", err)
+		}
+		next := nextFetchTime(time.Now(), s.effectiveInterval(feed), parseSkipHours(feed.SkipHours), parseSkipDays(feed.SkipDays))
+		if err := s.Repo.RecordFeedFetchSuccess(ctx, database.RecordFeedFetchSuccessParams{
+			NextFetchAfter: sql.NullTime{Time: next, Valid: true},
+			ID:             feed.ID,
+		}); err != nil {
+			return false, fmt.Errorf("failed to record feed fetch: %s", err)
 		}
+		return false, nil
+	}
+
+	// Handle successful response with new content
+	if result.Feed == nil {
+		fmt.Printf("No feed data received for %s\n", feed.Name)
+		return true, nil
 	}
 
+	// Update conditional headers and fetch timestamp
+	if err := s.Repo.UpdateFeedConditionalHeaders(ctx, database.UpdateFeedConditionalHeadersParams{
+		Etag:         sql.NullString{String: result.ETag, Valid: result.ETag != ""},
+		LastModified: sql.NullString{String: result.LastModified, Valid: result.LastModified != ""},
+		ID:           feed.ID,
+	}); err != nil {
+		return false, fmt.Errorf("failed to update feed headers: %s", err)
+	}
+
+	// Process new and edited posts
+	firstSeen := time.Now().UTC()
+	for _, item := range result.Feed.GetItems() {
+		if err := s.ingestItem(ctx, feed, item, firstSeen); err != nil {
+			fmt.Printf("failed to store post %s: %s\n", item.GetLink(), err)
+		}
+	}
+
+	// Schedule the next fetch now that the new posts are part of the history
+	hints := result.Feed.GetUpdateHints()
+	interval, err := s.fetchInterval(ctx, feed, hints)
+	if err != nil {
+		return false, err
+	}
+	feed.FetchIntervalSeconds = sql.NullInt64{Int64: int64(interval / time.Second), Valid: true}
+
+	if err := s.Repo.UpdateFeedSchedule(ctx, database.UpdateFeedScheduleParams{
+		FetchIntervalSeconds: feed.FetchIntervalSeconds,
+		SkipHours:            formatSkipHours(hints.SkipHours),
+		SkipDays:             formatSkipDays(hints.SkipDays),
+		ID:                   feed.ID,
+	}); err != nil {
+		return false, fmt.Errorf("failed to update feed schedule: %s", err)
+	}
+
+	// A page of a paged feed that couldn't be fetched is shown on the feed,
+	// but doesn't count as a failure since the rest of it was stored
+	var lastError sql.NullString
+	if result.PageErr != nil {
+		fmt.Printf("feed %s is incomplete: %s\n", feed.Name, result.PageErr)
+		lastError = sql.NullString{String: fmt.Sprintf("some posts are missing: %s", result.PageErr), Valid: true}
+	}
+
+	next := nextFetchTime(time.Now(), s.effectiveInterval(feed), hints.SkipHours, hints.SkipDays)
+	if err := s.Repo.RecordFeedFetchSuccess(ctx, database.RecordFeedFetchSuccessParams{
+		LastError:      lastError,
+		NextFetchAfter: sql.NullTime{Time: next, Valid: true},
+		ID:             feed.ID,
+	}); err != nil {
+		return false, fmt.Errorf("failed to record feed fetch: %s", err)
+	}
+
+	return false, nil
+}
+
+// recordFailure stores the error on the feed row so it can be shown later and
+// so repeated failures can be counted. Temporary failures also push the next
+// fetch back so a struggling host is not hit again on the very next run.
+func (s *FeedService) recordFailure(ctx context.Context, feed database.Feed, fetchErr error) error {
+	if s.isDead(feed, fetchErr, time.Now()) {
+		fmt.Printf("marking feed %s dead: %s\n", feed.Name, fetchErr)
+		s.writeMu.Lock()
+		defer s.writeMu.Unlock()
+		return s.Repo.MarkFeedDead(ctx, database.MarkFeedDeadParams{
+			LastError: sql.NullString{String: fetchErr.Error(), Valid: true},
+			ID:        feed.ID,
+		})
+	}
+
+	var nextFetchAfter sql.NullTime
+	if retryable, retryAfter := isRetryable(fetchErr); retryable {
+		delay := backoffDelay(feed.ConsecutiveFailures+1, retryAfter)
+		nextFetchAfter = sql.NullTime{Time: time.Now().UTC().Add(delay), Valid: true}
+		fmt.Printf("backing off feed %s for %s\n", feed.Name, delay)
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	return s.Repo.RecordFeedFetchFailure(ctx, database.RecordFeedFetchFailureParams{
+		LastError:      sql.NullString{String: fetchErr.Error(), Valid: true},
+		NextFetchAfter: nextFetchAfter,
+		ID:             feed.ID,
+	})
+}
+
+// isDead reports whether a failed fetch means the feed should be retired:
+// the server says it is gone for good, or it has been failing for longer
+// than DeadAfter since its last successful fetch.
+func (s *FeedService) isDead(feed database.Feed, fetchErr error, now time.Time) bool {
+	var httpErr *feedparser.HTTPError
+	if errors.As(fetchErr, &httpErr) && httpErr.StatusCode == http.StatusGone {
+		return true
+	}
+
+	if feed.ConsecutiveFailures == 0 {
+		return false
+	}
+	deadAfter := s.DeadAfter
+	if deadAfter <= 0 {
+		deadAfter = defaultDeadAfter
+	}
+	lastSuccess := feed.CreatedAt
+	if feed.LastSuccessAt.Valid {
+		lastSuccess = feed.LastSuccessAt.Time
+	}
+	return now.Sub(lastSuccess) > deadAfter
+}
+
+// trackRedirect counts how many fetches in a row were permanently
+// redirected to permanentURL, and moves the feed there once the redirect
+// has held for redirectStableFetches. An empty permanentURL resets the
+// count. Callers must hold writeMu.
+func (s *FeedService) trackRedirect(ctx context.Context, feed database.Feed, permanentURL string) error {
+	if permanentURL == "" || permanentURL == feed.Url {
+		if !feed.RedirectUrl.Valid {
+			return nil
+		}
+		if err := s.Repo.ClearFeedRedirect(ctx, feed.ID); err != nil {
+			return fmt.Errorf("failed to clear feed redirect: %s", err)
+		}
+		return nil
+	}
+
+	count, err := s.Repo.RecordFeedRedirect(ctx, database.RecordFeedRedirectParams{
+		RedirectUrl: sql.NullString{String: permanentURL, Valid: true},
+		ID:          feed.ID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record feed redirect: %s", err)
+	}
+	if count < redirectStableFetches {
+		return nil
+	}
+
+	// Feed URLs are unique, so a feed that moved onto one already added
+	// keeps being fetched through the redirect
+	if _, err := s.Repo.GetFeedByUrl(ctx, permanentURL); err == nil {
+		fmt.Printf("feed %s moved to %s, which is already a feed\n", feed.Name, permanentURL)
+		return nil
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to look up redirected feed: %s", err)
+	}
+
+	fmt.Printf("feed %s moved permanently to %s\n", feed.Name, permanentURL)
+	if err := s.Repo.MoveFeed(ctx, database.MoveFeedParams{
+		Url: permanentURL,
+		ID:  feed.ID,
+	}); err != nil {
+		return fmt.Errorf("failed to move feed: %s", err)
+	}
 	return nil
 }
+
+// isRetryable reports whether a fetch error is worth backing off from rather
+// than retrying on the normal schedule, along with any delay the server asked
+// for.
+func isRetryable(err error) (bool, time.Duration) {
+	var httpErr *feedparser.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Temporary(), httpErr.RetryAfter
+	}
+
+	var timeoutErr *feedparser.TimeoutError
+	if errors.As(err, &timeoutErr) {
+		return true, 0
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) {
+		return true, 0
+	}
+
+	return false, 0
+}
+
+// backoffDelay doubles the wait for every consecutive failure, starting at
+// minBackoff and capped at maxBackoff. A longer Retry-After from the server
+// always wins, up to maxRetryAfter.
+func backoffDelay(failures int64, retryAfter time.Duration) time.Duration {
+	delay := maxBackoff
+	if failures < 1 {
+		failures = 1
+	}
+	if shift := failures - 1; shift < 16 {
+		delay = min(minBackoff<<shift, maxBackoff)
+	}
+
+	if retryAfter > delay {
+		delay = min(retryAfter, maxRetryAfter)
+	}
+
+	return delay
+}
+
+func feedHost(feedURL string) string {
+	u, err := url.Parse(feedURL)
+	if err != nil || u.Host == "" {
+		return feedURL
+	}
+	return strings.ToLower(u.Host)
+}
@@ -3,27 +3,69 @@ package service
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/nrbernard/gator/internal/database"
+	"github.com/nrbernard/gator/internal/feedparser"
 )
 
+func TestMain(m *testing.M) {
+	// Test feeds are served from httptest servers on loopback
+	feedparser.DefaultFetcher = &feedparser.Fetcher{
+		AllowedNetworks: []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8"), netip.MustParsePrefix("::1/128")},
+	}
+	os.Exit(m.Run())
+}
+
 func setupTestDB(t *testing.T) *database.Queries {
+	return database.New(openTestDB(t))
+}
+
+// openTestDB returns an in-memory database with the tables the services use.
+func openTestDB(t *testing.T) *sql.DB {
 	db, err := sql.Open("sqlite3", ":memory:")
 	if err != nil {
 		t.Fatalf("Failed to open database: %v", err)
 	}
 
+	// Every connection to :memory: is a separate database
+	db.SetMaxOpenConns(1)
+
 	// Create tables
 	createTables := `
 	CREATE TABLE users (
 		id TEXT PRIMARY KEY,
 		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
 		updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-		name VARCHAR(255) NOT NULL
+		name VARCHAR(255) NOT NULL UNIQUE,
+		password_hash TEXT
+	);
+
+	CREATE TABLE sessions (
+		id TEXT PRIMARY KEY,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		expires_at TIMESTAMP NOT NULL,
+		user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE api_tokens (
+		id TEXT PRIMARY KEY,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		last_used_at TIMESTAMP,
+		name TEXT NOT NULL,
+		token_hash TEXT NOT NULL UNIQUE,
+		user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE
 	);
 	
 	CREATE TABLE feeds (
@@ -32,22 +74,94 @@ func setupTestDB(t *testing.T) *database.Queries {
 		updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
 		name VARCHAR(255) NOT NULL,
 		url VARCHAR(255) NOT NULL UNIQUE,
-		user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		user_id TEXT REFERENCES users(id) ON DELETE SET NULL,
 		last_fetched_at TIMESTAMP,
 		description TEXT,
 		etag TEXT,
-		last_modified TEXT
+		last_modified TEXT,
+		last_error TEXT,
+		consecutive_failures INTEGER NOT NULL DEFAULT 0,
+		last_success_at TIMESTAMP,
+		next_fetch_after TIMESTAMP,
+		fetch_interval_seconds INTEGER,
+		fetch_interval_override_seconds INTEGER,
+		skip_hours TEXT,
+		skip_days TEXT,
+		redirect_url TEXT,
+		redirect_count INTEGER NOT NULL DEFAULT 0,
+		dead_at TIMESTAMP,
+		credentials BLOB
+	);
+
+	CREATE TABLE feed_follows (
+		id TEXT PRIMARY KEY,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		feed_id TEXT NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+		folder TEXT,
+		fetch_interval_override_seconds INTEGER,
+		mark_unread_on_update BOOLEAN NOT NULL DEFAULT false,
+		UNIQUE(user_id, feed_id)
 	);
 	
 	CREATE TABLE posts (
-		id TEXT PRIMARY KEY,
+		id TEXT NOT NULL UNIQUE,
 		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
 		updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
 		title VARCHAR(255) NOT NULL,
-		url VARCHAR(255) NOT NULL UNIQUE,
+		url VARCHAR(255) NOT NULL,
+		description TEXT,
+		published_at TIMESTAMP NOT NULL,
+		feed_id TEXT NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+		seq INTEGER PRIMARY KEY,
+		guid TEXT,
+		identity_key TEXT NOT NULL,
+		content_hash TEXT,
+		thumbnail_url TEXT,
+		content_html TEXT,
+		UNIQUE (feed_id, identity_key)
+	);
+
+	CREATE TABLE post_enclosures (
+		id TEXT PRIMARY KEY,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		post_id TEXT NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+		url TEXT NOT NULL,
+		mime_type TEXT,
+		length INTEGER,
+		duration_seconds INTEGER,
+		UNIQUE(post_id, url)
+	);
+
+	CREATE TABLE post_revisions (
+		id TEXT PRIMARY KEY,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		post_id TEXT NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+		title TEXT NOT NULL,
 		description TEXT,
 		published_at TIMESTAMP NOT NULL,
-		feed_id TEXT NOT NULL REFERENCES feeds(id) ON DELETE CASCADE
+		content_hash TEXT,
+		content_html TEXT
+	);
+
+	CREATE TABLE post_saves (
+		id TEXT PRIMARY KEY,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		post_id TEXT NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+		user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		UNIQUE(post_id, user_id)
+	);
+
+	CREATE TABLE post_reads (
+		id TEXT PRIMARY KEY,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		post_id TEXT NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+		user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		UNIQUE(post_id, user_id)
 	);
 	`
 
@@ -55,7 +169,7 @@ func setupTestDB(t *testing.T) *database.Queries {
 		t.Fatalf("Failed to create tables: %v", err)
 	}
 
-	return database.New(db)
+	return db
 }
 
 func TestFeedService_ScrapeFeeds_WithConditionalRequests(t *testing.T) {
@@ -79,7 +193,7 @@ func TestFeedService_ScrapeFeeds_WithConditionalRequests(t *testing.T) {
 		ID:          feedID,
 		Name:        "Test Feed",
 		Url:         "http://example.com/feed.xml",
-		UserID:      userID,
+		UserID:      sql.NullString{String: userID, Valid: true},
 		Description: sql.NullString{String: "Test Description", Valid: true},
 	})
 	if err != nil {
@@ -98,7 +212,10 @@ func TestFeedService_ScrapeFeeds_WithConditionalRequests(t *testing.T) {
 
 	// Test that the feed is included in feeds to fetch (new feeds should be fetched)
 	cutoff := time.Now().Add(-1 * time.Hour)
-	feeds, err := queries.GetFeedsToFetch(ctx, sql.NullTime{Time: cutoff, Valid: true})
+	feeds, err := queries.GetFeedsToFetch(ctx, database.GetFeedsToFetchParams{
+		Cutoff: sql.NullTime{Time: cutoff, Valid: true},
+		Now:    sql.NullTime{Time: time.Now(), Valid: true},
+	})
 	if err != nil {
 		t.Fatalf("Failed to get feeds to fetch: %v", err)
 	}
@@ -137,7 +254,7 @@ func TestFeedService_ScrapeFeeds_PollingFrequency(t *testing.T) {
 		ID:     feedID,
 		Name:   "Test Feed",
 		Url:    "http://example.com/feed.xml",
-		UserID: userID,
+		UserID: sql.NullString{String: userID, Valid: true},
 	})
 	if err != nil {
 		t.Fatalf("Failed to create feed: %v", err)
@@ -151,7 +268,10 @@ func TestFeedService_ScrapeFeeds_PollingFrequency(t *testing.T) {
 
 	// Test that the feed is NOT included in feeds to fetch (due to 1-hour limit)
 	cutoff := time.Now().Add(-1 * time.Hour)
-	feeds, err := queries.GetFeedsToFetch(ctx, sql.NullTime{Time: cutoff, Valid: true})
+	feeds, err := queries.GetFeedsToFetch(ctx, database.GetFeedsToFetchParams{
+		Cutoff: sql.NullTime{Time: cutoff, Valid: true},
+		Now:    sql.NullTime{Time: time.Now(), Valid: true},
+	})
 	if err != nil {
 		t.Fatalf("Failed to get feeds to fetch: %v", err)
 	}
@@ -160,3 +280,1503 @@ func TestFeedService_ScrapeFeeds_PollingFrequency(t *testing.T) {
 		t.Errorf("Expected 0 feeds to fetch (recently fetched), got %d", len(feeds))
 	}
 }
+
+func TestFeedService_ScrapeFeeds_Concurrency(t *testing.T) {
+	queries := setupTestDB(t)
+
+	ctx := context.Background()
+
+	var (
+		mu           sync.Mutex
+		inFlight     int
+		maxInFlight  int
+		requestsSeen int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		requestsSeen++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/rss+xml")
+		fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0">
+  <channel>
+    <title>Feed %[1]s</title>
+    <item>
+      <title>Item %[1]s</title>
+      <link>http://example.com%[1]s</link>
+      <pubDate>Wed, 21 Oct 2015 07:28:00 GMT</pubDate>
+    </item>
+  </channel>
+</rss>`, r.URL.Path)
+	}))
+	defer server.Close()
+
+	userID := uuid.New().String()
+	if _, err := queries.CreateUser(ctx, database.CreateUserParams{ID: userID, Name: "Test User"}); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	const feedCount = 6
+	for i := 0; i < feedCount; i++ {
+		if _, err := queries.CreateFeed(ctx, database.CreateFeedParams{
+			ID:     uuid.New().String(),
+			Name:   fmt.Sprintf("Feed %d", i),
+			Url:    fmt.Sprintf("%s/feed-%d.xml", server.URL, i),
+			UserID: sql.NullString{String: userID, Valid: true},
+		}); err != nil {
+			t.Fatalf("Failed to create feed: %v", err)
+		}
+	}
+
+	feedService := &FeedService{Repo: queries, MaxConcurrency: 4, MaxPerHost: 2}
+	if _, err := feedService.ScrapeFeeds(ctx); err != nil {
+		t.Fatalf("Failed to scrape feeds: %v", err)
+	}
+
+	if requestsSeen != feedCount {
+		t.Errorf("Expected %d requests, got %d", feedCount, requestsSeen)
+	}
+
+	if maxInFlight > 2 {
+		t.Errorf("Expected at most 2 concurrent requests to one host, got %d", maxInFlight)
+	}
+
+	feeds, err := queries.GetFeedsToFetch(ctx, database.GetFeedsToFetchParams{
+		Cutoff: sql.NullTime{Time: time.Now().Add(-1 * time.Hour), Valid: true},
+		Now:    sql.NullTime{Time: time.Now(), Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("Failed to get feeds to fetch: %v", err)
+	}
+	if len(feeds) != 0 {
+		t.Errorf("Expected all feeds to be marked as fetched, got %d still due", len(feeds))
+	}
+}
+
+func TestFeedService_ScrapeFeeds_GlobalConcurrency(t *testing.T) {
+	queries := setupTestDB(t)
+
+	ctx := context.Background()
+
+	var (
+		mu          sync.Mutex
+		inFlight    int
+		maxInFlight int
+		hostFlight  = make(map[string]int)
+		maxPerHost  int
+	)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		hostFlight[r.Host]++
+		maxInFlight = max(maxInFlight, inFlight)
+		maxPerHost = max(maxPerHost, hostFlight[r.Host])
+		mu.Unlock()
+
+		time.Sleep(50 * time.Millisecond)
+
+		mu.Lock()
+		inFlight--
+		hostFlight[r.Host]--
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/rss+xml")
+		fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"><channel><title>Feed %s</title></channel></rss>`, r.URL.Path)
+	})
+
+	userID := uuid.New().String()
+	if _, err := queries.CreateUser(ctx, database.CreateUserParams{ID: userID, Name: "Test User"}); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	// Each server listens on its own port, which makes it a separate host
+	const hostCount, feedsPerHost = 4, 3
+	for i := 0; i < hostCount; i++ {
+		server := httptest.NewServer(handler)
+		defer server.Close()
+		for j := 0; j < feedsPerHost; j++ {
+			if _, err := queries.CreateFeed(ctx, database.CreateFeedParams{
+				ID:     uuid.New().String(),
+				Name:   fmt.Sprintf("Feed %d-%d", i, j),
+				Url:    fmt.Sprintf("%s/feed-%d.xml", server.URL, j),
+				UserID: sql.NullString{String: userID, Valid: true},
+			}); err != nil {
+				t.Fatalf("Failed to create feed: %v", err)
+			}
+		}
+	}
+
+	feedService := &FeedService{Repo: queries, MaxConcurrency: 3, MaxPerHost: 2}
+	summary, err := feedService.ScrapeFeeds(ctx)
+	if err != nil {
+		t.Fatalf("Failed to scrape feeds: %v", err)
+	}
+	if len(summary.Succeeded) != hostCount*feedsPerHost {
+		t.Errorf("Expected %d feeds to succeed, got %s", hostCount*feedsPerHost, summary)
+	}
+
+	if maxInFlight > 3 {
+		t.Errorf("Expected at most 3 concurrent requests overall, got %d", maxInFlight)
+	}
+	if maxInFlight < 2 {
+		t.Errorf("Expected feeds on different hosts to be fetched in parallel, got at most %d at once", maxInFlight)
+	}
+	if maxPerHost > 2 {
+		t.Errorf("Expected at most 2 concurrent requests to one host, got %d", maxPerHost)
+	}
+}
+
+func TestFeedService_ScrapeFeeds_FailureIsolation(t *testing.T) {
+	queries := setupTestDB(t)
+
+	ctx := context.Background()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken.xml" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0">
+  <channel>
+    <title>Working Feed</title>
+    <item>
+      <title>Item</title>
+      <link>http://example.com/item</link>
+      <pubDate>Wed, 21 Oct 2015 07:28:00 GMT</pubDate>
+    </item>
+  </channel>
+</rss>`))
+	}))
+	defer server.Close()
+
+	userID := uuid.New().String()
+	if _, err := queries.CreateUser(ctx, database.CreateUserParams{ID: userID, Name: "Test User"}); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	for _, path := range []string{"/broken.xml", "/working.xml"} {
+		if _, err := queries.CreateFeed(ctx, database.CreateFeedParams{
+			ID:     uuid.New().String(),
+			Name:   path,
+			Url:    server.URL + path,
+			UserID: sql.NullString{String: userID, Valid: true},
+		}); err != nil {
+			t.Fatalf("Failed to create feed: %v", err)
+		}
+	}
+
+	feedService := &FeedService{Repo: queries}
+	summary, err := feedService.ScrapeFeeds(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error from a run with one broken feed, got %v", err)
+	}
+
+	if len(summary.Succeeded) != 1 || summary.Succeeded[0] != "/working.xml" {
+		t.Errorf("Expected working feed to succeed, got %v", summary.Succeeded)
+	}
+
+	if len(summary.Failed) != 1 || summary.Failed[0].FeedName != "/broken.xml" {
+		t.Fatalf("Expected broken feed to fail, got %v", summary.Failed)
+	}
+
+	broken, err := queries.GetFeedByUrl(ctx, server.URL+"/broken.xml")
+	if err != nil {
+		t.Fatalf("Failed to get feed: %v", err)
+	}
+
+	if !broken.LastError.Valid || broken.LastError.String == "" {
+		t.Error("Expected last error to be recorded")
+	}
+
+	if broken.ConsecutiveFailures != 1 {
+		t.Errorf("Expected 1 consecutive failure, got %d", broken.ConsecutiveFailures)
+	}
+
+	if broken.LastSuccessAt.Valid {
+		t.Error("Expected no last success time for broken feed")
+	}
+
+	working, err := queries.GetFeedByUrl(ctx, server.URL+"/working.xml")
+	if err != nil {
+		t.Fatalf("Failed to get feed: %v", err)
+	}
+
+	if !working.LastSuccessAt.Valid {
+		t.Error("Expected last success time to be recorded")
+	}
+}
+
+func TestFeedService_ScrapeFeeds_Backoff(t *testing.T) {
+	queries := setupTestDB(t)
+
+	ctx := context.Background()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "7200")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	userID := uuid.New().String()
+	if _, err := queries.CreateUser(ctx, database.CreateUserParams{ID: userID, Name: "Test User"}); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	if _, err := queries.CreateFeed(ctx, database.CreateFeedParams{
+		ID:     uuid.New().String(),
+		Name:   "Unavailable Feed",
+		Url:    server.URL,
+		UserID: sql.NullString{String: userID, Valid: true},
+	}); err != nil {
+		t.Fatalf("Failed to create feed: %v", err)
+	}
+
+	feedService := &FeedService{Repo: queries}
+	summary, err := feedService.ScrapeFeeds(ctx)
+	if err != nil {
+		t.Fatalf("Failed to scrape feeds: %v", err)
+	}
+
+	if len(summary.Failed) != 1 {
+		t.Fatalf("Expected 1 failed feed, got %d", len(summary.Failed))
+	}
+
+	feed, err := queries.GetFeedByUrl(ctx, server.URL)
+	if err != nil {
+		t.Fatalf("Failed to get feed: %v", err)
+	}
+
+	if !feed.NextFetchAfter.Valid {
+		t.Fatal("Expected next fetch time to be set")
+	}
+
+	// Retry-After is longer than the first backoff step, so it wins
+	if wait := time.Until(feed.NextFetchAfter.Time); wait < 119*time.Minute || wait > 121*time.Minute {
+		t.Errorf("Expected next fetch in about 2h, got %s", wait)
+	}
+
+	// Even with an old cutoff the feed is held back until next_fetch_after
+	feeds, err := queries.GetFeedsToFetch(ctx, database.GetFeedsToFetchParams{
+		Cutoff: sql.NullTime{Time: time.Now().UTC().Add(time.Hour), Valid: true},
+		Now:    sql.NullTime{Time: time.Now().UTC(), Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("Failed to get feeds to fetch: %v", err)
+	}
+
+	if len(feeds) != 0 {
+		t.Errorf("Expected feed in backoff to be skipped, got %d feeds", len(feeds))
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	tests := []struct {
+		name       string
+		failures   int64
+		retryAfter time.Duration
+		expected   time.Duration
+	}{
+		{name: "first failure", failures: 1, expected: 5 * time.Minute},
+		{name: "doubles", failures: 3, expected: 20 * time.Minute},
+		{name: "capped", failures: 20, expected: 24 * time.Hour},
+		{name: "huge failure count", failures: 1000, expected: 24 * time.Hour},
+		{name: "retry after wins", failures: 1, retryAfter: time.Hour, expected: time.Hour},
+		{name: "shorter retry after ignored", failures: 3, retryAfter: time.Minute, expected: 20 * time.Minute},
+		{name: "retry after capped", failures: 1, retryAfter: 30 * 24 * time.Hour, expected: 7 * 24 * time.Hour},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := backoffDelay(tc.failures, tc.retryAfter); got != tc.expected {
+				t.Errorf("Expected %s, got %s", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestFeedService_ScrapeFeeds_AdaptiveInterval(t *testing.T) {
+	queries := setupTestDB(t)
+
+	ctx := context.Background()
+
+	// Posts every two hours, with a TTL that asks for at most every three
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0">
+  <channel>
+    <title>Cadence Feed</title>
+    <ttl>%s</ttl>
+    <item><title>One</title><link>http://example.com%[2]s/1</link><pubDate>Wed, 21 Oct 2015 06:00:00 GMT</pubDate></item>
+    <item><title>Two</title><link>http://example.com%[2]s/2</link><pubDate>Wed, 21 Oct 2015 08:00:00 GMT</pubDate></item>
+    <item><title>Three</title><link>http://example.com%[2]s/3</link><pubDate>Wed, 21 Oct 2015 10:00:00 GMT</pubDate></item>
+  </channel>
+</rss>`, r.URL.Query().Get("ttl"), r.URL.Path)
+	}))
+	defer server.Close()
+
+	userID := uuid.New().String()
+	if _, err := queries.CreateUser(ctx, database.CreateUserParams{ID: userID, Name: "Test User"}); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	feeds := map[string]time.Duration{
+		server.URL + "/cadence":         time.Hour,
+		server.URL + "/ttl?ttl=180":     3 * time.Hour,
+		server.URL + "/tiny-ttl?ttl=10": time.Hour,
+	}
+	for feedURL := range feeds {
+		if _, err := queries.CreateFeed(ctx, database.CreateFeedParams{
+			ID:     uuid.New().String(),
+			Name:   feedURL,
+			Url:    feedURL,
+			UserID: sql.NullString{String: userID, Valid: true},
+		}); err != nil {
+			t.Fatalf("Failed to create feed: %v", err)
+		}
+	}
+
+	feedService := &FeedService{Repo: queries}
+	if _, err := feedService.ScrapeFeeds(ctx); err != nil {
+		t.Fatalf("Failed to scrape feeds: %v", err)
+	}
+
+	for feedURL, expected := range feeds {
+		feed, err := queries.GetFeedByUrl(ctx, feedURL)
+		if err != nil {
+			t.Fatalf("Failed to get feed: %v", err)
+		}
+
+		if got := time.Duration(feed.FetchIntervalSeconds.Int64) * time.Second; got != expected {
+			t.Errorf("%s: expected interval %s, got %s", feedURL, expected, got)
+		}
+
+		if !feed.NextFetchAfter.Valid {
+			t.Fatalf("%s: expected next fetch time to be set", feedURL)
+		}
+
+		if wait := time.Until(feed.NextFetchAfter.Time); wait < expected-time.Minute || wait > expected+time.Minute {
+			t.Errorf("%s: expected next fetch in about %s, got %s", feedURL, expected, wait)
+		}
+	}
+
+	// A manual override takes precedence over the computed interval
+	feed, err := queries.GetFeedByUrl(ctx, server.URL+"/ttl?ttl=180")
+	if err != nil {
+		t.Fatalf("Failed to get feed: %v", err)
+	}
+
+	override := 30 * time.Minute
+	owner := uuid.MustParse(userID)
+	if err := feedService.SetFetchIntervalOverride(ctx, owner, uuid.MustParse(feed.ID), &override); err == nil {
+		t.Error("Expected only followers to change a feed's interval")
+	}
+	if _, err := queries.FollowFeed(ctx, database.FollowFeedParams{ID: uuid.New().String(), UserID: userID, FeedID: feed.ID}); err != nil {
+		t.Fatalf("Failed to follow feed: %v", err)
+	}
+	if err := feedService.SetFetchIntervalOverride(ctx, owner, uuid.MustParse(feed.ID), &override); err != nil {
+		t.Fatalf("Failed to set override: %v", err)
+	}
+
+	updated, err := feedService.GetFeed(ctx, owner, uuid.MustParse(feed.ID))
+	if err != nil {
+		t.Fatalf("Failed to get feed: %v", err)
+	}
+
+	if updated.FetchInterval != override {
+		t.Errorf("Expected overridden interval %s, got %s", override, updated.FetchInterval)
+	}
+
+	if updated.NextFetchAt == nil || time.Until(*updated.NextFetchAt) > override+time.Minute {
+		t.Errorf("Expected next fetch to move within %s, got %v", override, updated.NextFetchAt)
+	}
+
+	tooShort := time.Minute
+	if err := feedService.SetFetchIntervalOverride(ctx, owner, uuid.MustParse(feed.ID), &tooShort); err == nil {
+		t.Error("Expected error for override below the minimum interval")
+	}
+}
+
+func TestFeedService_SetFetchIntervalOverride_SharedFeed(t *testing.T) {
+	queries := setupTestDB(t)
+	ctx := context.Background()
+
+	alice, bob := uuid.New(), uuid.New()
+	feed, err := queries.CreateFeed(ctx, database.CreateFeedParams{
+		ID:   uuid.New().String(),
+		Name: "Shared Feed",
+		Url:  "https://example.com/feed.xml",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create feed: %v", err)
+	}
+	for _, user := range []uuid.UUID{alice, bob} {
+		if _, err := queries.CreateUser(ctx, database.CreateUserParams{ID: user.String(), Name: user.String()}); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		if _, err := queries.FollowFeed(ctx, database.FollowFeedParams{ID: uuid.New().String(), UserID: user.String(), FeedID: feed.ID}); err != nil {
+			t.Fatalf("Failed to follow feed: %v", err)
+		}
+	}
+
+	feedService := &FeedService{Repo: queries}
+	feedID := uuid.MustParse(feed.ID)
+	expectInterval := func(user uuid.UUID, interval time.Duration, override *time.Duration) {
+		t.Helper()
+		got, err := feedService.GetFeed(ctx, user, feedID)
+		if err != nil {
+			t.Fatalf("Failed to get feed: %v", err)
+		}
+		if got.FetchInterval != interval {
+			t.Errorf("Expected feed polled every %s, got %s", interval, got.FetchInterval)
+		}
+		if (got.FetchIntervalOverride == nil) != (override == nil) || (override != nil && *got.FetchIntervalOverride != *override) {
+			t.Errorf("Expected override %v, got %v", override, got.FetchIntervalOverride)
+		}
+	}
+
+	short, long := 30*time.Minute, 2*time.Hour
+	if err := feedService.SetFetchIntervalOverride(ctx, alice, feedID, &short); err != nil {
+		t.Fatalf("Failed to set override: %v", err)
+	}
+	expectInterval(alice, short, &short)
+	expectInterval(bob, short, nil)
+
+	// A longer interval from another follower doesn't slow the feed down
+	if err := feedService.SetFetchIntervalOverride(ctx, bob, feedID, &long); err != nil {
+		t.Fatalf("Failed to set override: %v", err)
+	}
+	expectInterval(alice, short, &short)
+	expectInterval(bob, short, &long)
+
+	if err := feedService.SetFetchIntervalOverride(ctx, alice, feedID, nil); err != nil {
+		t.Fatalf("Failed to clear override: %v", err)
+	}
+	expectInterval(alice, long, nil)
+
+	// Unsubscribing takes the follower's interval with them
+	if err := feedService.SetFetchIntervalOverride(ctx, alice, feedID, &short); err != nil {
+		t.Fatalf("Failed to set override: %v", err)
+	}
+	if err := feedService.Unsubscribe(ctx, alice, feedID); err != nil {
+		t.Fatalf("Failed to unsubscribe: %v", err)
+	}
+	expectInterval(bob, long, &long)
+}
+
+func TestCadenceInterval(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if _, ok := cadenceInterval([]time.Time{base}); ok {
+		t.Error("Expected no interval from a single post")
+	}
+
+	if _, ok := cadenceInterval([]time.Time{base, base}); ok {
+		t.Error("Expected no interval from posts with identical dates")
+	}
+
+	// Gaps of 1h, 4h, 4h, 24h: the median is 4h
+	dates := []time.Time{
+		base,
+		base.Add(1 * time.Hour),
+		base.Add(5 * time.Hour),
+		base.Add(9 * time.Hour),
+		base.Add(33 * time.Hour),
+	}
+	interval, ok := cadenceInterval(dates)
+	if !ok {
+		t.Fatal("Expected an interval")
+	}
+
+	if interval != 2*time.Hour {
+		t.Errorf("Expected 2h, got %s", interval)
+	}
+}
+
+func TestNextFetchTime(t *testing.T) {
+	// Friday 22:30 UTC
+	from := time.Date(2024, 1, 5, 22, 30, 0, 0, time.UTC)
+
+	if got := nextFetchTime(from, time.Hour, nil, nil); !got.Equal(from.Add(time.Hour)) {
+		t.Errorf("Expected %s, got %s", from.Add(time.Hour), got)
+	}
+
+	// 23:30 falls in a skipped hour, so wait until midnight
+	got := nextFetchTime(from, time.Hour, []int{23}, nil)
+	if expected := time.Date(2024, 1, 6, 0, 0, 0, 0, time.UTC); !got.Equal(expected) {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+
+	// Skipping the weekend pushes a Saturday fetch to Monday
+	got = nextFetchTime(from.Add(24*time.Hour), time.Hour, nil, []time.Weekday{time.Saturday, time.Sunday})
+	if expected := time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC); !got.Equal(expected) {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+}
+
+func TestFeedService_SharedFeeds(t *testing.T) {
+	queries := setupTestDB(t)
+	ctx := context.Background()
+
+	var fetches int
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		fetches++
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0">
+  <channel>
+    <title>Shared Feed</title>
+  </channel>
+</rss>`))
+	}))
+	defer server.Close()
+
+	alice, bob := uuid.New(), uuid.New()
+	for _, user := range []struct {
+		id   uuid.UUID
+		name string
+	}{{alice, "alice"}, {bob, "bob"}} {
+		if _, err := queries.CreateUser(ctx, database.CreateUserParams{ID: user.id.String(), Name: user.name}); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+
+	feedService := &FeedService{Repo: queries}
+	feedURL := server.URL + "/feed.xml"
+
+	aliceFeed, err := feedService.CreateFeed(ctx, CreateFeedParams{Url: feedURL, UserID: alice})
+	if err != nil {
+		t.Fatalf("Failed to create feed: %v", err)
+	}
+
+	// Bob subscribing to the same URL shares the feed instead of failing
+	bobFeed, err := feedService.CreateFeed(ctx, CreateFeedParams{Url: feedURL, UserID: bob})
+	if err != nil {
+		t.Fatalf("Expected second user to follow existing feed, got %v", err)
+	}
+	if bobFeed.ID != aliceFeed.ID {
+		t.Errorf("Expected the same feed, got %s and %s", aliceFeed.ID, bobFeed.ID)
+	}
+
+	// Following again is a no-op
+	if _, err := feedService.CreateFeed(ctx, CreateFeedParams{Url: feedURL, UserID: bob}); err != nil {
+		t.Fatalf("Expected repeated follow to succeed, got %v", err)
+	}
+	if fetches != 1 {
+		t.Errorf("Expected the feed to be fetched once, got %d", fetches)
+	}
+
+	for _, userID := range []uuid.UUID{alice, bob} {
+		feeds, err := feedService.ListFeeds(ctx, userID)
+		if err != nil {
+			t.Fatalf("Failed to list feeds: %v", err)
+		}
+		if len(feeds) != 1 || feeds[0].ID != aliceFeed.ID {
+			t.Errorf("Expected user %s to follow exactly the shared feed, got %+v", userID, feeds)
+		}
+	}
+
+	// Alice unsubscribing leaves the feed in place for Bob
+	if err := feedService.Unsubscribe(ctx, alice, aliceFeed.ID); err != nil {
+		t.Fatalf("Failed to unsubscribe: %v", err)
+	}
+	if feeds, _ := feedService.ListFeeds(ctx, alice); len(feeds) != 0 {
+		t.Errorf("Expected alice to follow no feeds, got %d", len(feeds))
+	}
+	if _, err := queries.GetFeedByID(ctx, aliceFeed.ID.String()); err != nil {
+		t.Errorf("Expected feed to survive while bob follows it, got %v", err)
+	}
+
+	if err := feedService.Unsubscribe(ctx, alice, aliceFeed.ID); !errors.Is(err, ErrNotFollowing) {
+		t.Errorf("Expected ErrNotFollowing, got %v", err)
+	}
+
+	// The last follower leaving deletes the feed
+	if err := feedService.Unsubscribe(ctx, bob, aliceFeed.ID); err != nil {
+		t.Fatalf("Failed to unsubscribe: %v", err)
+	}
+	if _, err := queries.GetFeedByID(ctx, aliceFeed.ID.String()); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected unfollowed feed to be deleted, got %v", err)
+	}
+}
+
+func TestFeedService_ScrapeFeed_UndatedItems(t *testing.T) {
+	queries := setupTestDB(t)
+	ctx := context.Background()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0">
+  <channel>
+    <title>Undated</title>
+    <item>
+      <title>No date</title>
+      <link>http://example.com/undated</link>
+    </item>
+    <item>
+      <title>Bad date</title>
+      <link>http://example.com/bad-date</link>
+      <pubDate>the other day</pubDate>
+    </item>
+  </channel>
+</rss>`))
+	}))
+	defer server.Close()
+
+	userID := uuid.New().String()
+	if _, err := queries.CreateUser(ctx, database.CreateUserParams{ID: userID, Name: "Test User"}); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	feed, err := queries.CreateFeed(ctx, database.CreateFeedParams{
+		ID:     uuid.New().String(),
+		Name:   "Undated",
+		Url:    server.URL,
+		UserID: sql.NullString{String: userID, Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create feed: %v", err)
+	}
+	if _, err := queries.FollowFeed(ctx, database.FollowFeedParams{ID: uuid.New().String(), UserID: userID, FeedID: feed.ID}); err != nil {
+		t.Fatalf("Failed to follow feed: %v", err)
+	}
+
+	feedService := &FeedService{Repo: queries}
+
+	before := time.Now().UTC().Add(-time.Second)
+	if _, err := feedService.scrapeFeed(ctx, feed); err != nil {
+		t.Fatalf("Failed to scrape feed: %v", err)
+	}
+	after := time.Now().UTC().Add(time.Second)
+
+	posts, err := queries.GetPostsByUser(ctx, database.GetPostsByUserParams{UserID: userID, Limit: 10})
+	if err != nil {
+		t.Fatalf("Failed to get posts: %v", err)
+	}
+	if len(posts) != 2 {
+		t.Fatalf("Expected undated items to be kept, got %d posts", len(posts))
+	}
+	firstSeen := make(map[string]time.Time)
+	for _, post := range posts {
+		if post.PublishedAt.Before(before) || post.PublishedAt.After(after) {
+			t.Errorf("Expected %q to be dated when first seen, got %s", post.Title, post.PublishedAt)
+		}
+		firstSeen[post.Url] = post.PublishedAt
+	}
+
+	// A later fetch must not move the first-seen date
+	time.Sleep(10 * time.Millisecond)
+	if _, err := feedService.scrapeFeed(ctx, feed); err != nil {
+		t.Fatalf("Failed to scrape feed again: %v", err)
+	}
+	posts, err = queries.GetPostsByUser(ctx, database.GetPostsByUserParams{UserID: userID, Limit: 10})
+	if err != nil {
+		t.Fatalf("Failed to get posts: %v", err)
+	}
+	for _, post := range posts {
+		if !post.PublishedAt.Equal(firstSeen[post.Url]) {
+			t.Errorf("Expected %q to keep its first-seen date %s, got %s", post.Title, firstSeen[post.Url], post.PublishedAt)
+		}
+	}
+}
+
+func TestFeedService_ScrapeFeed_IncompletePagedFeed(t *testing.T) {
+	queries := setupTestDB(t)
+	ctx := context.Background()
+
+	// The second page of the feed is missing
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/feed.json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/feed+json")
+		w.Write([]byte(`{"version": "https://jsonfeed.org/version/1.1", "title": "Paged", "next_url": "/page/2.json", "items": [
+			{"id": "1", "url": "http://example.com/paged/1", "title": "One", "date_published": "2024-01-03T00:00:00Z"}]}`))
+	}))
+	defer server.Close()
+
+	userID := uuid.New().String()
+	if _, err := queries.CreateUser(ctx, database.CreateUserParams{ID: userID, Name: "Test User"}); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	feed, err := queries.CreateFeed(ctx, database.CreateFeedParams{
+		ID:     uuid.New().String(),
+		Name:   "Paged",
+		Url:    server.URL + "/feed.json",
+		UserID: sql.NullString{String: userID, Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create feed: %v", err)
+	}
+	if _, err := queries.FollowFeed(ctx, database.FollowFeedParams{ID: uuid.New().String(), UserID: userID, FeedID: feed.ID}); err != nil {
+		t.Fatalf("Failed to follow feed: %v", err)
+	}
+
+	feedService := &FeedService{Repo: queries}
+	if _, err := feedService.scrapeFeed(ctx, feed); err != nil {
+		t.Fatalf("Failed to scrape feed: %v", err)
+	}
+
+	posts, err := queries.GetPostsByUser(ctx, database.GetPostsByUserParams{UserID: userID, Limit: 10})
+	if err != nil {
+		t.Fatalf("Failed to get posts: %v", err)
+	}
+	if len(posts) != 1 {
+		t.Errorf("Expected the posts of the first page to be stored, got %d posts", len(posts))
+	}
+
+	// The missing page is shown on the feed without counting as a failure
+	updated, err := queries.GetFeedByID(ctx, feed.ID)
+	if err != nil {
+		t.Fatalf("Failed to get feed: %v", err)
+	}
+	if !strings.Contains(updated.LastError.String, "404") {
+		t.Errorf("Expected the missing page to be recorded, got %q", updated.LastError.String)
+	}
+	if updated.ConsecutiveFailures != 0 || !updated.LastSuccessAt.Valid {
+		t.Errorf("Expected the fetch to count as a success, got %d failures", updated.ConsecutiveFailures)
+	}
+}
+
+func TestFeedService_ScrapeFeed_PostIdentity(t *testing.T) {
+	queries := setupTestDB(t)
+	ctx := context.Background()
+
+	var (
+		mu         sync.Mutex
+		linkFormat = "https://example.com/%s"
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		format := linkFormat
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/rss+xml")
+		fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0">
+  <channel>
+    <title>Identity</title>
+    <item>
+      <title>With GUID</title>
+      <guid isPermaLink="false">post-1</guid>
+      <link>%s</link>
+      <pubDate>Wed, 21 Oct 2015 07:28:00 GMT</pubDate>
+    </item>
+    <item>
+      <title>Shared link</title>
+      <link>https://example.com/shared</link>
+      <pubDate>Wed, 21 Oct 2015 07:28:00 GMT</pubDate>
+    </item>
+    <item>
+      <title>Nothing but a title</title>
+    </item>
+  </channel>
+</rss>`, fmt.Sprintf(format, "post-1"))
+	}))
+	defer server.Close()
+
+	userID := uuid.New().String()
+	if _, err := queries.CreateUser(ctx, database.CreateUserParams{ID: userID, Name: "Test User"}); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	var feeds []database.Feed
+	for _, path := range []string{"/a.xml", "/b.xml"} {
+		feed, err := queries.CreateFeed(ctx, database.CreateFeedParams{
+			ID:   uuid.New().String(),
+			Name: path,
+			Url:  server.URL + path,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create feed: %v", err)
+		}
+		if _, err := queries.FollowFeed(ctx, database.FollowFeedParams{ID: uuid.New().String(), UserID: userID, FeedID: feed.ID}); err != nil {
+			t.Fatalf("Failed to follow feed: %v", err)
+		}
+		feeds = append(feeds, feed)
+	}
+
+	// A post stored before GUIDs were tracked, keyed by its link
+	if _, err := queries.CreatePost(ctx, database.CreatePostParams{
+		ID:          uuid.New().String(),
+		Title:       "With GUID",
+		Url:         "https://example.com/post-1",
+		PublishedAt: time.Date(2015, 10, 21, 7, 28, 0, 0, time.UTC),
+		FeedID:      feeds[0].ID,
+		IdentityKey: "link:https://example.com/post-1",
+	}); err != nil {
+		t.Fatalf("Failed to create legacy post: %v", err)
+	}
+
+	feedService := &FeedService{Repo: queries}
+	countPosts := func() map[string]int {
+		t.Helper()
+		posts, err := queries.GetPostsByUser(ctx, database.GetPostsByUserParams{UserID: userID, Limit: 100})
+		if err != nil {
+			t.Fatalf("Failed to get posts: %v", err)
+		}
+		counts := make(map[string]int)
+		for _, post := range posts {
+			counts[post.FeedID+" "+post.Title]++
+		}
+		return counts
+	}
+	scrapeAll := func() {
+		t.Helper()
+		for _, feed := range feeds {
+			if _, err := feedService.scrapeFeed(ctx, feed); err != nil {
+				t.Fatalf("Failed to scrape feed: %v", err)
+			}
+		}
+	}
+
+	scrapeAll()
+
+	// The link format changes, but the GUIDs don't
+	mu.Lock()
+	linkFormat = "https://example.com/blog/%s/"
+	mu.Unlock()
+	scrapeAll()
+
+	counts := countPosts()
+	for _, feed := range feeds {
+		for _, title := range []string{"With GUID", "Shared link", "Nothing but a title"} {
+			if got := counts[feed.ID+" "+title]; got != 1 {
+				t.Errorf("Expected one %q post in feed %s, got %d", title, feed.Name, got)
+			}
+		}
+	}
+
+	posts, err := queries.GetPostsByUser(ctx, database.GetPostsByUserParams{UserID: userID, Limit: 100})
+	if err != nil {
+		t.Fatalf("Failed to get posts: %v", err)
+	}
+	for _, post := range posts {
+		if post.Title == "With GUID" && (post.Guid.String != "post-1" || post.IdentityKey != "guid:post-1") {
+			t.Errorf("Expected post to be keyed by its GUID, got guid %q and key %q", post.Guid.String, post.IdentityKey)
+		}
+	}
+}
+
+func TestFeedService_ScrapeFeed_EditedPosts(t *testing.T) {
+	db := openTestDB(t)
+	queries := database.New(db)
+	ctx := context.Background()
+
+	var (
+		mu          sync.Mutex
+		title       = "Original title"
+		description = "Original description"
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/rss+xml")
+		fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0">
+  <channel>
+    <title>Edits</title>
+    <item>
+      <title>%s</title>
+      <guid>https://example.com/post</guid>
+      <description>%s</description>
+      <pubDate>Wed, 21 Oct 2015 07:28:00 GMT</pubDate>
+    </item>
+  </channel>
+</rss>`, title, description)
+	}))
+	defer server.Close()
+	edit := func(newTitle, newDescription string) {
+		mu.Lock()
+		defer mu.Unlock()
+		title, description = newTitle, newDescription
+	}
+
+	userID, otherID := uuid.New(), uuid.New()
+	feed, err := queries.CreateFeed(ctx, database.CreateFeedParams{ID: uuid.New().String(), Name: "Edits", Url: server.URL})
+	if err != nil {
+		t.Fatalf("Failed to create feed: %v", err)
+	}
+	for _, user := range []uuid.UUID{userID, otherID} {
+		if _, err := queries.CreateUser(ctx, database.CreateUserParams{ID: user.String(), Name: user.String()}); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+		if _, err := queries.FollowFeed(ctx, database.FollowFeedParams{ID: uuid.New().String(), UserID: user.String(), FeedID: feed.ID}); err != nil {
+			t.Fatalf("Failed to follow feed: %v", err)
+		}
+	}
+
+	feedService := &FeedService{Repo: queries, DB: db}
+	readPostService := &ReadPostService{Repo: queries}
+	scrape := func() database.Post {
+		t.Helper()
+		// Reload the feed so settings changes are picked up
+		current, err := queries.GetFeedByID(ctx, feed.ID)
+		if err != nil {
+			t.Fatalf("Failed to get feed: %v", err)
+		}
+		if _, err := feedService.scrapeFeed(ctx, current); err != nil {
+			t.Fatalf("Failed to scrape feed: %v", err)
+		}
+		posts, err := queries.GetPostsByUser(ctx, database.GetPostsByUserParams{UserID: userID.String(), Limit: 10})
+		if err != nil {
+			t.Fatalf("Failed to get posts: %v", err)
+		}
+		if len(posts) != 1 {
+			t.Fatalf("Expected 1 post, got %d", len(posts))
+		}
+		return posts[0]
+	}
+	revisions := func(postID string) []string {
+		t.Helper()
+		rows, err := db.Query(`SELECT title FROM post_revisions WHERE post_id = ? ORDER BY created_at, rowid`, postID)
+		if err != nil {
+			t.Fatalf("Failed to get revisions: %v", err)
+		}
+		defer rows.Close()
+		var titles []string
+		for rows.Next() {
+			var title string
+			if err := rows.Scan(&title); err != nil {
+				t.Fatalf("Failed to scan revision: %v", err)
+			}
+			titles = append(titles, title)
+		}
+		return titles
+	}
+	isRead := func(postID string, user uuid.UUID) bool {
+		t.Helper()
+		var count int
+		if err := db.QueryRow(`SELECT count(*) FROM post_reads WHERE post_id = ? AND user_id = ?`, postID, user.String()).Scan(&count); err != nil {
+			t.Fatalf("Failed to check read state: %v", err)
+		}
+		return count > 0
+	}
+
+	post := scrape()
+	for _, user := range []uuid.UUID{userID, otherID} {
+		if err := readPostService.Save(ctx, uuid.MustParse(post.ID), user); err != nil {
+			t.Fatalf("Failed to mark post read: %v", err)
+		}
+	}
+
+	// An unchanged item is left alone
+	if post = scrape(); len(revisions(post.ID)) != 0 {
+		t.Errorf("Expected no revisions for an unchanged post, got %v", revisions(post.ID))
+	}
+
+	// An edit updates the post and keeps the previous version
+	edit("Corrected title", "Corrected description")
+	post = scrape()
+	if post.Title != "Corrected title" || post.Description.String != "Corrected description" {
+		t.Errorf("Expected post to be updated, got %q / %q", post.Title, post.Description.String)
+	}
+	if got := revisions(post.ID); len(got) != 1 || got[0] != "Original title" {
+		t.Errorf("Expected the original version to be kept, got %v", got)
+	}
+	if !isRead(post.ID, userID) || !isRead(post.ID, otherID) {
+		t.Errorf("Expected post to stay read by default")
+	}
+
+	// With the setting on, an edit marks the post unread again for the
+	// follower who turned it on only
+	if err := feedService.SetMarkUnreadOnUpdate(ctx, userID, uuid.MustParse(feed.ID), true); err != nil {
+		t.Fatalf("Failed to change setting: %v", err)
+	}
+	edit("Corrected title", "Corrected description, again")
+	post = scrape()
+	if got := revisions(post.ID); len(got) != 2 {
+		t.Errorf("Expected 2 revisions, got %v", got)
+	}
+	if isRead(post.ID, userID) {
+		t.Errorf("Expected edited post to be marked unread")
+	}
+	if !isRead(post.ID, otherID) {
+		t.Errorf("Expected edited post to stay read for the other follower")
+	}
+
+	// An edit that can't be stored in full leaves the post as it was
+	if _, err := db.Exec(`DROP TABLE post_reads`); err != nil {
+		t.Fatalf("Failed to drop table: %v", err)
+	}
+	edit("Lost title", "Lost description")
+	post = scrape()
+	if post.Title != "Corrected title" || len(revisions(post.ID)) != 2 {
+		t.Errorf("Expected the failed edit to be rolled back, got %q with revisions %v", post.Title, revisions(post.ID))
+	}
+}
+
+func TestFeedService_ScrapeFeed_Enclosures(t *testing.T) {
+	db := openTestDB(t)
+	queries := database.New(db)
+	ctx := context.Background()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd" xmlns:media="http://search.yahoo.com/mrss/">
+  <channel>
+    <title>Podcast</title>
+    <item>
+      <title>Audio episode</title>
+      <guid>https://example.com/episodes/2</guid>
+      <pubDate>Wed, 21 Oct 2015 07:28:00 GMT</pubDate>
+      <media:thumbnail url="https://cdn.example.com/2.jpg"/>
+      <media:content url="https://cdn.example.com/2.jpg" type="image/jpeg"/>
+      <enclosure url="https://cdn.example.com/2.mp3" length="1234" type="audio/mpeg"/>
+      <itunes:duration>30:00</itunes:duration>
+    </item>
+    <item>
+      <title>Photo post</title>
+      <guid>https://example.com/episodes/1</guid>
+      <pubDate>Tue, 20 Oct 2015 07:28:00 GMT</pubDate>
+      <media:content url="https://cdn.example.com/1.png" medium="image"/>
+    </item>
+  </channel>
+</rss>`))
+	}))
+	defer server.Close()
+
+	userID := uuid.New()
+	if _, err := queries.CreateUser(ctx, database.CreateUserParams{ID: userID.String(), Name: "Test User"}); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	feed, err := queries.CreateFeed(ctx, database.CreateFeedParams{ID: uuid.New().String(), Name: "Podcast", Url: server.URL})
+	if err != nil {
+		t.Fatalf("Failed to create feed: %v", err)
+	}
+	if _, err := queries.FollowFeed(ctx, database.FollowFeedParams{ID: uuid.New().String(), UserID: userID.String(), FeedID: feed.ID}); err != nil {
+		t.Fatalf("Failed to follow feed: %v", err)
+	}
+
+	feedService := &FeedService{Repo: queries, DB: db}
+	// A second fetch must not duplicate the enclosures
+	for range 2 {
+		if _, err := feedService.scrapeFeed(ctx, feed); err != nil {
+			t.Fatalf("Failed to scrape feed: %v", err)
+		}
+	}
+
+	var count int
+	if err := db.QueryRow(`SELECT count(*) FROM post_enclosures`).Scan(&count); err != nil {
+		t.Fatalf("Failed to count enclosures: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("Expected 3 enclosures, got %d", count)
+	}
+
+	postService := &PostService{Repo: queries}
+	posts, err := postService.SearchPosts(ctx, userID, SearchOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get posts: %v", err)
+	}
+	if len(posts) != 2 {
+		t.Fatalf("Expected 2 posts, got %d", len(posts))
+	}
+
+	// The player gets the audio even though an image was listed first
+	episode := posts[0]
+	if episode.Thumbnail != "https://cdn.example.com/2.jpg" {
+		t.Errorf("Expected episode thumbnail, got %q", episode.Thumbnail)
+	}
+	if episode.Enclosure == nil {
+		t.Fatalf("Expected episode to have an enclosure")
+	}
+	if episode.Enclosure.URL != "https://cdn.example.com/2.mp3" || episode.Enclosure.MimeType != "audio/mpeg" || episode.Enclosure.Duration != 30*time.Minute {
+		t.Errorf("Unexpected enclosure %+v", episode.Enclosure)
+	}
+
+	photo := posts[1]
+	if photo.Thumbnail != "https://cdn.example.com/1.png" {
+		t.Errorf("Expected photo thumbnail, got %q", photo.Thumbnail)
+	}
+	if photo.Enclosure != nil {
+		t.Errorf("Expected no playable enclosure for an image, got %+v", photo.Enclosure)
+	}
+}
+
+func TestFeedService_ScrapeFeed_Content(t *testing.T) {
+	db := openTestDB(t)
+	queries := database.New(db)
+	ctx := context.Background()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/">
+  <channel>
+    <title>Content</title>
+    <link>https://example.com/</link>
+    <item>
+      <title>Post</title>
+      <guid>https://example.com/post</guid>
+      <description>A &lt;b&gt;summary&lt;/b&gt;</description>
+      <content:encoded><![CDATA[<p onclick="steal()">The <img src="/a.png">body</p><script>steal()</script>]]></content:encoded>
+      <pubDate>Wed, 21 Oct 2015 07:28:00 GMT</pubDate>
+    </item>
+  </channel>
+</rss>`))
+	}))
+	defer server.Close()
+
+	userID := uuid.New()
+	if _, err := queries.CreateUser(ctx, database.CreateUserParams{ID: userID.String(), Name: "Test User"}); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	feed, err := queries.CreateFeed(ctx, database.CreateFeedParams{ID: uuid.New().String(), Name: "Content", Url: server.URL})
+	if err != nil {
+		t.Fatalf("Failed to create feed: %v", err)
+	}
+	if _, err := queries.FollowFeed(ctx, database.FollowFeedParams{ID: uuid.New().String(), UserID: userID.String(), FeedID: feed.ID}); err != nil {
+		t.Fatalf("Failed to follow feed: %v", err)
+	}
+
+	feedService := &FeedService{Repo: queries, DB: db}
+	if _, err := feedService.scrapeFeed(ctx, feed); err != nil {
+		t.Fatalf("Failed to scrape feed: %v", err)
+	}
+
+	postService := &PostService{Repo: queries}
+	posts, err := postService.SearchPosts(ctx, userID, SearchOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get posts: %v", err)
+	}
+	if len(posts) != 1 {
+		t.Fatalf("Expected 1 post, got %d", len(posts))
+	}
+	post := posts[0]
+	if post.Description != "A summary" {
+		t.Errorf("Expected a plain-text description, got %q", post.Description)
+	}
+	if !post.HasContent {
+		t.Errorf("Expected post to have content")
+	}
+
+	content, err := postService.GetContent(ctx, userID, post.ID)
+	if err != nil {
+		t.Fatalf("Failed to get content: %v", err)
+	}
+	if expected := `<p>The <img src="https://example.com/a.png">body</p>`; string(content) != expected {
+		t.Errorf("Expected content %s, got %s", expected, content)
+	}
+
+	// Only followers of the feed can read the content
+	if _, err := postService.GetContent(ctx, uuid.New(), post.ID); !errors.Is(err, ErrPostNotFound) {
+		t.Errorf("Expected ErrPostNotFound for another user, got %v", err)
+	}
+
+	// A post whose hash was cleared, as when the stored format changes, is
+	// rewritten on the next fetch without recording an edit
+	if _, err := db.Exec(`UPDATE posts SET content_hash = NULL, description = 'A <b>summary</b>', content_html = NULL`); err != nil {
+		t.Fatalf("Failed to clear hash: %v", err)
+	}
+	if _, err := feedService.scrapeFeed(ctx, feed); err != nil {
+		t.Fatalf("Failed to scrape feed: %v", err)
+	}
+	var revisions int
+	if err := db.QueryRow(`SELECT count(*) FROM post_revisions`).Scan(&revisions); err != nil {
+		t.Fatalf("Failed to count revisions: %v", err)
+	}
+	if revisions != 0 {
+		t.Errorf("Expected no revisions, got %d", revisions)
+	}
+	if content, err := postService.GetContent(ctx, userID, post.ID); err != nil || content == "" {
+		t.Errorf("Expected content to be restored, got %q (%v)", content, err)
+	}
+}
+
+func TestFeedService_CreateFeed_Discovery(t *testing.T) {
+	queries := setupTestDB(t)
+	ctx := context.Background()
+
+	pages := map[string]string{
+		"/single/": `<html><head><link rel="alternate" type="application/rss+xml" href="/single/rss.xml"></head></html>`,
+		"/several/": `<html><head>
+<link rel="alternate" type="application/rss+xml" title="RSS" href="/several/rss.xml">
+<link rel="alternate" type="application/atom+xml" title="Atom" href="/several/atom.xml">
+</head></html>`,
+		"/loop/":     `<html><head><link rel="alternate" type="application/rss+xml" href="/loop/page"></head></html>`,
+		"/loop/page": `<html><head><link rel="alternate" type="application/rss+xml" href="/loop/"></head></html>`,
+		"/empty/":    `<html><head><title>Nothing here</title></head></html>`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if page, ok := pages[r.URL.Path]; ok {
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, page)
+			return
+		}
+		if r.URL.Path != "/single/rss.xml" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/rss+xml")
+		fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"><channel><title>Discovered</title></channel></rss>`)
+	}))
+	defer server.Close()
+
+	userID := uuid.New()
+	if _, err := queries.CreateUser(ctx, database.CreateUserParams{ID: userID.String(), Name: "Test User"}); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	feedService := &FeedService{Repo: queries}
+
+	// A page linking to one feed subscribes to that feed
+	feed, err := feedService.CreateFeed(ctx, CreateFeedParams{Url: server.URL + "/single/", UserID: userID})
+	if err != nil {
+		t.Fatalf("CreateFeed failed: %v", err)
+	}
+	if feed.Url != server.URL+"/single/rss.xml" || feed.Name != "Discovered" {
+		t.Errorf("Expected the discovered feed, got %+v", feed)
+	}
+
+	// A page linking to several asks which one
+	_, err = feedService.CreateFeed(ctx, CreateFeedParams{Url: server.URL + "/several/", UserID: userID})
+	var choiceErr *FeedChoiceError
+	if !errors.As(err, &choiceErr) {
+		t.Fatalf("Expected a FeedChoiceError, got %v", err)
+	}
+	if len(choiceErr.Candidates) != 2 || choiceErr.Candidates[0].Title != "RSS" || choiceErr.Candidates[1].Title != "Atom" {
+		t.Errorf("Expected the RSS and Atom feeds, got %+v", choiceErr.Candidates)
+	}
+
+	// Pages linking to each other don't loop
+	var fetchErr *FetchError
+	if _, err := feedService.CreateFeed(ctx, CreateFeedParams{Url: server.URL + "/loop/", UserID: userID}); !errors.As(err, &fetchErr) || !errors.Is(err, feedparser.ErrNotFeed) {
+		t.Errorf("Expected a FetchError for a page linking to another page, got %v", err)
+	}
+
+	var httpErr *feedparser.HTTPError
+	if _, err := feedService.CreateFeed(ctx, CreateFeedParams{Url: server.URL + "/missing.xml", UserID: userID}); !errors.As(err, &fetchErr) || !errors.As(err, &httpErr) {
+		t.Errorf("Expected a FetchError for a missing feed, got %v", err)
+	}
+
+	if _, err := feedService.CreateFeed(ctx, CreateFeedParams{Url: server.URL + "/empty/", UserID: userID}); !errors.Is(err, ErrNoFeedFound) {
+		t.Errorf("Expected ErrNoFeedFound, got %v", err)
+	}
+
+	feeds, err := feedService.ListFeeds(ctx, userID)
+	if err != nil {
+		t.Fatalf("Failed to list feeds: %v", err)
+	}
+	if len(feeds) != 1 {
+		t.Errorf("Expected only the discovered feed to be followed, got %d feeds", len(feeds))
+	}
+}
+
+func TestFeedService_ScrapeFeed_PermanentRedirect(t *testing.T) {
+	queries := setupTestDB(t)
+	ctx := context.Background()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old.xml" {
+			http.Redirect(w, r, "/new.xml", http.StatusMovedPermanently)
+			return
+		}
+		w.Header().Set("Content-Type", "application/rss+xml")
+		fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0"><channel><title>Moved</title></channel></rss>`)
+	}))
+	defer server.Close()
+
+	userID := uuid.New().String()
+	if _, err := queries.CreateUser(ctx, database.CreateUserParams{ID: userID, Name: "Test User"}); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	feed, err := queries.CreateFeed(ctx, database.CreateFeedParams{
+		ID:     uuid.New().String(),
+		Name:   "Moved",
+		Url:    server.URL + "/old.xml",
+		UserID: sql.NullString{String: userID, Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create feed: %v", err)
+	}
+
+	feedService := &FeedService{Repo: queries}
+	for i := 1; i <= redirectStableFetches; i++ {
+		if _, err := feedService.scrapeFeed(ctx, feed); err != nil {
+			t.Fatalf("Failed to scrape feed: %v", err)
+		}
+		feed, err = queries.GetFeedByID(ctx, feed.ID)
+		if err != nil {
+			t.Fatalf("Failed to get feed: %v", err)
+		}
+		if i < redirectStableFetches {
+			if feed.Url != server.URL+"/old.xml" || feed.RedirectCount != int64(i) {
+				t.Errorf("Expected the feed to stay put after %d redirects, got %s (%d)", i, feed.Url, feed.RedirectCount)
+			}
+		}
+	}
+
+	if feed.Url != server.URL+"/new.xml" {
+		t.Errorf("Expected the feed to move to the new URL, got %s", feed.Url)
+	}
+	if feed.RedirectUrl.Valid || feed.RedirectCount != 0 {
+		t.Errorf("Expected the redirect to be cleared once moved, got %v (%d)", feed.RedirectUrl, feed.RedirectCount)
+	}
+}
+
+func TestFeedService_ScrapeFeeds_GoneFeed(t *testing.T) {
+	queries := setupTestDB(t)
+	ctx := context.Background()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer server.Close()
+
+	userID := uuid.New()
+	if _, err := queries.CreateUser(ctx, database.CreateUserParams{ID: userID.String(), Name: "Test User"}); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	feed, err := queries.CreateFeed(ctx, database.CreateFeedParams{
+		ID:     uuid.New().String(),
+		Name:   "Gone",
+		Url:    server.URL,
+		UserID: sql.NullString{String: userID.String(), Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create feed: %v", err)
+	}
+	if _, err := queries.FollowFeed(ctx, database.FollowFeedParams{ID: uuid.New().String(), UserID: userID.String(), FeedID: feed.ID}); err != nil {
+		t.Fatalf("Failed to follow feed: %v", err)
+	}
+
+	feedService := &FeedService{Repo: queries}
+	summary, err := feedService.ScrapeFeeds(ctx)
+	if err != nil {
+		t.Fatalf("Failed to scrape feeds: %v", err)
+	}
+	if len(summary.Failed) != 1 {
+		t.Fatalf("Expected the gone feed to fail, got %v", summary)
+	}
+
+	feeds, err := feedService.ListFeeds(ctx, userID)
+	if err != nil {
+		t.Fatalf("Failed to list feeds: %v", err)
+	}
+	if len(feeds) != 1 || feeds[0].DeadAt == nil || feeds[0].LastError == "" {
+		t.Fatalf("Expected the feed to be listed as dead, got %+v", feeds)
+	}
+
+	// Dead feeds are never due again
+	due, err := queries.GetFeedsToFetch(ctx, database.GetFeedsToFetchParams{
+		Cutoff: sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true},
+		Now:    sql.NullTime{Time: time.Now().Add(365 * 24 * time.Hour), Valid: true},
+	})
+	if err != nil {
+		t.Fatalf("Failed to get feeds to fetch: %v", err)
+	}
+	if len(due) != 0 {
+		t.Errorf("Expected no feeds to fetch, got %d", len(due))
+	}
+
+	// Followers can revive it from the settings page
+	if err := feedService.ReviveFeed(ctx, uuid.New(), uuid.MustParse(feed.ID)); !errors.Is(err, ErrNotFollowing) {
+		t.Errorf("Expected ErrNotFollowing for a non-follower, got %v", err)
+	}
+	if err := feedService.ReviveFeed(ctx, userID, uuid.MustParse(feed.ID)); err != nil {
+		t.Fatalf("Failed to revive feed: %v", err)
+	}
+	isDue := func() bool {
+		t.Helper()
+		due, err := queries.GetFeedsToFetch(ctx, database.GetFeedsToFetchParams{
+			Cutoff: sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true},
+			Now:    sql.NullTime{Time: time.Now(), Valid: true},
+		})
+		if err != nil {
+			t.Fatalf("Failed to get feeds to fetch: %v", err)
+		}
+		return len(due) == 1
+	}
+	if feeds, _ := feedService.ListFeeds(ctx, userID); len(feeds) != 1 || feeds[0].DeadAt != nil || !isDue() {
+		t.Errorf("Expected the revived feed to be due again, got %+v", feeds)
+	}
+
+	// Subscribing to it again revives it too
+	if _, err := feedService.ScrapeFeeds(ctx); err != nil {
+		t.Fatalf("Failed to scrape feeds: %v", err)
+	}
+	if feeds, _ := feedService.ListFeeds(ctx, userID); len(feeds) != 1 || feeds[0].DeadAt == nil {
+		t.Fatalf("Expected the feed to be dead again, got %+v", feeds)
+	}
+	if _, err := feedService.CreateFeed(ctx, CreateFeedParams{Url: server.URL, UserID: userID}); err != nil {
+		t.Fatalf("Failed to subscribe again: %v", err)
+	}
+	if feeds, _ := feedService.ListFeeds(ctx, userID); len(feeds) != 1 || feeds[0].DeadAt != nil || !isDue() {
+		t.Errorf("Expected subscribing again to revive the feed, got %+v", feeds)
+	}
+}
+
+func TestFeedService_IsDead(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	feedService := &FeedService{DeadAfter: 7 * 24 * time.Hour}
+	failing := errors.New("connection refused")
+
+	tests := []struct {
+		name string
+		feed database.Feed
+		err  error
+		want bool
+	}{
+		{
+			name: "gone",
+			feed: database.Feed{CreatedAt: now},
+			err:  fmt.Errorf("failed to fetch feed: %w", &feedparser.HTTPError{StatusCode: http.StatusGone}),
+			want: true,
+		},
+		{
+			name: "first failure of an old feed",
+			feed: database.Feed{CreatedAt: now.AddDate(-1, 0, 0), LastSuccessAt: sql.NullTime{Time: now.AddDate(0, 0, -30), Valid: true}},
+			err:  failing,
+			want: false,
+		},
+		{
+			name: "failing since the last success",
+			feed: database.Feed{CreatedAt: now.AddDate(-1, 0, 0), ConsecutiveFailures: 40, LastSuccessAt: sql.NullTime{Time: now.AddDate(0, 0, -8), Valid: true}},
+			err:  failing,
+			want: true,
+		},
+		{
+			name: "failing briefly",
+			feed: database.Feed{CreatedAt: now.AddDate(-1, 0, 0), ConsecutiveFailures: 3, LastSuccessAt: sql.NullTime{Time: now.AddDate(0, 0, -1), Valid: true}},
+			err:  failing,
+			want: false,
+		},
+		{
+			name: "never fetched since being added",
+			feed: database.Feed{CreatedAt: now.AddDate(0, 0, -8), ConsecutiveFailures: 20},
+			err:  failing,
+			want: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := feedService.isDead(tc.feed, tc.err, now); got != tc.want {
+				t.Errorf("Expected isDead to be %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
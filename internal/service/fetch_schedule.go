@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nrbernard/gator/internal/database"
+	"github.com/nrbernard/gator/internal/feedparser"
+)
+
+const (
+	defaultFetchInterval    = time.Hour
+	defaultMinFetchInterval = 15 * time.Minute
+	defaultMaxFetchInterval = 24 * time.Hour
+
+	// cadenceSampleSize is how many recent posts are used to estimate how
+	// often a feed publishes.
+	cadenceSampleSize = 20
+)
+
+func (s *FeedService) fetchIntervalBounds() (time.Duration, time.Duration) {
+	minInterval := s.MinFetchInterval
+	if minInterval <= 0 {
+		minInterval = defaultMinFetchInterval
+	}
+	maxInterval := s.MaxFetchInterval
+	if maxInterval <= 0 {
+		maxInterval = defaultMaxFetchInterval
+	}
+	return minInterval, max(minInterval, maxInterval)
+}
+
+// fetchInterval works out how long to wait before polling feed again, based
+// on how often it has published recently and on the publisher's own hints.
+func (s *FeedService) fetchInterval(ctx context.Context, feed database.Feed, hints feedparser.UpdateHints) (time.Duration, error) {
+	minInterval, maxInterval := s.fetchIntervalBounds()
+
+	dates, err := s.Repo.GetRecentPostDatesForFeed(ctx, database.GetRecentPostDatesForFeedParams{
+		FeedID: feed.ID,
+		Limit:  cadenceSampleSize,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get post history: %w", err)
+	}
+
+	interval, ok := cadenceInterval(dates)
+	if !ok {
+		interval = defaultFetchInterval
+	}
+
+	// Never poll more often than the publisher asked
+	interval = max(interval, hints.MinInterval())
+
+	return min(max(interval, minInterval), maxInterval), nil
+}
+
+// effectiveInterval applies a manual override, if any, to the interval that
+// was last computed for the feed.
+func (s *FeedService) effectiveInterval(feed database.Feed) time.Duration {
+	minInterval, maxInterval := s.fetchIntervalBounds()
+
+	interval := defaultFetchInterval
+	if feed.FetchIntervalSeconds.Valid {
+		interval = time.Duration(feed.FetchIntervalSeconds.Int64) * time.Second
+	}
+	if feed.FetchIntervalOverrideSeconds.Valid {
+		interval = time.Duration(feed.FetchIntervalOverrideSeconds.Int64) * time.Second
+	}
+
+	return min(max(interval, minInterval), maxInterval)
+}
+
+// cadenceInterval estimates a polling interval as half the median gap
+// between recent posts, so a new post is usually picked up within half a
+// publishing cycle. It reports false when there is not enough history.
+func cadenceInterval(dates []time.Time) (time.Duration, bool) {
+	gap, ok := medianPostGap(dates)
+	return gap / 2, ok
+}
+
+// medianPostGap returns the typical time between posts published at dates,
+// reporting false when there are too few of them to tell.
+func medianPostGap(dates []time.Time) (time.Duration, bool) {
+	if len(dates) < 2 {
+		return 0, false
+	}
+
+	sorted := slices.Clone(dates)
+	slices.SortFunc(sorted, func(a, b time.Time) int { return b.Compare(a) })
+
+	gaps := make([]time.Duration, 0, len(sorted)-1)
+	for i := 1; i < len(sorted); i++ {
+		if gap := sorted[i-1].Sub(sorted[i]); gap > 0 {
+			gaps = append(gaps, gap)
+		}
+	}
+	if len(gaps) == 0 {
+		return 0, false
+	}
+
+	slices.Sort(gaps)
+	return gaps[len(gaps)/2], true
+}
+
+// nextFetchTime returns the first time at least interval after from that is
+// not inside one of the feed's skipHours or skipDays. Both are in GMT as the
+// RSS spec requires.
+func nextFetchTime(from time.Time, interval time.Duration, skipHours []int, skipDays []time.Weekday) time.Time {
+	next := from.Add(interval).UTC()
+
+	// A week of hours is enough to get past any combination of skips
+	for i := 0; i < 7*24; i++ {
+		if !slices.Contains(skipHours, next.Hour()) && !slices.Contains(skipDays, next.Weekday()) {
+			break
+		}
+		next = next.Truncate(time.Hour).Add(time.Hour)
+	}
+
+	return next
+}
+
+func formatSkipHours(hours []int) sql.NullString {
+	parts := make([]string, len(hours))
+	for i, hour := range hours {
+		parts[i] = strconv.Itoa(hour)
+	}
+	return sql.NullString{String: strings.Join(parts, ","), Valid: len(parts) > 0}
+}
+
+func formatSkipDays(days []time.Weekday) sql.NullString {
+	parts := make([]string, len(days))
+	for i, day := range days {
+		parts[i] = strconv.Itoa(int(day))
+	}
+	return sql.NullString{String: strings.Join(parts, ","), Valid: len(parts) > 0}
+}
+
+func parseSkipHours(value sql.NullString) []int {
+	var hours []int
+	for _, part := range strings.Split(value.String, ",") {
+		if hour, err := strconv.Atoi(part); err == nil {
+			hours = append(hours, hour)
+		}
+	}
+	return hours
+}
+
+func parseSkipDays(value sql.NullString) []time.Weekday {
+	var days []time.Weekday
+	for _, part := range strings.Split(value.String, ",") {
+		if day, err := strconv.Atoi(part); err == nil {
+			days = append(days, time.Weekday(day))
+		}
+	}
+	return days
+}
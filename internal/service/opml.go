@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nrbernard/gator/internal/opml"
+)
+
+// ImportSummary reports what happened to each feed in an OPML import.
+type ImportSummary struct {
+	// Created feeds were new to gator and have been fetched and followed.
+	Created []string
+	// Followed feeds already existed and are now followed by the user.
+	Followed []string
+	// AlreadyFollowing feeds were followed before the import.
+	AlreadyFollowing []string
+	Failed           []ImportFailure
+}
+
+// ImportFailure describes an OPML entry that could not be subscribed to.
+type ImportFailure struct {
+	Title string
+	Url   string
+	Err   error
+}
+
+func (s ImportSummary) String() string {
+	return fmt.Sprintf("%d created, %d followed, %d already followed, %d failed",
+		len(s.Created), len(s.Followed), len(s.AlreadyFollowing), len(s.Failed))
+}
+
+type importResult int
+
+const (
+	importCreated importResult = iota
+	importFollowed
+	importAlreadyFollowing
+)
+
+// ImportOPML subscribes the user to every feed in an OPML document. Known
+// feeds are followed directly; unknown ones are fetched and created through
+// CreateFeed. A bad entry is reported in the summary without stopping the
+// rest of the import.
+func (s *FeedService) ImportOPML(ctx context.Context, userID uuid.UUID, r io.Reader) (ImportSummary, error) {
+	var summary ImportSummary
+
+	subscriptions, err := opml.Parse(r)
+	if err != nil {
+		return summary, err
+	}
+
+	// Keep the first entry for feeds listed more than once
+	seen := make(map[string]bool)
+	unique := subscriptions[:0]
+	for _, sub := range subscriptions {
+		if seen[sub.XMLURL] {
+			continue
+		}
+		seen[sub.XMLURL] = true
+		unique = append(unique, sub)
+	}
+	subscriptions = unique
+
+	maxConcurrency := s.MaxConcurrency
+	if maxConcurrency <= 0 {
+		maxConcurrency = defaultMaxConcurrency
+	}
+	maxPerHost := s.MaxPerHost
+	if maxPerHost <= 0 {
+		maxPerHost = defaultMaxPerHost
+	}
+	fetchTimeout := s.FetchTimeout
+	if fetchTimeout <= 0 {
+		fetchTimeout = defaultFetchTimeout
+	}
+
+	var (
+		wg      sync.WaitGroup
+		workers = make(chan struct{}, maxConcurrency)
+		hosts   = make(map[string]chan struct{})
+		results = make([]importResult, len(subscriptions))
+		errs    = make([]error, len(subscriptions))
+	)
+
+	for i, sub := range subscriptions {
+		host := feedHost(sub.XMLURL)
+		hostSlots, ok := hosts[host]
+		if !ok {
+			hostSlots = make(chan struct{}, maxPerHost)
+			hosts[host] = hostSlots
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			hostSlots <- struct{}{}
+			defer func() { <-hostSlots }()
+			workers <- struct{}{}
+			defer func() { <-workers }()
+
+			fetchCtx, cancel := context.WithTimeout(ctx, fetchTimeout)
+			defer cancel()
+
+			results[i], errs[i] = s.importSubscription(fetchCtx, userID, sub)
+		}()
+	}
+
+	wg.Wait()
+
+	for i, sub := range subscriptions {
+		name := sub.Title
+		if name == "" {
+			name = sub.XMLURL
+		}
+
+		if errs[i] != nil {
+			summary.Failed = append(summary.Failed, ImportFailure{Title: name, Url: sub.XMLURL, Err: errs[i]})
+			continue
+		}
+
+		switch results[i] {
+		case importCreated:
+			summary.Created = append(summary.Created, name)
+		case importFollowed:
+			summary.Followed = append(summary.Followed, name)
+		case importAlreadyFollowing:
+			summary.AlreadyFollowing = append(summary.AlreadyFollowing, name)
+		}
+	}
+
+	fmt.Printf("imported OPML: %s\n", summary)
+	return summary, nil
+}
+
+func (s *FeedService) importSubscription(ctx context.Context, userID uuid.UUID, sub opml.Subscription) (importResult, error) {
+	dbFeed, err := s.Repo.GetFeedByUrl(ctx, sub.XMLURL)
+	if errors.Is(err, sql.ErrNoRows) {
+		if _, err := s.CreateFeed(ctx, CreateFeedParams{
+			Url:    sub.XMLURL,
+			UserID: userID,
+			Folder: sub.Folder,
+		}); err != nil {
+			return 0, err
+		}
+		return importCreated, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to look up feed: %s", err)
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	followed, err := s.follow(ctx, userID, dbFeed.ID, sub.Folder)
+	if err != nil {
+		return 0, err
+	}
+	if !followed {
+		return importAlreadyFollowing, nil
+	}
+	return importFollowed, nil
+}
+
+// ExportOPML writes the user's subscriptions as an OPML 2.0 document, keeping
+// the folders they were imported into.
+func (s *FeedService) ExportOPML(ctx context.Context, userID uuid.UUID, w io.Writer) error {
+	dbFeeds, err := s.Repo.GetFollowedFeedsForUser(ctx, userID.String())
+	if err != nil {
+		return fmt.Errorf("failed to get followed feeds: %s", err)
+	}
+
+	subscriptions := make([]opml.Subscription, 0, len(dbFeeds))
+	for _, dbFeed := range dbFeeds {
+		subscriptions = append(subscriptions, opml.Subscription{
+			Title:       dbFeed.Name,
+			Description: dbFeed.Description.String,
+			XMLURL:      dbFeed.Url,
+			Folder:      dbFeed.Folder.String,
+		})
+	}
+
+	return opml.Write(w, "Gator subscriptions", time.Now(), subscriptions)
+}
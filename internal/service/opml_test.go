@@ -0,0 +1,162 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/nrbernard/gator/internal/database"
+	"github.com/nrbernard/gator/internal/opml"
+)
+
+func TestFeedService_ImportOPML(t *testing.T) {
+	queries := setupTestDB(t)
+	ctx := context.Background()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken.xml" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/rss+xml")
+		fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0">
+  <channel>
+    <title>Feed %s</title>
+    <description>Served from %s</description>
+  </channel>
+</rss>`, r.URL.Path, r.URL.Path)
+	}))
+	defer server.Close()
+
+	userID := uuid.New()
+	if _, err := queries.CreateUser(ctx, database.CreateUserParams{ID: userID.String(), Name: "Test User"}); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	// A feed another user already added is followed without being fetched
+	knownURL := server.URL + "/known.xml"
+	if _, err := queries.CreateFeed(ctx, database.CreateFeedParams{
+		ID:     uuid.New().String(),
+		Name:   "Known Feed",
+		Url:    knownURL,
+		UserID: sql.NullString{String: userID.String(), Valid: true},
+	}); err != nil {
+		t.Fatalf("Failed to create feed: %v", err)
+	}
+
+	input := fmt.Sprintf(`<?xml version="1.0"?>
+<opml version="2.0">
+  <body>
+    <outline text="Tech">
+      <outline text="New" type="rss" xmlUrl="%[1]s/new.xml"/>
+      <outline text="Known" type="rss" xmlUrl="%[1]s/known.xml"/>
+    </outline>
+    <outline text="Broken" type="rss" xmlUrl="%[1]s/broken.xml"/>
+    <outline text="New again" type="rss" xmlUrl="%[1]s/new.xml"/>
+  </body>
+</opml>`, server.URL)
+
+	feedService := &FeedService{Repo: queries}
+	summary, err := feedService.ImportOPML(ctx, userID, strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ImportOPML failed: %v", err)
+	}
+
+	if len(summary.Created) != 1 || summary.Created[0] != "New" {
+		t.Errorf("Expected New to be created, got %v", summary.Created)
+	}
+	if len(summary.Followed) != 1 || summary.Followed[0] != "Known" {
+		t.Errorf("Expected Known to be followed, got %v", summary.Followed)
+	}
+	if len(summary.Failed) != 1 || summary.Failed[0].Title != "Broken" || summary.Failed[0].Err == nil {
+		t.Errorf("Expected Broken to fail with an error, got %+v", summary.Failed)
+	}
+
+	follows, err := queries.GetFollowedFeedsForUser(ctx, userID.String())
+	if err != nil {
+		t.Fatalf("Failed to get follows: %v", err)
+	}
+	if len(follows) != 2 {
+		t.Fatalf("Expected 2 follows, got %d", len(follows))
+	}
+	for _, follow := range follows {
+		if follow.Folder.String != "Tech" {
+			t.Errorf("Expected %s to be filed under Tech, got %q", follow.Name, follow.Folder.String)
+		}
+	}
+
+	// Importing the same file again changes nothing
+	summary, err = feedService.ImportOPML(ctx, userID, strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ImportOPML failed: %v", err)
+	}
+	if len(summary.AlreadyFollowing) != 2 || len(summary.Created) != 0 || len(summary.Followed) != 0 {
+		t.Errorf("Expected both feeds to be already followed, got %s", summary)
+	}
+
+	if _, err := feedService.ImportOPML(ctx, userID, strings.NewReader("not opml")); err == nil {
+		t.Error("Expected an error for an invalid OPML file")
+	}
+}
+
+func TestFeedService_ExportOPML(t *testing.T) {
+	queries := setupTestDB(t)
+	ctx := context.Background()
+
+	userID := uuid.New()
+	if _, err := queries.CreateUser(ctx, database.CreateUserParams{ID: userID.String(), Name: "Test User"}); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	for _, feed := range []struct{ name, url, folder string }{
+		{"Zeta", "https://zeta.example.com/feed", "News"},
+		{"Alpha", "https://alpha.example.com/feed", ""},
+		{"Beta", "https://beta.example.com/feed", "News"},
+	} {
+		dbFeed, err := queries.CreateFeed(ctx, database.CreateFeedParams{
+			ID:     uuid.New().String(),
+			Name:   feed.name,
+			Url:    feed.url,
+			UserID: sql.NullString{String: userID.String(), Valid: true},
+		})
+		if err != nil {
+			t.Fatalf("Failed to create feed: %v", err)
+		}
+		if _, err := queries.FollowFeed(ctx, database.FollowFeedParams{
+			ID:     uuid.New().String(),
+			UserID: userID.String(),
+			FeedID: dbFeed.ID,
+			Folder: sql.NullString{String: feed.folder, Valid: feed.folder != ""},
+		}); err != nil {
+			t.Fatalf("Failed to follow feed: %v", err)
+		}
+	}
+
+	feedService := &FeedService{Repo: queries}
+	var buf bytes.Buffer
+	if err := feedService.ExportOPML(ctx, userID, &buf); err != nil {
+		t.Fatalf("ExportOPML failed: %v", err)
+	}
+
+	subscriptions, err := opml.Parse(&buf)
+	if err != nil {
+		t.Fatalf("Exported OPML did not parse: %v", err)
+	}
+
+	var got []string
+	for _, sub := range subscriptions {
+		got = append(got, sub.Folder+"/"+sub.Title)
+	}
+	want := []string{"/Alpha", "News/Beta", "News/Zeta"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/nrbernard/gator/internal/feedparser"
+)
+
+// postIdentityKey identifies an item within its feed, so the same story is
+// stored once however often it is fetched. The item's GUID is preferred
+// since it survives changes to the link format. Without one the link is
+// used, and as a last resort a hash of the title and date. Keys are
+// prefixed with their source so the kinds can never collide.
+func postIdentityKey(item feedparser.Item) string {
+	if guid := strings.TrimSpace(item.GetGUID()); guid != "" {
+		return "guid:" + guid
+	}
+	if link := strings.TrimSpace(item.GetLink()); link != "" {
+		return linkIdentityKey(link)
+	}
+
+	var date string
+	if !item.GetDate().IsZero() {
+		date = item.GetDate().UTC().Format(time.RFC3339)
+	}
+	sum := sha256.Sum256([]byte(strings.TrimSpace(item.GetTitle()) + "\n" + date))
+	return "hash:" + hex.EncodeToString(sum[:])
+}
+
+// linkIdentityKey is the key of an item without a GUID. Posts stored before
+// GUIDs were tracked all have one.
+func linkIdentityKey(link string) string {
+	return "link:" + strings.TrimSpace(link)
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nrbernard/gator/internal/database"
+	"github.com/nrbernard/gator/internal/feedparser"
+)
+
+// ingestItem stores a fetched item as a post, or brings the stored post up
+// to date if the publisher has edited it since. The caller holds writeMu.
+func (s *FeedService) ingestItem(ctx context.Context, feed database.Feed, item feedparser.Item, firstSeen time.Time) error {
+	identityKey := postIdentityKey(item)
+	guid := strings.TrimSpace(item.GetGUID())
+	description := sql.NullString{String: *item.GetDescription(), Valid: item.GetDescription() != nil}
+	content := sql.NullString{String: item.GetContent(), Valid: item.GetContent() != ""}
+	contentHash := postContentHash(item.GetTitle(), description.String, content.String, item.GetDate())
+	thumbnail := sql.NullString{String: item.GetThumbnail(), Valid: item.GetThumbnail() != ""}
+
+	// Posts stored before GUIDs were tracked are keyed by link, so the first
+	// fetch that sees their GUID moves them over to it
+	if guid != "" && item.GetLink() != "" {
+		if _, err := s.Repo.AdoptPostGUID(ctx, database.AdoptPostGUIDParams{
+			Guid:        sql.NullString{String: guid, Valid: true},
+			IdentityKey: identityKey,
+			FeedID:      feed.ID,
+			LinkKey:     linkIdentityKey(item.GetLink()),
+		}); err != nil {
+			return fmt.Errorf("failed to update post identity: %s", err)
+		}
+	}
+
+	// Undated items are dated when first seen, and keep that date
+	publishedAt := item.GetDate()
+	if publishedAt.IsZero() {
+		publishedAt = firstSeen
+	}
+
+	postID := uuid.New().String()
+	created, err := s.Repo.CreatePost(ctx, database.CreatePostParams{
+		ID:           postID,
+		Title:        item.GetTitle(),
+		Url:          item.GetLink(),
+		Description:  description,
+		PublishedAt:  publishedAt,
+		FeedID:       feed.ID,
+		Guid:         sql.NullString{String: guid, Valid: guid != ""},
+		IdentityKey:  identityKey,
+		ContentHash:  sql.NullString{String: contentHash, Valid: true},
+		ThumbnailUrl: thumbnail,
+		ContentHtml:  content,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create post: %s", err)
+	}
+	if created > 0 {
+		fmt.Printf("created post with URL %s\n", item.GetLink())
+		return saveEnclosures(ctx, s.Repo, postID, item)
+	}
+
+	post, err := s.Repo.GetPostByIdentity(ctx, database.GetPostByIdentityParams{
+		FeedID:      feed.ID,
+		IdentityKey: identityKey,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get post: %s", err)
+	}
+
+	if post.ContentHash.Valid && post.ContentHash.String == contentHash {
+		return nil
+	}
+
+	update := database.UpdatePostContentParams{
+		Title:        item.GetTitle(),
+		Description:  description,
+		PublishedAt:  post.PublishedAt,
+		ContentHash:  sql.NullString{String: contentHash, Valid: true},
+		ThumbnailUrl: thumbnail,
+		ContentHtml:  content,
+		ID:           post.ID,
+	}
+	if !item.GetDate().IsZero() {
+		update.PublishedAt = item.GetDate()
+	}
+
+	// Posts without a hash were stored before hashes were kept, or before the
+	// stored format last changed, so there is nothing to compare them with.
+	// They are brought up to date without counting as an edit.
+	if !post.ContentHash.Valid {
+		return s.inTx(ctx, func(q *database.Queries) error {
+			if err := updatePostContent(ctx, q, update); err != nil {
+				return err
+			}
+			return saveEnclosures(ctx, q, post.ID, item)
+		})
+	}
+
+	// Keep the previous version and mark the post unread for followers who
+	// asked for it, in one transaction so an edit is never stored without its
+	// revision
+	if err := s.inTx(ctx, func(q *database.Queries) error {
+		if err := q.CreatePostRevision(ctx, database.CreatePostRevisionParams{
+			ID:     uuid.New().String(),
+			PostID: post.ID,
+		}); err != nil {
+			return fmt.Errorf("failed to save post revision: %s", err)
+		}
+		if err := updatePostContent(ctx, q, update); err != nil {
+			return err
+		}
+		if err := saveEnclosures(ctx, q, post.ID, item); err != nil {
+			return err
+		}
+		if err := q.DeleteReadPostsForPost(ctx, database.DeleteReadPostsForPostParams{
+			PostID: post.ID,
+			FeedID: feed.ID,
+		}); err != nil {
+			return fmt.Errorf("failed to mark post unread: %s", err)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	fmt.Printf("updated post with URL %s\n", item.GetLink())
+
+	return nil
+}
+
+// inTx runs fn with queries bound to a transaction, which is committed if
+// fn succeeds and rolled back otherwise.
+func (s *FeedService) inTx(ctx context.Context, fn func(q *database.Queries) error) error {
+	if s.DB == nil {
+		return fmt.Errorf("failed to begin transaction: no database")
+	}
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %s", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(s.Repo.WithTx(tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %s", err)
+	}
+	return nil
+}
+
+func updatePostContent(ctx context.Context, q *database.Queries, update database.UpdatePostContentParams) error {
+	if err := q.UpdatePostContent(ctx, update); err != nil {
+		return fmt.Errorf("failed to update post: %s", err)
+	}
+	return nil
+}
+
+// saveEnclosures stores an item's media files against its post, updating
+// the details of ones it already has.
+func saveEnclosures(ctx context.Context, q *database.Queries, postID string, item feedparser.Item) error {
+	for _, enclosure := range item.GetEnclosures() {
+		if err := q.CreatePostEnclosure(ctx, database.CreatePostEnclosureParams{
+			ID:              uuid.New().String(),
+			PostID:          postID,
+			Url:             enclosure.URL,
+			MimeType:        sql.NullString{String: enclosure.MimeType, Valid: enclosure.MimeType != ""},
+			Length:          sql.NullInt64{Int64: enclosure.Length, Valid: enclosure.Length > 0},
+			DurationSeconds: sql.NullInt64{Int64: int64(enclosure.Duration / time.Second), Valid: enclosure.Duration > 0},
+		}); err != nil {
+			return fmt.Errorf("failed to save enclosure: %s", err)
+		}
+	}
+	return nil
+}
+
+// postContentHash fingerprints the parts of a post a reader sees. A zero
+// date is left out, so an undated item's first-seen date never counts as an
+// edit.
+func postContentHash(title, description, content string, date time.Time) string {
+	var formatted string
+	if !date.IsZero() {
+		formatted = date.UTC().Format(time.RFC3339)
+	}
+	sum := sha256.Sum256([]byte(title + "\x00" + description + "\x00" + content + "\x00" + formatted))
+	return hex.EncodeToString(sum[:])
+}
@@ -3,6 +3,11 @@ package service
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/nrbernard/gator/internal/database"
@@ -13,23 +18,24 @@ type PostService struct {
 	Repo *database.Queries
 }
 
+var ErrPostNotFound = errors.New("post not found")
+
 type SearchOptions struct {
 	Query  *string
 	Unread bool
 	Saved  bool
 }
 
+// SearchPosts lists posts from the user's feeds, newest first. When a query is
+// given the full-text index is used instead: results are ranked by relevance
+// and carry highlighted titles and snippets.
 func (s *PostService) SearchPosts(ctx context.Context, userID uuid.UUID, options SearchOptions) ([]models.Post, error) {
-	var queryStr sql.NullString
-	if options.Query != nil {
-		queryStr = sql.NullString{String: *options.Query, Valid: true}
-	} else {
-		queryStr = sql.NullString{Valid: false}
+	if options.Query != nil && strings.TrimSpace(*options.Query) != "" {
+		return s.searchFullText(ctx, userID, options)
 	}
 
 	dbPosts, err := s.Repo.SearchPostsByUser(ctx, database.SearchPostsByUserParams{
 		UserID:         userID.String(),
-		SearchText:     queryStr.String,
 		FilterByUnread: options.Unread,
 		FilterBySaved:  options.Saved,
 		LimitCount:     100,
@@ -55,8 +61,100 @@ func (s *PostService) SearchPosts(ctx context.Context, userID uuid.UUID, options
 			FeedName:    dbPost.FeedName,
 			IsSaved:     dbPost.SavedAt.Valid,
 			IsRead:      isRead,
+			HasContent:  dbPost.HasContent,
+			Thumbnail:   dbPost.ThumbnailUrl.String,
+			Enclosure:   postEnclosure(dbPost.EnclosureUrl, dbPost.EnclosureMimeType, dbPost.EnclosureDurationSeconds),
 		})
 	}
 
 	return posts, nil
 }
+
+// GetContent returns the body of a post in one of the user's feeds. It was
+// sanitized when the post was fetched, so it is safe to render as is.
+func (s *PostService) GetContent(ctx context.Context, userID, postID uuid.UUID) (template.HTML, error) {
+	content, err := s.Repo.GetPostContentForUser(ctx, database.GetPostContentForUserParams{
+		PostID: postID.String(),
+		UserID: userID.String(),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrPostNotFound
+		}
+		return "", fmt.Errorf("failed to get post content: %s", err)
+	}
+
+	return template.HTML(content.String), nil
+}
+
+// checkPostAccess returns ErrPostNotFound unless the post is in one of the
+// user's feeds, so read and saved state can't be kept for other posts.
+func checkPostAccess(ctx context.Context, repo *database.Queries, userID, postID uuid.UUID) error {
+	_, err := repo.GetPostFeedIDForUser(ctx, database.GetPostFeedIDForUserParams{
+		PostID: postID.String(),
+		UserID: userID.String(),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrPostNotFound
+		}
+		return fmt.Errorf("failed to get post: %s", err)
+	}
+	return nil
+}
+
+func (s *PostService) searchFullText(ctx context.Context, userID uuid.UUID, options SearchOptions) ([]models.Post, error) {
+	matchQuery := ftsQuery(*options.Query)
+	if matchQuery == "" {
+		return []models.Post{}, nil
+	}
+
+	dbPosts, err := s.Repo.SearchPostsFullText(ctx, database.SearchPostsFullTextParams{
+		UserID:         userID.String(),
+		MatchQuery:     matchQuery,
+		FilterByUnread: options.Unread,
+		FilterBySaved:  options.Saved,
+		LimitCount:     100,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	posts := make([]models.Post, 0, len(dbPosts))
+	for _, dbPost := range dbPosts {
+		isRead := dbPost.ReadAt.Valid
+		if options.Saved {
+			isRead = false
+		}
+
+		posts = append(posts, models.Post{
+			ID:             uuid.MustParse(dbPost.ID),
+			Title:          dbPost.Title,
+			Link:           dbPost.Url,
+			Description:    dbPost.Description.String,
+			PublishedAt:    dbPost.PublishedAt,
+			FeedID:         uuid.MustParse(dbPost.FeedID),
+			FeedName:       dbPost.FeedName,
+			IsSaved:        dbPost.SavedAt.Valid,
+			IsRead:         isRead,
+			HasContent:     dbPost.HasContent,
+			Thumbnail:      dbPost.ThumbnailUrl.String,
+			Enclosure:      postEnclosure(dbPost.EnclosureUrl, dbPost.EnclosureMimeType, dbPost.EnclosureDurationSeconds),
+			TitleHighlight: highlightHTML(dbPost.TitleHighlight),
+			Snippet:        highlightHTML(dbPost.DescriptionSnippet),
+		})
+	}
+
+	return posts, nil
+}
+
+func postEnclosure(url, mimeType sql.NullString, durationSeconds sql.NullInt64) *models.Enclosure {
+	if !url.Valid {
+		return nil
+	}
+	return &models.Enclosure{
+		URL:      url.String,
+		MimeType: mimeType.String,
+		Duration: time.Duration(durationSeconds.Int64) * time.Second,
+	}
+}
@@ -0,0 +1,189 @@
+//go:build sqlite_fts5
+
+package service
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nrbernard/gator/internal/database"
+)
+
+// createSearchIndex mirrors the posts_fts table and triggers from
+// sql/schema/019_posts_fts.sql.
+const createSearchIndex = `
+CREATE VIRTUAL TABLE posts_fts USING fts5(
+	title,
+	description,
+	feed_name,
+	tokenize = 'porter unicode61 remove_diacritics 2'
+);
+
+CREATE TRIGGER posts_fts_insert AFTER INSERT ON posts BEGIN
+	INSERT INTO posts_fts (rowid, title, description, feed_name)
+	VALUES (new.seq, new.title, coalesce(new.description, ''), (SELECT name FROM feeds WHERE id = new.feed_id));
+END;
+
+CREATE TRIGGER posts_fts_update AFTER UPDATE OF title, description, feed_id ON posts BEGIN
+	UPDATE posts_fts
+	SET title = new.title,
+		description = coalesce(new.description, ''),
+		feed_name = (SELECT name FROM feeds WHERE id = new.feed_id)
+	WHERE rowid = new.seq;
+END;
+
+CREATE TRIGGER posts_fts_delete AFTER DELETE ON posts BEGIN
+	DELETE FROM posts_fts WHERE rowid = old.seq;
+END;
+
+CREATE TRIGGER feeds_fts_rename AFTER UPDATE OF name ON feeds BEGIN
+	UPDATE posts_fts
+	SET feed_name = new.name
+	WHERE rowid IN (SELECT seq FROM posts WHERE feed_id = new.id);
+END;
+`
+
+func TestPostService_SearchPosts_FullText(t *testing.T) {
+	db := openTestDB(t)
+	if _, err := db.Exec(createSearchIndex); err != nil {
+		t.Fatalf("Failed to create search index: %v", err)
+	}
+	queries := database.New(db)
+	ctx := context.Background()
+
+	userID := uuid.New()
+	if _, err := queries.CreateUser(ctx, database.CreateUserParams{ID: userID.String(), Name: "Test User"}); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	feedID := uuid.New().String()
+	if _, err := queries.CreateFeed(ctx, database.CreateFeedParams{
+		ID:     feedID,
+		Name:   "Database Weekly",
+		Url:    "https://db.example.com/feed",
+		UserID: sql.NullString{String: userID.String(), Valid: true},
+	}); err != nil {
+		t.Fatalf("Failed to create feed: %v", err)
+	}
+	if _, err := queries.FollowFeed(ctx, database.FollowFeedParams{ID: uuid.New().String(), UserID: userID.String(), FeedID: feedID}); err != nil {
+		t.Fatalf("Failed to follow feed: %v", err)
+	}
+
+	// A feed the user does not follow must never show up
+	otherFeedID := uuid.New().String()
+	if _, err := queries.CreateFeed(ctx, database.CreateFeedParams{ID: otherFeedID, Name: "Other", Url: "https://other.example.com/feed"}); err != nil {
+		t.Fatalf("Failed to create feed: %v", err)
+	}
+
+	posts := []struct {
+		feedID      string
+		title       string
+		description string
+	}{
+		{feedID, "SQLite full text search", "Using FTS5 <b>virtual tables</b> for ranking."},
+		{feedID, "Indexing strategies", "When a full table scan beats an index, and why sqlite is fast."},
+		{feedID, "Release notes", "Nothing about searching here."},
+		{otherFeedID, "SQLite elsewhere", "Unfollowed feed."},
+	}
+	published := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, post := range posts {
+		url := "https://example.com/" + uuid.New().String()
+		if _, err := queries.CreatePost(ctx, database.CreatePostParams{
+			ID:          uuid.New().String(),
+			Title:       post.title,
+			Url:         url,
+			Description: sql.NullString{String: post.description, Valid: true},
+			PublishedAt: published.Add(time.Duration(i) * time.Hour),
+			FeedID:      post.feedID,
+			IdentityKey: "link:" + url,
+		}); err != nil {
+			t.Fatalf("Failed to create post: %v", err)
+		}
+	}
+
+	postService := &PostService{Repo: queries}
+	search := func(query string) []string {
+		t.Helper()
+		results, err := postService.SearchPosts(ctx, userID, SearchOptions{Query: &query})
+		if err != nil {
+			t.Fatalf("SearchPosts(%q) failed: %v", query, err)
+		}
+		var titles []string
+		for _, post := range results {
+			titles = append(titles, post.Title)
+		}
+		return titles
+	}
+
+	// Title matches outrank description matches
+	if got := search("sqlite "); strings.Join(got, "|") != "SQLite full text search|Indexing strategies" {
+		t.Errorf("Expected title match ranked first, got %v", got)
+	}
+
+	// Phrases must match in order
+	if got := search(`"full text" `); len(got) != 1 || got[0] != "SQLite full text search" {
+		t.Errorf("Expected one phrase match, got %v", got)
+	}
+
+	// Porter stemming and prefix matching while typing
+	if got := search("search"); len(got) != 2 {
+		t.Errorf("Expected searching to match search and searching, got %v", got)
+	}
+	if got := search("strat"); len(got) != 1 || got[0] != "Indexing strategies" {
+		t.Errorf("Expected prefix match, got %v", got)
+	}
+
+	// Operators
+	if got := search("sqlite -ranking "); len(got) != 1 || got[0] != "Indexing strategies" {
+		t.Errorf("Expected exclusion to drop the FTS5 post, got %v", got)
+	}
+	if got := search("release OR virtual "); len(got) != 2 {
+		t.Errorf("Expected OR to match two posts, got %v", got)
+	}
+
+	// Feed names are searchable and follow renames
+	if got := search("weekly "); len(got) != 3 {
+		t.Errorf("Expected feed name to match all followed posts, got %v", got)
+	}
+	if _, err := db.Exec(`UPDATE feeds SET name = 'Storage Digest' WHERE id = ?`, feedID); err != nil {
+		t.Fatalf("Failed to rename feed: %v", err)
+	}
+	if got := search("digest "); len(got) != 3 {
+		t.Errorf("Expected renamed feed to be searchable, got %v", got)
+	}
+
+	// Queries that reduce to nothing return no results rather than an error
+	if got := search(`"" -`); len(got) != 0 {
+		t.Errorf("Expected no results, got %v", got)
+	}
+
+	query := "virtual"
+	results, err := postService.SearchPosts(ctx, userID, SearchOptions{Query: &query})
+	if err != nil || len(results) != 1 {
+		t.Fatalf("Expected one result, got %v (%v)", results, err)
+	}
+	if want := "<mark>virtual</mark>"; !strings.Contains(string(results[0].Snippet), want) {
+		t.Errorf("Expected snippet to highlight match, got %q", results[0].Snippet)
+	}
+	if strings.Contains(string(results[0].Snippet), "<b>") {
+		t.Errorf("Expected feed HTML to be escaped, got %q", results[0].Snippet)
+	}
+
+	// VACUUM may renumber rowids, which must not point matches at other posts
+	if _, err := db.Exec(`DELETE FROM posts WHERE title = 'SQLite full text search'`); err != nil {
+		t.Fatalf("Failed to delete post: %v", err)
+	}
+	if _, err := db.Exec(`VACUUM`); err != nil {
+		t.Fatalf("Failed to vacuum: %v", err)
+	}
+	if got := search("strat"); len(got) != 1 || got[0] != "Indexing strategies" {
+		t.Errorf("Expected match to survive VACUUM, got %v", got)
+	}
+	if got := search("release "); len(got) != 1 || got[0] != "Release notes" {
+		t.Errorf("Expected match to survive VACUUM, got %v", got)
+	}
+}
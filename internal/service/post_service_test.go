@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nrbernard/gator/internal/database"
+)
+
+func TestPostState_RequiresFollow(t *testing.T) {
+	queries := setupTestDB(t)
+	ctx := context.Background()
+
+	alice, bob := uuid.New(), uuid.New()
+	for _, user := range []struct {
+		id   uuid.UUID
+		name string
+	}{{alice, "alice"}, {bob, "bob"}} {
+		if _, err := queries.CreateUser(ctx, database.CreateUserParams{ID: user.id.String(), Name: user.name}); err != nil {
+			t.Fatalf("Failed to create user: %v", err)
+		}
+	}
+
+	feed, err := queries.CreateFeed(ctx, database.CreateFeedParams{
+		ID:   uuid.New().String(),
+		Name: "Alice's feed",
+		Url:  "https://example.com/feed.xml",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create feed: %v", err)
+	}
+	if _, err := queries.FollowFeed(ctx, database.FollowFeedParams{ID: uuid.New().String(), UserID: alice.String(), FeedID: feed.ID}); err != nil {
+		t.Fatalf("Failed to follow feed: %v", err)
+	}
+
+	postID := uuid.New()
+	if _, err := queries.CreatePost(ctx, database.CreatePostParams{
+		ID:          postID.String(),
+		Title:       "Post",
+		Url:         "https://example.com/post",
+		PublishedAt: time.Now(),
+		FeedID:      feed.ID,
+		IdentityKey: "link:https://example.com/post",
+	}); err != nil {
+		t.Fatalf("Failed to create post: %v", err)
+	}
+
+	savedPostService := &SavedPostService{Repo: queries}
+	readPostService := &ReadPostService{Repo: queries}
+	updates := map[string]func(ctx context.Context, postID uuid.UUID, userID uuid.UUID) error{
+		"save":        savedPostService.SavePost,
+		"unsave":      savedPostService.UnsavePost,
+		"mark read":   readPostService.Save,
+		"mark unread": readPostService.Delete,
+	}
+
+	for name, update := range updates {
+		if err := update(ctx, postID, alice); err != nil {
+			t.Errorf("%s: expected follower to succeed, got %v", name, err)
+		}
+		if err := update(ctx, postID, bob); !errors.Is(err, ErrPostNotFound) {
+			t.Errorf("%s: expected ErrPostNotFound for a non-follower, got %v", name, err)
+		}
+		if err := update(ctx, uuid.New(), alice); !errors.Is(err, ErrPostNotFound) {
+			t.Errorf("%s: expected ErrPostNotFound for a missing post, got %v", name, err)
+		}
+	}
+}
@@ -12,6 +12,10 @@ type ReadPostService struct {
 }
 
 func (s *ReadPostService) Save(ctx context.Context, postID uuid.UUID, userID uuid.UUID) error {
+	if err := checkPostAccess(ctx, s.Repo, userID, postID); err != nil {
+		return err
+	}
+
 	if err := s.Repo.SaveReadPost(ctx, database.SaveReadPostParams{
 		ID:     uuid.New().String(),
 		PostID: postID.String(),
@@ -22,3 +26,14 @@ func (s *ReadPostService) Save(ctx context.Context, postID uuid.UUID, userID uui
 
 	return nil
 }
+
+func (s *ReadPostService) Delete(ctx context.Context, postID uuid.UUID, userID uuid.UUID) error {
+	if err := checkPostAccess(ctx, s.Repo, userID, postID); err != nil {
+		return err
+	}
+
+	return s.Repo.DeleteReadPost(ctx, database.DeleteReadPostParams{
+		UserID: userID.String(),
+		PostID: postID.String(),
+	})
+}
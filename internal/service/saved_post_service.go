@@ -12,6 +12,10 @@ type SavedPostService struct {
 }
 
 func (s *SavedPostService) SavePost(ctx context.Context, postID uuid.UUID, userID uuid.UUID) error {
+	if err := checkPostAccess(ctx, s.Repo, userID, postID); err != nil {
+		return err
+	}
+
 	err := s.Repo.SaveSavedPost(ctx, database.SaveSavedPostParams{
 		ID:     uuid.New().String(),
 		PostID: postID.String(),
@@ -25,6 +29,10 @@ func (s *SavedPostService) SavePost(ctx context.Context, postID uuid.UUID, userI
 }
 
 func (s *SavedPostService) UnsavePost(ctx context.Context, postID uuid.UUID, userID uuid.UUID) error {
+	if err := checkPostAccess(ctx, s.Repo, userID, postID); err != nil {
+		return err
+	}
+
 	err := s.Repo.DeleteSavedPost(ctx, database.DeleteSavedPostParams{
 		PostID: postID.String(),
 		UserID: userID.String(),
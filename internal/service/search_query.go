@@ -0,0 +1,106 @@
+package service
+
+import (
+	"html"
+	"html/template"
+	"strings"
+	"unicode"
+)
+
+// Markers the FTS5 highlight() and snippet() functions wrap around matched
+// terms. They are control characters so they survive HTML escaping and can
+// never be confused with text from a feed.
+const (
+	highlightStart = "\x02"
+	highlightEnd   = "\x03"
+)
+
+// ftsQuery turns what someone typed into the search box into an FTS5 MATCH
+// expression. Words and "quoted phrases" must all match; OR and NOT between
+// terms, or a leading -, work as operators; a trailing * makes a prefix
+// search. Every term is quoted, so punctuation in the input can never be an
+// FTS5 syntax error. The last word is also matched as a prefix so results
+// keep up while the user is still typing it.
+func ftsQuery(input string) string {
+	var (
+		parts      []string
+		pendingOp  string
+		rest       = input
+		typingWord = input != "" && !unicode.IsSpace(rune(input[len(input)-1]))
+	)
+
+	for {
+		rest = strings.TrimLeftFunc(rest, unicode.IsSpace)
+		if rest == "" {
+			break
+		}
+
+		negate := false
+		if len(rest) > 1 && rest[0] == '-' {
+			negate = true
+			rest = rest[1:]
+		}
+
+		var text string
+		phrase := rest[0] == '"'
+		if phrase {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				text, rest = rest[1:], ""
+			} else {
+				text, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else {
+			end := strings.IndexFunc(rest, unicode.IsSpace)
+			if end < 0 {
+				end = len(rest)
+			}
+			text, rest = rest[:end], rest[end:]
+		}
+
+		if !phrase && !negate && (text == "OR" || text == "AND" || text == "NOT") {
+			if len(parts) > 0 {
+				pendingOp = text
+			}
+			continue
+		}
+
+		prefix := false
+		if !phrase {
+			prefix = strings.HasSuffix(text, "*") || (rest == "" && typingWord)
+			text = strings.Trim(text, `*"`)
+		}
+		if !strings.ContainsFunc(text, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) {
+			continue
+		}
+
+		if negate {
+			// FTS5 has no unary NOT, so an exclusion needs something before it
+			if len(parts) == 0 {
+				continue
+			}
+			pendingOp = "NOT"
+		}
+		if pendingOp != "" {
+			parts = append(parts, pendingOp)
+			pendingOp = ""
+		}
+
+		clause := `"` + strings.ReplaceAll(text, `"`, `""`) + `"`
+		if prefix {
+			clause += "*"
+		}
+		parts = append(parts, clause)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// highlightHTML escapes text from the search index and turns the highlight
+// markers into <mark> elements.
+func highlightHTML(s string) template.HTML {
+	escaped := html.EscapeString(s)
+	escaped = strings.ReplaceAll(escaped, highlightStart, "<mark>")
+	escaped = strings.ReplaceAll(escaped, highlightEnd, "</mark>")
+	return template.HTML(escaped)
+}
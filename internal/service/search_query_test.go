@@ -0,0 +1,45 @@
+package service
+
+import "testing"
+
+func TestFTSQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"empty", "", ""},
+		{"whitespace", "   ", ""},
+		{"single word being typed", "gol", `"gol"*`},
+		{"finished word", "golang ", `"golang"`},
+		{"several words", "sqlite full text ", `"sqlite" "full" "text"`},
+		{"phrase", `"full text" search `, `"full text" "search"`},
+		{"unterminated phrase", `"full text`, `"full text"`},
+		{"explicit prefix", "data* base ", `"data"* "base"`},
+		{"or operator", "rust OR go ", `"rust" OR "go"`},
+		{"not operator", "go NOT generics ", `"go" NOT "generics"`},
+		{"minus excludes", "go -generics ", `"go" NOT "generics"`},
+		{"leading exclusion is dropped", "-generics go ", `"go"`},
+		{"leading operator is dropped", "OR go ", `"go"`},
+		{"lowercase or is a word", "this or that ", `"this" "or" "that"`},
+		{"punctuation only", `"" * - ( ) `, ""},
+		{"fts syntax is quoted", `title:go (NEAR) `, `"title:go" "(NEAR)"`},
+		{"embedded quote", `it"s `, `"it""s"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ftsQuery(tt.input); got != tt.want {
+				t.Errorf("ftsQuery(%q) = %s, want %s", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHighlightHTML(t *testing.T) {
+	got := highlightHTML("<b>Go</b> & \x02SQLite\x03 tips")
+	want := "&lt;b&gt;Go&lt;/b&gt; &amp; <mark>SQLite</mark> tips"
+	if string(got) != want {
+		t.Errorf("highlightHTML() = %q, want %q", got, want)
+	}
+}
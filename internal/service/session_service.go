@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nrbernard/gator/internal/database"
+	"github.com/nrbernard/gator/internal/models"
+)
+
+const SessionDuration = 30 * 24 * time.Hour
+
+var ErrSessionNotFound = errors.New("session not found")
+
+type SessionService struct {
+	Repo *database.Queries
+}
+
+// Create starts a session for the user and returns the token to hand to the
+// browser. Only a hash of the token is stored, so a leaked database does not
+// leak live sessions.
+func (s *SessionService) Create(ctx context.Context, userID uuid.UUID) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate session token: %s", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	err := s.Repo.CreateSession(ctx, database.CreateSessionParams{
+		ID:        hashToken(token),
+		UserID:    userID.String(),
+		ExpiresAt: time.Now().UTC().Add(SessionDuration),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create session: %s", err)
+	}
+
+	return token, nil
+}
+
+func (s *SessionService) GetUser(ctx context.Context, token string) (models.User, error) {
+	dbUser, err := s.Repo.GetSessionUser(ctx, database.GetSessionUserParams{
+		ID:  hashToken(token),
+		Now: time.Now().UTC(),
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.User{}, ErrSessionNotFound
+		}
+		return models.User{}, fmt.Errorf("failed to get session: %s", err)
+	}
+
+	return toUserModel(dbUser), nil
+}
+
+func (s *SessionService) Delete(ctx context.Context, token string) error {
+	if err := s.Repo.DeleteSession(ctx, hashToken(token)); err != nil {
+		return fmt.Errorf("failed to delete session: %s", err)
+	}
+	return nil
+}
+
+func (s *SessionService) DeleteExpired(ctx context.Context) error {
+	if err := s.Repo.DeleteExpiredSessions(ctx, time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to delete expired sessions: %s", err)
+	}
+	return nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -2,12 +2,53 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
 
 	"github.com/google/uuid"
+	"github.com/mattn/go-sqlite3"
 	"github.com/nrbernard/gator/internal/database"
 	"github.com/nrbernard/gator/internal/models"
+	"golang.org/x/crypto/bcrypt"
 )
 
+const (
+	minPasswordLength = 8
+	// bcrypt ignores everything past 72 bytes, so longer passwords are
+	// rejected rather than silently truncated.
+	maxPasswordLength = 72
+	maxUserNameLength = 64
+)
+
+var (
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrUserExists         = errors.New("username is already taken")
+)
+
+// dummyPasswordHash is compared against when there is no user or no password
+// to check, so that a failed login takes as long whether or not the account
+// exists.
+var dummyPasswordHash = sync.OnceValue(func() []byte {
+	hash, err := bcrypt.GenerateFromPassword([]byte("not a real password"), bcrypt.DefaultCost)
+	if err != nil {
+		panic(fmt.Sprintf("failed to hash dummy password: %s", err))
+	}
+	return hash
+})
+
+// ValidationError reports which registration field was rejected and why.
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
 type UserService struct {
 	Repo *database.Queries
 }
@@ -18,8 +59,71 @@ func (s *UserService) GetUser(ctx context.Context, userName string) (models.User
 		return models.User{}, err
 	}
 
+	return toUserModel(dbUser), nil
+}
+
+func (s *UserService) Register(ctx context.Context, userName, password string) (models.User, error) {
+	userName = strings.TrimSpace(userName)
+	if userName == "" {
+		return models.User{}, &ValidationError{Field: "name", Message: "username is required"}
+	}
+	if len(userName) > maxUserNameLength {
+		return models.User{}, &ValidationError{Field: "name", Message: fmt.Sprintf("username must be at most %d characters", maxUserNameLength)}
+	}
+	if len(password) < minPasswordLength {
+		return models.User{}, &ValidationError{Field: "password", Message: fmt.Sprintf("password must be at least %d characters", minPasswordLength)}
+	}
+	if len(password) > maxPasswordLength {
+		return models.User{}, &ValidationError{Field: "password", Message: fmt.Sprintf("password must be at most %d bytes", maxPasswordLength)}
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return models.User{}, fmt.Errorf("failed to hash password: %s", err)
+	}
+
+	dbUser, err := s.Repo.RegisterUser(ctx, database.RegisterUserParams{
+		ID:           uuid.New().String(),
+		Name:         userName,
+		PasswordHash: sql.NullString{String: string(hash), Valid: true},
+	})
+	if err != nil {
+		var sqliteErr sqlite3.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+			return models.User{}, ErrUserExists
+		}
+		return models.User{}, fmt.Errorf("failed to create user: %s", err)
+	}
+
+	return toUserModel(dbUser), nil
+}
+
+// Authenticate checks a username and password. Unknown users, users without a
+// password and wrong passwords all return ErrInvalidCredentials.
+func (s *UserService) Authenticate(ctx context.Context, userName, password string) (models.User, error) {
+	dbUser, err := s.Repo.GetUser(ctx, strings.TrimSpace(userName))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			bcrypt.CompareHashAndPassword(dummyPasswordHash(), []byte(password))
+			return models.User{}, ErrInvalidCredentials
+		}
+		return models.User{}, fmt.Errorf("failed to get user: %s", err)
+	}
+
+	if !dbUser.PasswordHash.Valid {
+		bcrypt.CompareHashAndPassword(dummyPasswordHash(), []byte(password))
+		return models.User{}, ErrInvalidCredentials
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(dbUser.PasswordHash.String), []byte(password)); err != nil {
+		return models.User{}, ErrInvalidCredentials
+	}
+
+	return toUserModel(dbUser), nil
+}
+
+func toUserModel(dbUser database.User) models.User {
 	return models.User{
 		ID:   uuid.MustParse(dbUser.ID),
 		Name: dbUser.Name,
-	}, nil
+	}
 }
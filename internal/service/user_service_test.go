@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nrbernard/gator/internal/database"
+)
+
+func TestUserService_RegisterAndAuthenticate(t *testing.T) {
+	queries := setupTestDB(t)
+	userService := &UserService{Repo: queries}
+	ctx := context.Background()
+
+	user, err := userService.Register(ctx, " alice ", "correct horse")
+	if err != nil {
+		t.Fatalf("Failed to register user: %v", err)
+	}
+	if user.Name != "alice" {
+		t.Errorf("Expected name %q, got %q", "alice", user.Name)
+	}
+
+	dbUser, err := queries.GetUser(ctx, "alice")
+	if err != nil {
+		t.Fatalf("Failed to get user: %v", err)
+	}
+	if !dbUser.PasswordHash.Valid || dbUser.PasswordHash.String == "correct horse" {
+		t.Errorf("Expected a hashed password to be stored, got %+v", dbUser.PasswordHash)
+	}
+
+	authed, err := userService.Authenticate(ctx, "alice", "correct horse")
+	if err != nil {
+		t.Fatalf("Expected valid credentials to authenticate: %v", err)
+	}
+	if authed.ID != user.ID {
+		t.Errorf("Expected user %s, got %s", user.ID, authed.ID)
+	}
+
+	if _, err := userService.Authenticate(ctx, "alice", "wrong password"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected ErrInvalidCredentials for wrong password, got %v", err)
+	}
+	if _, err := userService.Authenticate(ctx, "bob", "correct horse"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected ErrInvalidCredentials for unknown user, got %v", err)
+	}
+
+	if _, err := userService.Register(ctx, "alice", "another password"); !errors.Is(err, ErrUserExists) {
+		t.Errorf("Expected ErrUserExists for duplicate name, got %v", err)
+	}
+}
+
+func TestUserService_Register_Validation(t *testing.T) {
+	queries := setupTestDB(t)
+	userService := &UserService{Repo: queries}
+
+	tests := []struct {
+		name      string
+		userName  string
+		password  string
+		wantField string
+	}{
+		{"empty name", "  ", "long enough", "name"},
+		{"long name", strings.Repeat("a", maxUserNameLength+1), "long enough", "name"},
+		{"short password", "alice", "short", "password"},
+		{"long password", "alice", strings.Repeat("p", maxPasswordLength+1), "password"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := userService.Register(context.Background(), tt.userName, tt.password)
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Expected a ValidationError, got %v", err)
+			}
+			if validationErr.Field != tt.wantField {
+				t.Errorf("Expected field %q, got %q", tt.wantField, validationErr.Field)
+			}
+		})
+	}
+}
+
+func TestUserService_Authenticate_PasswordlessUser(t *testing.T) {
+	queries := setupTestDB(t)
+	userService := &UserService{Repo: queries}
+	ctx := context.Background()
+
+	_, err := queries.CreateUser(ctx, database.CreateUserParams{
+		ID:   uuid.New().String(),
+		Name: "nick",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	if _, err := userService.Authenticate(ctx, "nick", ""); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected ErrInvalidCredentials for user without a password, got %v", err)
+	}
+}
+
+func TestSessionService(t *testing.T) {
+	queries := setupTestDB(t)
+	userService := &UserService{Repo: queries}
+	sessionService := &SessionService{Repo: queries}
+	ctx := context.Background()
+
+	user, err := userService.Register(ctx, "alice", "correct horse")
+	if err != nil {
+		t.Fatalf("Failed to register user: %v", err)
+	}
+
+	token, err := sessionService.Create(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	sessionUser, err := sessionService.GetUser(ctx, token)
+	if err != nil {
+		t.Fatalf("Failed to get session user: %v", err)
+	}
+	if sessionUser.ID != user.ID {
+		t.Errorf("Expected user %s, got %s", user.ID, sessionUser.ID)
+	}
+
+	if _, err := sessionService.GetUser(ctx, "not-a-token"); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("Expected ErrSessionNotFound for unknown token, got %v", err)
+	}
+
+	if err := sessionService.Delete(ctx, token); err != nil {
+		t.Fatalf("Failed to delete session: %v", err)
+	}
+	if _, err := sessionService.GetUser(ctx, token); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("Expected ErrSessionNotFound after logout, got %v", err)
+	}
+
+	// Expired sessions no longer resolve to a user
+	expiredToken := "expired-token"
+	err = queries.CreateSession(ctx, database.CreateSessionParams{
+		ID:        hashToken(expiredToken),
+		UserID:    user.ID.String(),
+		ExpiresAt: time.Now().UTC().Add(-time.Minute),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create expired session: %v", err)
+	}
+	if _, err := sessionService.GetUser(ctx, expiredToken); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("Expected ErrSessionNotFound for expired session, got %v", err)
+	}
+
+	if err := sessionService.DeleteExpired(ctx); err != nil {
+		t.Fatalf("Failed to delete expired sessions: %v", err)
+	}
+	_, err = queries.GetSessionUser(ctx, database.GetSessionUserParams{
+		ID:  hashToken(expiredToken),
+		Now: time.Now().UTC().Add(-time.Hour),
+	})
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected expired session to be deleted, got %v", err)
+	}
+}
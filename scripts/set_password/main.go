@@ -0,0 +1,74 @@
+// Command set_password assigns a password to an existing user, such as the
+// accounts created before sign-up existed. The password is read from stdin
+// rather than the command line, where it would show up in ps and the shell
+// history.
+//
+//	DATABASE_PATH=gator.db go run ./scripts/set_password <username>
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/nrbernard/gator/internal/database"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Println("Usage: set_password <username>")
+		os.Exit(1)
+	}
+	userName := os.Args[1]
+
+	fmt.Fprint(os.Stderr, "Password: ")
+	password, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		fmt.Printf("Failed to read password: %s\n", err)
+		os.Exit(1)
+	}
+	password = strings.TrimRight(password, "\r\n")
+	if password == "" {
+		fmt.Println("Password must not be empty")
+		os.Exit(1)
+	}
+
+	dbPath := os.Getenv("DATABASE_PATH")
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		fmt.Printf("Failed to connect to database: %s\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	dbQueries := database.New(db)
+
+	if _, err := dbQueries.GetUser(context.Background(), userName); err != nil {
+		fmt.Printf("Failed to get user %s: %s\n", userName, err)
+		os.Exit(1)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		fmt.Printf("Failed to hash password: %s\n", err)
+		os.Exit(1)
+	}
+
+	err = dbQueries.SetUserPassword(context.Background(), database.SetUserPasswordParams{
+		PasswordHash: sql.NullString{String: string(hash), Valid: true},
+		Name:         userName,
+	})
+	if err != nil {
+		fmt.Printf("Failed to set password: %s\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Password set for %s\n", userName)
+}
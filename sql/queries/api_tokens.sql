@@ -0,0 +1,21 @@
+-- name: CreateAPIToken :one
+INSERT INTO api_tokens (id, name, token_hash, user_id)
+VALUES (@id, @name, @token_hash, @user_id)
+RETURNING *;
+
+-- name: GetAPITokensByUser :many
+SELECT * FROM api_tokens
+WHERE user_id = @user_id
+ORDER BY created_at DESC;
+
+-- name: GetAPITokenUser :one
+SELECT users.* FROM api_tokens
+JOIN users ON api_tokens.user_id = users.id
+WHERE api_tokens.token_hash = @token_hash;
+
+-- name: TouchAPIToken :exec
+UPDATE api_tokens SET last_used_at = @last_used_at
+WHERE token_hash = @token_hash;
+
+-- name: DeleteAPIToken :exec
+DELETE FROM api_tokens WHERE id = @id AND user_id = @user_id;
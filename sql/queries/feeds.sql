@@ -9,20 +9,20 @@ VALUES (
 )
 RETURNING *;
 
--- name: GetFeeds :many
-SELECT f.id, f.name, f.url, f.description, u.name as user_name 
-FROM feeds f
-JOIN users u ON f.user_id = u.id
-ORDER BY f.created_at DESC;
-
 -- name: GetFeedByUrl :one
 SELECT * FROM feeds WHERE url = ?;
 
+-- name: GetFeedByID :one
+SELECT * FROM feeds WHERE id = ?;
+
 -- name: CreateFeedFollow :one
 INSERT INTO feed_follows (id, created_at, updated_at, user_id, feed_id)
 VALUES (?, ?, ?, ?, ?)
 RETURNING *;
 
+-- name: GetFeedFollow :one
+SELECT * FROM feed_follows WHERE user_id = ? AND feed_id = ?;
+
 -- name: GetFeedFollowsForUser :many
 SELECT feed_follows.*, f.name as feed_name, u.name as user_name
 FROM feed_follows
@@ -33,8 +33,12 @@ WHERE feed_follows.user_id = ?;
 -- name: DeleteFeed :exec
 DELETE FROM feeds WHERE id = ?;
 
--- name: DeleteFeedFollow :exec
-DELETE FROM feed_follows WHERE feed_follows.user_id = ? AND feed_follows.feed_id = (SELECT id FROM feeds WHERE url = ?);
+-- name: DeleteFeedFollow :execrows
+DELETE FROM feed_follows WHERE feed_follows.user_id = ? AND feed_follows.feed_id = ?;
+
+-- name: DeleteFeedIfUnfollowed :exec
+DELETE FROM feeds
+WHERE id = ? AND NOT EXISTS (SELECT 1 FROM feed_follows WHERE feed_follows.feed_id = feeds.id);
 
 -- name: MarkFeedAsFetched :exec
 UPDATE feeds SET last_fetched_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP WHERE id = ?;
@@ -46,8 +50,9 @@ LIMIT 1;
 
 -- name: GetFeedsToFetch :many
 SELECT * FROM feeds
-WHERE last_fetched_at IS NULL
-   OR last_fetched_at < ?
+WHERE dead_at IS NULL
+  AND ((next_fetch_after IS NULL AND (last_fetched_at IS NULL OR last_fetched_at < @cutoff))
+   OR next_fetch_after <= @now)
 ORDER BY (last_fetched_at IS NOT NULL), last_fetched_at ASC;
 
 -- name: UpdateFeedConditionalHeaders :exec
@@ -59,3 +64,92 @@ WHERE id = ?;
 UPDATE feeds 
 SET etag = ?, last_modified = ?, updated_at = CURRENT_TIMESTAMP 
 WHERE id = ?;
+
+-- name: RecordFeedFetchSuccess :exec
+UPDATE feeds
+SET last_error = ?, consecutive_failures = 0, next_fetch_after = ?, last_success_at = CURRENT_TIMESTAMP, last_fetched_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: RecordFeedFetchFailure :exec
+UPDATE feeds
+SET last_error = ?, consecutive_failures = consecutive_failures + 1, next_fetch_after = ?, last_fetched_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: MarkFeedDead :exec
+UPDATE feeds
+SET dead_at = CURRENT_TIMESTAMP, last_error = ?, consecutive_failures = consecutive_failures + 1, next_fetch_after = NULL, last_fetched_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: ReviveFeed :exec
+UPDATE feeds
+SET dead_at = NULL, last_error = NULL, consecutive_failures = 0, next_fetch_after = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: RecordFeedRedirect :one
+UPDATE feeds
+SET redirect_count = CASE WHEN redirect_url = @redirect_url THEN redirect_count + 1 ELSE 1 END, redirect_url = @redirect_url, updated_at = CURRENT_TIMESTAMP
+WHERE id = @id
+RETURNING redirect_count;
+
+-- name: ClearFeedRedirect :exec
+UPDATE feeds
+SET redirect_url = NULL, redirect_count = 0, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: MoveFeed :exec
+UPDATE feeds
+SET url = ?, redirect_url = NULL, redirect_count = 0, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: UpdateFeedSchedule :exec
+UPDATE feeds
+SET fetch_interval_seconds = ?, skip_hours = ?, skip_days = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: SetFeedCredentials :exec
+UPDATE feeds
+SET credentials = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: SetFeedFetchIntervalOverride :exec
+UPDATE feeds
+SET fetch_interval_override_seconds = ?, next_fetch_after = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: SetFeedFollowFetchIntervalOverride :exec
+UPDATE feed_follows
+SET fetch_interval_override_seconds = ?, updated_at = CURRENT_TIMESTAMP
+WHERE user_id = ? AND feed_id = ?;
+
+-- name: GetShortestFetchIntervalOverride :one
+SELECT fetch_interval_override_seconds FROM feed_follows
+WHERE feed_id = ? AND fetch_interval_override_seconds IS NOT NULL
+ORDER BY fetch_interval_override_seconds
+LIMIT 1;
+
+-- name: SetFeedFollowMarkUnreadOnUpdate :exec
+UPDATE feed_follows
+SET mark_unread_on_update = ?, updated_at = CURRENT_TIMESTAMP
+WHERE user_id = ? AND feed_id = ?;
+
+-- name: FollowFeed :execrows
+INSERT INTO feed_follows (id, user_id, feed_id, folder)
+VALUES (?, ?, ?, ?)
+ON CONFLICT (user_id, feed_id) DO NOTHING;
+
+-- name: CountOtherFeedFollowers :one
+SELECT count(*) FROM feed_follows
+WHERE feed_id = @feed_id AND user_id != @user_id;
+
+-- name: GetFollowedFeed :one
+SELECT feeds.*
+FROM feeds
+JOIN feed_follows ON feed_follows.feed_id = feeds.id
+WHERE feeds.id = ? AND feed_follows.user_id = ?;
+
+-- name: GetFollowedFeedsForUser :many
+SELECT feeds.id, feeds.name, feeds.url, feeds.description, feeds.last_fetched_at, feeds.last_error, feeds.dead_at, feed_follows.folder
+FROM feed_follows
+JOIN feeds ON feed_follows.feed_id = feeds.id
+WHERE feed_follows.user_id = ?
+ORDER BY feed_follows.folder, feeds.name;
@@ -1,21 +1,85 @@
--- name: CreatePost :one
-INSERT INTO posts (id, title, url, description, published_at, feed_id)
+-- name: CreatePost :execrows
+INSERT INTO posts (id, title, url, description, published_at, feed_id, guid, identity_key, content_hash, thumbnail_url, content_html)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (feed_id, identity_key) DO NOTHING;
+
+-- name: CreatePostEnclosure :exec
+INSERT INTO post_enclosures (id, post_id, url, mime_type, length, duration_seconds)
 VALUES (?, ?, ?, ?, ?, ?)
-RETURNING *;
+ON CONFLICT (post_id, url) DO UPDATE
+SET mime_type = excluded.mime_type, length = excluded.length, duration_seconds = excluded.duration_seconds, updated_at = CURRENT_TIMESTAMP;
+
+-- name: GetPostByIdentity :one
+SELECT * FROM posts WHERE feed_id = ? AND identity_key = ?;
+
+-- name: UpdatePostContent :exec
+UPDATE posts
+SET title = ?, description = ?, published_at = ?, content_hash = ?, thumbnail_url = ?, content_html = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: CreatePostRevision :exec
+INSERT INTO post_revisions (id, post_id, title, description, published_at, content_hash, content_html)
+SELECT @id, posts.id, posts.title, posts.description, posts.published_at, posts.content_hash, posts.content_html
+FROM posts
+WHERE posts.id = @post_id;
+
+-- name: AdoptPostGUID :execrows
+UPDATE posts
+SET guid = @guid, identity_key = @identity_key, updated_at = CURRENT_TIMESTAMP
+WHERE feed_id = @feed_id AND identity_key = @link_key AND guid IS NULL;
+
+-- name: GetRecentPostDatesForFeed :many
+SELECT published_at FROM posts WHERE feed_id = ? ORDER BY published_at DESC LIMIT ?;
+
+-- name: GetPostContentForUser :one
+SELECT posts.content_html FROM posts
+WHERE posts.id = @post_id
+AND posts.feed_id IN (SELECT feed_id FROM feed_follows WHERE feed_follows.user_id = @user_id);
+
+-- name: GetPostFeedIDForUser :one
+SELECT posts.feed_id FROM posts
+WHERE posts.id = @post_id
+AND posts.feed_id IN (SELECT feed_id FROM feed_follows WHERE feed_follows.user_id = @user_id);
 
 -- name: GetPostsByUser :many
 SELECT * FROM posts WHERE feed_id IN (SELECT feed_id FROM feed_follows WHERE user_id = @user_id) ORDER BY published_at DESC LIMIT @limit;
 
 -- name: SearchPostsByUser :many
-SELECT posts.id as id, title, posts.url as url, posts.description as description, published_at, feeds.name as feed_name, feeds.id as feed_id, post_saves.created_at as saved_at, post_reads.created_at as read_at FROM posts
+SELECT posts.id as id, title, posts.url as url, posts.description as description, published_at, feeds.name as feed_name, feeds.id as feed_id, post_saves.created_at as saved_at, post_reads.created_at as read_at,
+    posts.thumbnail_url as thumbnail_url, post_enclosures.url as enclosure_url, post_enclosures.mime_type as enclosure_mime_type, post_enclosures.duration_seconds as enclosure_duration_seconds,
+    CAST(posts.content_html IS NOT NULL AS BOOLEAN) as has_content
+FROM posts
 JOIN feeds ON posts.feed_id = feeds.id
+LEFT JOIN post_enclosures ON post_enclosures.id = (
+    SELECT media.id FROM post_enclosures AS media
+    WHERE media.post_id = posts.id AND (media.mime_type LIKE 'audio/%' OR media.mime_type LIKE 'video/%')
+    ORDER BY media.rowid LIMIT 1
+)
 LEFT JOIN post_saves ON posts.id = post_saves.post_id AND post_saves.user_id = @user_id
 LEFT JOIN post_reads ON posts.id = post_reads.post_id AND post_reads.user_id = @user_id
 WHERE feed_id IN (SELECT feed_id FROM feed_follows WHERE feed_follows.user_id = @user_id) 
-AND ( CAST(sqlc.arg('search_text') AS TEXT) = ''
-      OR posts.title LIKE '%' || CAST(sqlc.arg('search_text') AS TEXT) || '%'
-      OR posts.description LIKE '%' || CAST(sqlc.arg('search_text') AS TEXT) || '%'
-    )
 AND ( CAST(sqlc.arg('filter_by_unread') AS BOOLEAN) = false OR post_reads.id  IS NULL )
 AND ( CAST(sqlc.arg('filter_by_saved') AS BOOLEAN)  = false OR post_saves.id IS NOT NULL )
-ORDER BY published_at DESC LIMIT sqlc.arg('limit_count');
\ No newline at end of file
+ORDER BY published_at DESC LIMIT sqlc.arg('limit_count');
+
+-- name: SearchPostsFullText :many
+SELECT posts.id as id, posts.title as title, posts.url as url, posts.description as description, posts.published_at as published_at, feeds.name as feed_name, feeds.id as feed_id, post_saves.created_at as saved_at, post_reads.created_at as read_at,
+    posts.thumbnail_url as thumbnail_url, post_enclosures.url as enclosure_url, post_enclosures.mime_type as enclosure_mime_type, post_enclosures.duration_seconds as enclosure_duration_seconds,
+    CAST(posts.content_html IS NOT NULL AS BOOLEAN) as has_content,
+    CAST(highlight(posts_fts, 0, char(2), char(3)) AS TEXT) as title_highlight,
+    CAST(snippet(posts_fts, 1, char(2), char(3), '…', 32) AS TEXT) as description_snippet
+FROM posts_fts
+JOIN posts ON posts.seq = posts_fts.rowid
+JOIN feeds ON posts.feed_id = feeds.id
+LEFT JOIN post_enclosures ON post_enclosures.id = (
+    SELECT media.id FROM post_enclosures AS media
+    WHERE media.post_id = posts.id AND (media.mime_type LIKE 'audio/%' OR media.mime_type LIKE 'video/%')
+    ORDER BY media.rowid LIMIT 1
+)
+LEFT JOIN post_saves ON posts.id = post_saves.post_id AND post_saves.user_id = @user_id
+LEFT JOIN post_reads ON posts.id = post_reads.post_id AND post_reads.user_id = @user_id
+WHERE posts_fts MATCH sqlc.arg('match_query')
+AND posts.feed_id IN (SELECT feed_id FROM feed_follows WHERE feed_follows.user_id = @user_id)
+AND ( CAST(sqlc.arg('filter_by_unread') AS BOOLEAN) = false OR post_reads.id  IS NULL )
+AND ( CAST(sqlc.arg('filter_by_saved') AS BOOLEAN)  = false OR post_saves.id IS NOT NULL )
+ORDER BY bm25(posts_fts, 10.0, 1.0, 3.0), posts.published_at DESC LIMIT sqlc.arg('limit_count');
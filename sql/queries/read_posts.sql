@@ -1,5 +1,11 @@
 -- name: SaveReadPost :exec
-INSERT INTO post_reads (id, post_id, user_id) VALUES (@id, @post_id, @user_id);
+INSERT INTO post_reads (id, post_id, user_id) VALUES (@id, @post_id, @user_id)
+ON CONFLICT (post_id, user_id) DO NOTHING;
 
 -- name: DeleteReadPost :exec
-DELETE FROM post_reads WHERE user_id = @user_id AND post_id = @post_id;
\ No newline at end of file
+DELETE FROM post_reads WHERE user_id = @user_id AND post_id = @post_id;
+
+-- name: DeleteReadPostsForPost :exec
+DELETE FROM post_reads
+WHERE post_id = @post_id
+  AND user_id IN (SELECT user_id FROM feed_follows WHERE feed_id = @feed_id AND mark_unread_on_update);
\ No newline at end of file
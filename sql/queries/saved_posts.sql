@@ -1,5 +1,6 @@
 -- name: SaveSavedPost :exec
-INSERT INTO post_saves (id, post_id, user_id) VALUES (?, ?, ?);
+INSERT INTO post_saves (id, post_id, user_id) VALUES (?, ?, ?)
+ON CONFLICT (post_id, user_id) DO NOTHING;
 
 -- name: DeleteSavedPost :exec
 DELETE FROM post_saves WHERE post_id = ? AND user_id = ?; 
@@ -0,0 +1,13 @@
+-- name: CreateSession :exec
+INSERT INTO sessions (id, user_id, expires_at) VALUES (@id, @user_id, @expires_at);
+
+-- name: GetSessionUser :one
+SELECT users.* FROM sessions
+JOIN users ON sessions.user_id = users.id
+WHERE sessions.id = @id AND sessions.expires_at > @now;
+
+-- name: DeleteSession :exec
+DELETE FROM sessions WHERE id = @id;
+
+-- name: DeleteExpiredSessions :exec
+DELETE FROM sessions WHERE expires_at <= @now;
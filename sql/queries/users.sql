@@ -11,4 +11,17 @@ WHERE name = @name;
 SELECT * FROM users;
 
 -- name: DeleteUsers :exec
-DELETE FROM users;
\ No newline at end of file
+DELETE FROM users;
+
+-- name: RegisterUser :one
+INSERT INTO users (id, name, password_hash)
+VALUES (@id, @name, @password_hash)
+RETURNING *;
+
+-- name: GetUserByID :one
+SELECT * FROM users
+WHERE id = @id;
+
+-- name: SetUserPassword :exec
+UPDATE users SET password_hash = @password_hash, updated_at = CURRENT_TIMESTAMP
+WHERE name = @name;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE feeds ADD COLUMN next_fetch_after TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN next_fetch_after;
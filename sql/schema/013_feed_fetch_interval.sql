@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE feeds ADD COLUMN fetch_interval_seconds INTEGER;
+ALTER TABLE feeds ADD COLUMN fetch_interval_override_seconds INTEGER;
+ALTER TABLE feeds ADD COLUMN skip_hours TEXT;
+ALTER TABLE feeds ADD COLUMN skip_days TEXT;
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN fetch_interval_seconds;
+ALTER TABLE feeds DROP COLUMN fetch_interval_override_seconds;
+ALTER TABLE feeds DROP COLUMN skip_hours;
+ALTER TABLE feeds DROP COLUMN skip_days;
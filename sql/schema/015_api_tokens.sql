@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE api_tokens (
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP,
+    name TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX api_tokens_user_id ON api_tokens (user_id);
+
+-- +goose Down
+DROP TABLE api_tokens;
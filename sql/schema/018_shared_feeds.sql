@@ -0,0 +1,61 @@
+-- +goose Up
+-- Feeds are shared between everyone who follows them, so the user who first
+-- added a feed no longer owns it. SQLite cannot relax a column constraint in
+-- place, so the table is rebuilt with user_id nullable.
+CREATE TABLE feeds_new (
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    name VARCHAR(255) NOT NULL,
+    url VARCHAR(255) NOT NULL UNIQUE,
+    user_id TEXT REFERENCES users(id) ON DELETE SET NULL,
+    last_fetched_at TIMESTAMP,
+    description TEXT,
+    etag TEXT,
+    last_modified TEXT,
+    last_error TEXT,
+    consecutive_failures INTEGER NOT NULL DEFAULT 0,
+    last_success_at TIMESTAMP,
+    next_fetch_after TIMESTAMP,
+    fetch_interval_seconds INTEGER,
+    fetch_interval_override_seconds INTEGER,
+    skip_hours TEXT,
+    skip_days TEXT
+);
+
+INSERT INTO feeds_new (id, created_at, updated_at, name, url, user_id, last_fetched_at, description, etag, last_modified, last_error, consecutive_failures, last_success_at, next_fetch_after, fetch_interval_seconds, fetch_interval_override_seconds, skip_hours, skip_days)
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, description, etag, last_modified, last_error, consecutive_failures, last_success_at, next_fetch_after, fetch_interval_seconds, fetch_interval_override_seconds, skip_hours, skip_days
+FROM feeds;
+
+DROP TABLE feeds;
+ALTER TABLE feeds_new RENAME TO feeds;
+
+-- +goose Down
+CREATE TABLE feeds_old (
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    name VARCHAR(255) NOT NULL,
+    url VARCHAR(255) NOT NULL UNIQUE,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    last_fetched_at TIMESTAMP,
+    description TEXT,
+    etag TEXT,
+    last_modified TEXT,
+    last_error TEXT,
+    consecutive_failures INTEGER NOT NULL DEFAULT 0,
+    last_success_at TIMESTAMP,
+    next_fetch_after TIMESTAMP,
+    fetch_interval_seconds INTEGER,
+    fetch_interval_override_seconds INTEGER,
+    skip_hours TEXT,
+    skip_days TEXT
+);
+
+INSERT INTO feeds_old (id, created_at, updated_at, name, url, user_id, last_fetched_at, description, etag, last_modified, last_error, consecutive_failures, last_success_at, next_fetch_after, fetch_interval_seconds, fetch_interval_override_seconds, skip_hours, skip_days)
+SELECT id, created_at, updated_at, name, url, user_id, last_fetched_at, description, etag, last_modified, last_error, consecutive_failures, last_success_at, next_fetch_after, fetch_interval_seconds, fetch_interval_override_seconds, skip_hours, skip_days
+FROM feeds
+WHERE user_id IS NOT NULL;
+
+DROP TABLE feeds;
+ALTER TABLE feeds_old RENAME TO feeds;
@@ -0,0 +1,95 @@
+-- +goose Up
+-- Full-text index over posts. Rows share their rowid with posts so matches
+-- join straight back to the post; the feed name is copied in so searches can
+-- match it too. SQLite may renumber rowids on VACUUM while a table's primary
+-- key is its TEXT id, so posts is rebuilt with an INTEGER PRIMARY KEY, seq,
+-- which makes the rowid stable.
+CREATE TABLE posts_new (
+    id TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    title TEXT NOT NULL,
+    url TEXT NOT NULL UNIQUE,
+    description TEXT,
+    published_at TIMESTAMP NOT NULL,
+    feed_id TEXT NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    seq INTEGER PRIMARY KEY
+);
+
+INSERT INTO posts_new (seq, id, created_at, updated_at, title, url, description, published_at, feed_id)
+SELECT rowid, id, created_at, updated_at, title, url, description, published_at, feed_id
+FROM posts;
+
+DROP TABLE posts;
+ALTER TABLE posts_new RENAME TO posts;
+
+CREATE VIRTUAL TABLE posts_fts USING fts5(
+    title,
+    description,
+    feed_name,
+    tokenize = 'porter unicode61 remove_diacritics 2'
+);
+
+INSERT INTO posts_fts (rowid, title, description, feed_name)
+SELECT posts.seq, posts.title, coalesce(posts.description, ''), feeds.name
+FROM posts
+JOIN feeds ON posts.feed_id = feeds.id;
+
+-- +goose StatementBegin
+CREATE TRIGGER posts_fts_insert AFTER INSERT ON posts BEGIN
+    INSERT INTO posts_fts (rowid, title, description, feed_name)
+    VALUES (new.seq, new.title, coalesce(new.description, ''), (SELECT name FROM feeds WHERE id = new.feed_id));
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER posts_fts_update AFTER UPDATE OF title, description, feed_id ON posts BEGIN
+    UPDATE posts_fts
+    SET title = new.title,
+        description = coalesce(new.description, ''),
+        feed_name = (SELECT name FROM feeds WHERE id = new.feed_id)
+    WHERE rowid = new.seq;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER posts_fts_delete AFTER DELETE ON posts BEGIN
+    DELETE FROM posts_fts WHERE rowid = old.seq;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER feeds_fts_rename AFTER UPDATE OF name ON feeds BEGIN
+    UPDATE posts_fts
+    SET feed_name = new.name
+    WHERE rowid IN (SELECT seq FROM posts WHERE feed_id = new.id);
+END;
+-- +goose StatementEnd
+
+CREATE INDEX posts_feed_id ON posts (feed_id);
+
+-- +goose Down
+DROP INDEX posts_feed_id;
+DROP TRIGGER feeds_fts_rename;
+DROP TRIGGER posts_fts_delete;
+DROP TRIGGER posts_fts_update;
+DROP TRIGGER posts_fts_insert;
+DROP TABLE posts_fts;
+
+CREATE TABLE posts_old (
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    title TEXT NOT NULL,
+    url TEXT NOT NULL UNIQUE,
+    description TEXT,
+    published_at TIMESTAMP NOT NULL,
+    feed_id TEXT NOT NULL REFERENCES feeds(id) ON DELETE CASCADE
+);
+
+INSERT INTO posts_old (id, created_at, updated_at, title, url, description, published_at, feed_id)
+SELECT id, created_at, updated_at, title, url, description, published_at, feed_id
+FROM posts;
+
+DROP TABLE posts;
+ALTER TABLE posts_old RENAME TO posts;
@@ -0,0 +1,119 @@
+-- +goose Up
+-- Posts are identified within their feed by an identity key built from the
+-- item's GUID, falling back to its link, instead of by a globally unique URL.
+-- Existing posts are keyed by link and adopt their GUID on the next fetch.
+-- SQLite cannot drop a UNIQUE constraint in place, so the table is rebuilt,
+-- keeping seq so the full-text index still lines up.
+DROP TRIGGER feeds_fts_rename;
+
+CREATE TABLE posts_new (
+    id TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    title TEXT NOT NULL,
+    url TEXT NOT NULL,
+    description TEXT,
+    published_at TIMESTAMP NOT NULL,
+    feed_id TEXT NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    seq INTEGER PRIMARY KEY,
+    guid TEXT,
+    identity_key TEXT NOT NULL,
+    UNIQUE (feed_id, identity_key)
+);
+
+INSERT INTO posts_new (seq, id, created_at, updated_at, title, url, description, published_at, feed_id, guid, identity_key)
+SELECT seq, id, created_at, updated_at, title, url, description, published_at, feed_id, NULL, 'link:' || url
+FROM posts;
+
+DROP TABLE posts;
+ALTER TABLE posts_new RENAME TO posts;
+
+-- +goose StatementBegin
+CREATE TRIGGER posts_fts_insert AFTER INSERT ON posts BEGIN
+    INSERT INTO posts_fts (rowid, title, description, feed_name)
+    VALUES (new.seq, new.title, coalesce(new.description, ''), (SELECT name FROM feeds WHERE id = new.feed_id));
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER posts_fts_update AFTER UPDATE OF title, description, feed_id ON posts BEGIN
+    UPDATE posts_fts
+    SET title = new.title,
+        description = coalesce(new.description, ''),
+        feed_name = (SELECT name FROM feeds WHERE id = new.feed_id)
+    WHERE rowid = new.seq;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER posts_fts_delete AFTER DELETE ON posts BEGIN
+    DELETE FROM posts_fts WHERE rowid = old.seq;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER feeds_fts_rename AFTER UPDATE OF name ON feeds BEGIN
+    UPDATE posts_fts
+    SET feed_name = new.name
+    WHERE rowid IN (SELECT seq FROM posts WHERE feed_id = new.id);
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP TRIGGER feeds_fts_rename;
+
+CREATE TABLE posts_old (
+    id TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    title TEXT NOT NULL,
+    url TEXT NOT NULL UNIQUE,
+    description TEXT,
+    published_at TIMESTAMP NOT NULL,
+    feed_id TEXT NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    seq INTEGER PRIMARY KEY
+);
+
+-- Only the oldest post for each URL survives
+INSERT INTO posts_old (seq, id, created_at, updated_at, title, url, description, published_at, feed_id)
+SELECT seq, id, created_at, updated_at, title, url, description, published_at, feed_id
+FROM posts
+WHERE seq IN (SELECT min(seq) FROM posts GROUP BY url);
+
+DELETE FROM posts_fts WHERE rowid NOT IN (SELECT seq FROM posts_old);
+
+DROP TABLE posts;
+ALTER TABLE posts_old RENAME TO posts;
+
+CREATE INDEX posts_feed_id ON posts (feed_id);
+
+-- +goose StatementBegin
+CREATE TRIGGER posts_fts_insert AFTER INSERT ON posts BEGIN
+    INSERT INTO posts_fts (rowid, title, description, feed_name)
+    VALUES (new.seq, new.title, coalesce(new.description, ''), (SELECT name FROM feeds WHERE id = new.feed_id));
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER posts_fts_update AFTER UPDATE OF title, description, feed_id ON posts BEGIN
+    UPDATE posts_fts
+    SET title = new.title,
+        description = coalesce(new.description, ''),
+        feed_name = (SELECT name FROM feeds WHERE id = new.feed_id)
+    WHERE rowid = new.seq;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER posts_fts_delete AFTER DELETE ON posts BEGIN
+    DELETE FROM posts_fts WHERE rowid = old.seq;
+END;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER feeds_fts_rename AFTER UPDATE OF name ON feeds BEGIN
+    UPDATE posts_fts
+    SET feed_name = new.name
+    WHERE rowid IN (SELECT seq FROM posts WHERE feed_id = new.id);
+END;
+-- +goose StatementEnd
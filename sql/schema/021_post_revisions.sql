@@ -0,0 +1,24 @@
+-- +goose Up
+-- content_hash covers what a reader sees of a post, so a changed hash on a
+-- later fetch means the publisher edited it. The previous version is kept in
+-- post_revisions.
+ALTER TABLE posts ADD COLUMN content_hash TEXT;
+
+CREATE TABLE post_revisions (
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    post_id TEXT NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    title TEXT NOT NULL,
+    description TEXT,
+    published_at TIMESTAMP NOT NULL,
+    content_hash TEXT
+);
+
+CREATE INDEX post_revisions_post_id ON post_revisions (post_id);
+
+ALTER TABLE feeds ADD COLUMN mark_unread_on_update BOOLEAN NOT NULL DEFAULT false;
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN mark_unread_on_update;
+DROP TABLE post_revisions;
+ALTER TABLE posts DROP COLUMN content_hash;
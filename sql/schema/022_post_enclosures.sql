@@ -0,0 +1,20 @@
+-- +goose Up
+-- Media files attached to posts, such as podcast episodes. Posts keep a
+-- thumbnail of their own, since it often comes from elsewhere in the item.
+ALTER TABLE posts ADD COLUMN thumbnail_url TEXT;
+
+CREATE TABLE post_enclosures (
+    id TEXT PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    post_id TEXT NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    mime_type TEXT,
+    length INTEGER,
+    duration_seconds INTEGER,
+    UNIQUE(post_id, url)
+);
+
+-- +goose Down
+DROP TABLE post_enclosures;
+ALTER TABLE posts DROP COLUMN thumbnail_url;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- Posts keep their body as sanitized HTML next to the plain-text
+-- description, and revisions keep the body they replaced.
+ALTER TABLE posts ADD COLUMN content_html TEXT;
+ALTER TABLE post_revisions ADD COLUMN content_html TEXT;
+
+-- Descriptions used to be stored as raw HTML and hashes didn't cover the
+-- body, so no stored hash matches what a fetch now produces. Clearing them
+-- lets the next fetch rewrite each post without recording an edit.
+UPDATE posts SET content_hash = NULL;
+
+-- +goose Down
+ALTER TABLE post_revisions DROP COLUMN content_html;
+ALTER TABLE posts DROP COLUMN content_html;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- A permanent redirect is only followed for good once it has been seen on
+-- redirect_count fetches in a row.
+ALTER TABLE feeds ADD COLUMN redirect_url TEXT;
+ALTER TABLE feeds ADD COLUMN redirect_count INTEGER NOT NULL DEFAULT 0;
+-- Dead feeds are gone or have failed for too long, and are no longer fetched.
+ALTER TABLE feeds ADD COLUMN dead_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN redirect_url;
+ALTER TABLE feeds DROP COLUMN redirect_count;
+ALTER TABLE feeds DROP COLUMN dead_at;
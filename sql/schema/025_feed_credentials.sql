@@ -0,0 +1,7 @@
+-- +goose Up
+-- Credentials and extra request headers for feeds that aren't public,
+-- encrypted with the server's key and bound to the feed's id.
+ALTER TABLE feeds ADD COLUMN credentials BLOB;
+
+-- +goose Down
+ALTER TABLE feeds DROP COLUMN credentials;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- Each follower sets their own refresh interval. feeds.fetch_interval_override_seconds
+-- keeps the shortest of them, which is what the feed is polled at.
+ALTER TABLE feed_follows ADD COLUMN fetch_interval_override_seconds INTEGER;
+
+UPDATE feed_follows
+SET fetch_interval_override_seconds = (
+    SELECT feeds.fetch_interval_override_seconds FROM feeds WHERE feeds.id = feed_follows.feed_id
+);
+
+-- +goose Down
+ALTER TABLE feed_follows DROP COLUMN fetch_interval_override_seconds;
@@ -0,0 +1,21 @@
+-- +goose Up
+-- Whether edited posts are marked unread again is up to each follower, as it
+-- only changes their own read state.
+ALTER TABLE feed_follows ADD COLUMN mark_unread_on_update BOOLEAN NOT NULL DEFAULT false;
+
+UPDATE feed_follows
+SET mark_unread_on_update = (
+    SELECT feeds.mark_unread_on_update FROM feeds WHERE feeds.id = feed_follows.feed_id
+);
+
+ALTER TABLE feeds DROP COLUMN mark_unread_on_update;
+
+-- +goose Down
+ALTER TABLE feeds ADD COLUMN mark_unread_on_update BOOLEAN NOT NULL DEFAULT false;
+
+UPDATE feeds
+SET mark_unread_on_update = EXISTS (
+    SELECT 1 FROM feed_follows WHERE feed_follows.feed_id = feeds.id AND feed_follows.mark_unread_on_update
+);
+
+ALTER TABLE feed_follows DROP COLUMN mark_unread_on_update;